@@ -15,6 +15,8 @@
 package main
 
 import (
+	"os"
+
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that `exec-entrypoint` and `run` can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
@@ -22,10 +24,16 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/operator-framework/operator-sdk/internal/cmd/operator-sdk/cli"
+	openshiftv1 "github.com/operator-framework/operator-sdk/internal/plugins/openshift/v1"
 )
 
 func main() {
 	if err := cli.Run(); err != nil {
+		// Dry runs and verification exit with a distinct code if images need to be substituted.
+		if code := openshiftv1.ExitCode(err); code != 1 {
+			log.Error(err)
+			os.Exit(code)
+		}
 		log.Fatal(err)
 	}
 }
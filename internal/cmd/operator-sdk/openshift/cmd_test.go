@@ -0,0 +1,38 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openshift
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Running an openshift command", func() {
+	Describe("NewCmd", func() {
+		It("builds a cobra command with the correct subcommands", func() {
+			cmd := NewCmd()
+			Expect(cmd).NotTo(BeNil())
+			Expect(cmd.Use).To(Equal("openshift"))
+			Expect(cmd.Short).NotTo(BeEmpty())
+
+			subcommands := cmd.Commands()
+			Expect(subcommands).To(HaveLen(4))
+			Expect(subcommands[0].Use).To(Equal("config"))
+			Expect(subcommands[1].Use).To(Equal("rollback"))
+			Expect(subcommands[2].Use).To(Equal("verify"))
+			Expect(subcommands[3].Use).To(Equal("version"))
+		})
+	})
+})
@@ -0,0 +1,85 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openshift
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+	"sigs.k8s.io/yaml"
+
+	openshiftv1 "github.com/operator-framework/operator-sdk/internal/plugins/openshift/v1"
+)
+
+// printProjectConfigFlag prints the plugin config recorded in the PROJECT file instead of the effective config.
+const printProjectConfigFlag = "print-project-config"
+
+func newConfigCmd() *cobra.Command {
+	var printProject bool
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Print the effective configuration of the OpenShift plugin",
+		Long: `Print the OpenShift plugin configuration resolved for the project in the current directory as YAML,
+with the source each setting was resolved from. In order of precedence, settings are read from
+.openshiftplugin.yaml, the plugin config recorded in the PROJECT file, the environment, and defaults.
+Flags given to the plugin's subcommands override all of these. No files are changed.
+
+With --print-project-config, only the plugin config recorded in the PROJECT file is printed,
+exactly as the plugin reads it back, without applying defaults or any other source.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fs := machinery.Filesystem{FS: afero.NewOsFs()}
+			if printProject {
+				return printProjectConfig(cmd.OutOrStdout(), fs)
+			}
+			return printConfig(cmd.OutOrStdout(), fs)
+		},
+	}
+	cmd.Flags().BoolVar(&printProject, printProjectConfigFlag, false,
+		"print the plugin config recorded in the PROJECT file instead of the effective config")
+	return cmd
+}
+
+// printConfig writes the effective plugin config of the project in fs to w as YAML.
+func printConfig(w io.Writer, fs machinery.Filesystem) error {
+	effective, err := openshiftv1.ResolveConfig(fs)
+	if err != nil {
+		return err
+	}
+	return writeYAML(w, effective)
+}
+
+// printProjectConfig writes the plugin config recorded in the PROJECT file in fs to w as YAML.
+func printProjectConfig(w io.Writer, fs machinery.Filesystem) error {
+	cfg, err := openshiftv1.ProjectConfig(fs)
+	if err != nil {
+		return err
+	}
+	return writeYAML(w, cfg)
+}
+
+func writeYAML(w io.Writer, v interface{}) error {
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("error encoding config: %v", err)
+	}
+	if _, err := w.Write(b); err != nil {
+		return fmt.Errorf("error writing config: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openshift
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+var _ = Describe("Running the openshift config command", func() {
+	var fs machinery.Filesystem
+
+	BeforeEach(func() {
+		fs = machinery.Filesystem{FS: afero.NewMemMapFs()}
+	})
+
+	It("prints the effective config and the source of each setting", func() {
+		Expect(afero.WriteFile(fs.FS, ".openshiftplugin.yaml", []byte("registry: mirror.example.com\n"), 0644)).To(Succeed())
+		out := &bytes.Buffer{}
+		Expect(printConfig(out, fs)).To(Succeed())
+		Expect(out.String()).To(ContainSubstring("config:\n"))
+		Expect(out.String()).To(ContainSubstring("  registry: mirror.example.com\n"))
+		Expect(out.String()).To(ContainSubstring("sources:\n"))
+		Expect(out.String()).To(ContainSubstring("  ocpVersion: default\n"))
+		Expect(out.String()).To(ContainSubstring("  registry: .openshiftplugin.yaml\n"))
+	})
+
+	It("prints only the plugin config recorded in the PROJECT file with --print-project-config", func() {
+		Expect(afero.WriteFile(fs.FS, "PROJECT", []byte(projectWithPluginConfig), 0644)).To(Succeed())
+		Expect(afero.WriteFile(fs.FS, ".openshiftplugin.yaml", []byte("registry: mirror.example.com\n"), 0644)).To(Succeed())
+		out := &bytes.Buffer{}
+		Expect(printProjectConfig(out, fs)).To(Succeed())
+		Expect(out.String()).To(Equal("ocpVersion: \"4.14\"\n"))
+	})
+
+	It("fails to print the project config without a PROJECT file", func() {
+		Expect(printProjectConfig(&bytes.Buffer{}, fs)).To(MatchError("no PROJECT file found"))
+	})
+
+	It("fails on an invalid local config file", func() {
+		Expect(afero.WriteFile(fs.FS, ".openshiftplugin.yaml", []byte("- registry\n"), 0644)).To(Succeed())
+		Expect(printConfig(&bytes.Buffer{}, fs)).To(MatchError(ContainSubstring("error parsing .openshiftplugin.yaml")))
+	})
+})
+
+const projectWithPluginConfig = `domain: example.com
+layout:
+- go.kubebuilder.io/v3
+plugins:
+  sdk.x-openshift.io/v1:
+    ocpVersion: "4.14"
+projectName: example
+version: "3"
+`
@@ -0,0 +1,52 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openshift
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+
+	openshiftv1 "github.com/operator-framework/operator-sdk/internal/plugins/openshift/v1"
+)
+
+func newRollbackCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "rollback",
+		Short: "Restore files backed up by the OpenShift plugin",
+		Long: `Restore each file of the project in the current directory from the <file>.orig backup written
+by a previous run of the OpenShift plugin with --backup, undoing its image substitutions, then remove
+the backups. Backups are only removed once every file has been restored.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return rollback(cmd.OutOrStdout(), machinery.Filesystem{FS: afero.NewOsFs()})
+		},
+	}
+}
+
+// rollback restores each backed up file in fs, writing its path to w.
+func rollback(w io.Writer, fs machinery.Filesystem) error {
+	restored, err := openshiftv1.RollbackBackups(fs)
+	if err != nil {
+		return err
+	}
+	for _, filePath := range restored {
+		fmt.Fprintf(w, "Restored %s\n", filePath)
+	}
+	return nil
+}
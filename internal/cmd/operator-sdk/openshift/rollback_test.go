@@ -0,0 +1,47 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openshift
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+var _ = Describe("Running the openshift rollback command", func() {
+	var fs machinery.Filesystem
+
+	BeforeEach(func() {
+		fs = machinery.Filesystem{FS: afero.NewMemMapFs()}
+	})
+
+	It("restores backed up files and prints their paths", func() {
+		Expect(afero.WriteFile(fs.FS, "Dockerfile", []byte("FROM registry.access.redhat.com/ubi8/ubi-minimal:8.8\n"), 0644)).To(Succeed())
+		Expect(afero.WriteFile(fs.FS, "Dockerfile.orig", []byte("FROM gcr.io/distroless/static:nonroot\n"), 0644)).To(Succeed())
+		out := &bytes.Buffer{}
+		Expect(rollback(out, fs)).To(Succeed())
+		Expect(out.String()).To(Equal("Restored Dockerfile\n"))
+		b, err := afero.ReadFile(fs.FS, "Dockerfile")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(Equal("FROM gcr.io/distroless/static:nonroot\n"))
+	})
+
+	It("fails if there are no backups", func() {
+		Expect(rollback(&bytes.Buffer{}, fs)).To(MatchError(ContainSubstring("no backups found")))
+	})
+})
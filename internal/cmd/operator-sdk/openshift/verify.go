@@ -0,0 +1,63 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openshift
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+
+	openshiftv1 "github.com/operator-framework/operator-sdk/internal/plugins/openshift/v1"
+)
+
+func newVerifyCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "verify",
+		Short: "Verify that a project references no upstream images",
+		Long: `Verify that the files of the project in the current directory that the OpenShift plugin
+substitutes images in reference no upstream images, printing each upstream image found.
+
+Exits with 0 if no upstream images are found, with ` + strconv.Itoa(openshiftv1.ExitCodeSubstitutionsNeeded) + ` if any are,
+and with 1 on any other error.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return verifyImages(cmd.Context(), cmd.OutOrStdout(), machinery.Filesystem{FS: afero.NewOsFs()})
+		},
+	}
+}
+
+// verifyImages writes each upstream image referenced in fs to w, and returns an ExitError
+// if any was found.
+func verifyImages(ctx context.Context, w io.Writer, fs machinery.Filesystem) error {
+	images, err := openshiftv1.VerifyImages(ctx, fs)
+	if err != nil {
+		return err
+	}
+	for _, image := range images {
+		fmt.Fprintln(w, image)
+	}
+	if len(images) > 0 {
+		return &openshiftv1.ExitError{
+			Code: openshiftv1.ExitCodeSubstitutionsNeeded,
+			Err:  fmt.Errorf("found %d references to upstream images", len(images)),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openshift
+
+import (
+	"bytes"
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+
+	openshiftv1 "github.com/operator-framework/operator-sdk/internal/plugins/openshift/v1"
+)
+
+var _ = Describe("Running the openshift verify command", func() {
+	var fs machinery.Filesystem
+
+	BeforeEach(func() {
+		fs = machinery.Filesystem{FS: afero.NewMemMapFs()}
+	})
+
+	It("succeeds if no upstream images are referenced", func() {
+		Expect(afero.WriteFile(fs.FS, "Dockerfile", []byte("FROM registry.access.redhat.com/ubi8/ubi-minimal:8.8\n"), 0644)).To(Succeed())
+		out := &bytes.Buffer{}
+		Expect(verifyImages(context.Background(), out, fs)).To(Succeed())
+		Expect(out.String()).To(BeEmpty())
+	})
+
+	It("prints each upstream image and fails with the substitutions needed exit code", func() {
+		Expect(afero.WriteFile(fs.FS, "Dockerfile", []byte("FROM golang:1.20\nFROM gcr.io/distroless/static:nonroot\n"), 0644)).To(Succeed())
+		out := &bytes.Buffer{}
+		err := verifyImages(context.Background(), out, fs)
+		Expect(err).To(MatchError("found 1 references to upstream images"))
+		Expect(openshiftv1.ExitCode(err)).To(Equal(openshiftv1.ExitCodeSubstitutionsNeeded))
+		Expect(out.String()).To(Equal("Dockerfile:2: gcr.io/distroless/static:nonroot\n"))
+	})
+
+	It("stops if the context is canceled", func() {
+		Expect(afero.WriteFile(fs.FS, "Dockerfile", []byte("FROM gcr.io/distroless/static:nonroot\n"), 0644)).To(Succeed())
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := verifyImages(ctx, &bytes.Buffer{}, fs)
+		Expect(err).To(MatchError(context.Canceled))
+		Expect(openshiftv1.ExitCode(err)).To(Equal(1))
+	})
+})
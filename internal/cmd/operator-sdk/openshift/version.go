@@ -0,0 +1,78 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openshift
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	openshiftv1 "github.com/operator-framework/operator-sdk/internal/plugins/openshift/v1"
+)
+
+const (
+	textOutput = "text"
+	jsonOutput = "json"
+)
+
+func newVersionCmd() *cobra.Command {
+	var output string
+	cmd := &cobra.Command{
+		Use:   "version",
+		Short: "Print the OCP and UBI versions the OpenShift plugin tags downstream images with",
+		Long: `Print the default OCP release and UBI versions the OpenShift plugin tags downstream images with,
+and the UBI versions known to work with each OCP release.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return printVersions(cmd.OutOrStdout(), output, openshiftv1.DefaultVersions())
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", textOutput, "output format, one of "+textOutput+", "+jsonOutput)
+	return cmd
+}
+
+// printVersions writes versions to w in the given output format.
+func printVersions(w io.Writer, output string, versions openshiftv1.Versions) error {
+	switch output {
+	case jsonOutput:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(versions); err != nil {
+			return fmt.Errorf("error writing versions: %v", err)
+		}
+		return nil
+	case textOutput:
+	default:
+		return fmt.Errorf("invalid --output value %q: must be one of %s, %s", output, textOutput, jsonOutput)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "Default OCP version:\t%s\n", versions.OCPVersion)
+	fmt.Fprintf(tw, "Default UBI 8 version:\t%s\n", versions.UBIVersion)
+	fmt.Fprintf(tw, "Default UBI 9 version:\t%s\n", versions.UBI9Version)
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "OCP\tUBI 8\tUBI 9")
+	for _, release := range versions.Releases {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", release.OCPVersion, release.UBIVersion, release.UBI9Version)
+	}
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("error writing versions: %v", err)
+	}
+	return nil
+}
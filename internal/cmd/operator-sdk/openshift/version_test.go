@@ -0,0 +1,74 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openshift
+
+import (
+	"bytes"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	openshiftv1 "github.com/operator-framework/operator-sdk/internal/plugins/openshift/v1"
+)
+
+var _ = Describe("Running the openshift version command", func() {
+	versions := openshiftv1.Versions{
+		OCPVersion:  "4.14",
+		UBIVersion:  "8.8",
+		UBI9Version: "9.2",
+		Releases: []openshiftv1.ReleaseVersions{
+			{OCPVersion: "4.14", UBIVersion: "8.8", UBI9Version: "9.2"},
+			{OCPVersion: "4.15", UBIVersion: "8.9", UBI9Version: "9.3"},
+		},
+	}
+
+	It("prints versions as text", func() {
+		out := &bytes.Buffer{}
+		Expect(printVersions(out, textOutput, versions)).To(Succeed())
+		Expect(out.String()).To(Equal(`Default OCP version:    4.14
+Default UBI 8 version:  8.8
+Default UBI 9 version:  9.2
+
+OCP   UBI 8  UBI 9
+4.14  8.8    9.2
+4.15  8.9    9.3
+`))
+	})
+
+	It("prints versions as JSON", func() {
+		out := &bytes.Buffer{}
+		Expect(printVersions(out, jsonOutput, versions)).To(Succeed())
+		var decoded openshiftv1.Versions
+		Expect(json.Unmarshal(out.Bytes(), &decoded)).To(Succeed())
+		Expect(decoded).To(Equal(versions))
+		Expect(out.String()).To(ContainSubstring(`"ocpVersion": "4.14"`))
+	})
+
+	It("rejects unknown output formats", func() {
+		Expect(printVersions(&bytes.Buffer{}, "yaml", versions)).To(MatchError(ContainSubstring("--output")))
+	})
+
+	It("prints the plugin's versions", func() {
+		cmd := newVersionCmd()
+		out := &bytes.Buffer{}
+		cmd.SetOut(out)
+		cmd.SetArgs([]string{"--output", jsonOutput})
+		Expect(cmd.Execute()).To(Succeed())
+		var decoded openshiftv1.Versions
+		Expect(json.Unmarshal(out.Bytes(), &decoded)).To(Succeed())
+		Expect(decoded).To(Equal(openshiftv1.DefaultVersions()))
+	})
+})
@@ -0,0 +1,75 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// dockerfileDirectiveRE matches a Dockerfile parser directive, which must precede any other comment.
+var dockerfileDirectiveRE = regexp.MustCompile(`^#\s*[a-zA-Z]+\s*=`)
+
+// commentPrefix returns the line comment prefix of filePath's syntax, or "" if it is not known.
+func commentPrefix(filePath string) string {
+	switch {
+	case filepath.Base(filePath) == "go.mod":
+		return "//"
+	case isYAMLFile(filePath), isDockerfile(filePath):
+		return "#"
+	}
+	return ""
+}
+
+// isDockerfile reports whether filePath is named like a Dockerfile.
+func isDockerfile(filePath string) bool {
+	base := filepath.Base(filePath)
+	return base == "Dockerfile" || base == "Containerfile" || strings.HasSuffix(base, ".Dockerfile")
+}
+
+// annotationText returns the text of the annotation recording that images were substituted for OCP ocpVersion.
+func annotationText(ocpVersion string) string {
+	return fmt.Sprintf("modified by %s plugin, ocp=%s", pluginKey, ocpVersion)
+}
+
+// annotate returns b with a comment recording that images were substituted for OCP ocpVersion
+// as its first line, after any Dockerfile parser directives. Any existing annotation is replaced,
+// so files are annotated once. Files of unknown syntax are returned unchanged.
+func annotate(filePath string, b []byte, ocpVersion string) []byte {
+	prefix := commentPrefix(filePath)
+	if prefix == "" {
+		return b
+	}
+	marker := []byte(prefix + " modified by " + pluginKey + " plugin")
+
+	lines := bytes.SplitAfter(b, []byte("\n"))
+	out := make([]byte, 0, len(b)+len(marker)+16)
+	i := 0
+	if isDockerfile(filePath) {
+		for ; i < len(lines) && dockerfileDirectiveRE.Match(lines[i]); i++ {
+			out = append(out, lines[i]...)
+		}
+	}
+	out = append(out, prefix+" "+annotationText(ocpVersion)+"\n"...)
+	for ; i < len(lines); i++ {
+		if !bytes.HasPrefix(lines[i], marker) {
+			out = append(out, lines[i]...)
+		}
+	}
+	return out
+}
@@ -0,0 +1,68 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+var _ = Describe("annotate", func() {
+	It("adds a comment in each file's syntax", func() {
+		Expect(string(annotate("Dockerfile", []byte("FROM a\n"), "4.14"))).To(
+			Equal("# modified by sdk.x-openshift.io/v1 plugin, ocp=4.14\nFROM a\n"))
+		Expect(string(annotate(authProxyPatchPath, []byte("image: a\n"), "4.14"))).To(
+			Equal("# modified by sdk.x-openshift.io/v1 plugin, ocp=4.14\nimage: a\n"))
+		Expect(string(annotate("go.mod", []byte("module a\n"), "4.14"))).To(
+			Equal("// modified by sdk.x-openshift.io/v1 plugin, ocp=4.14\nmodule a\n"))
+	})
+
+	It("leaves files of unknown syntax unchanged", func() {
+		Expect(string(annotate("values.json", []byte(`{"image": "a"}`), "4.14"))).To(Equal(`{"image": "a"}`))
+	})
+
+	It("keeps Dockerfile parser directives first", func() {
+		Expect(string(annotate("Dockerfile", []byte("# syntax=docker/dockerfile:1\nFROM a\n"), "4.14"))).To(
+			Equal("# syntax=docker/dockerfile:1\n# modified by sdk.x-openshift.io/v1 plugin, ocp=4.14\nFROM a\n"))
+	})
+
+	It("replaces an existing annotation", func() {
+		b := annotate("Dockerfile", []byte("FROM a\n"), "4.14")
+		Expect(string(annotate("Dockerfile", b, "4.15"))).To(
+			Equal("# modified by sdk.x-openshift.io/v1 plugin, ocp=4.15\nFROM a\n"))
+	})
+
+	It("annotates only modified files, once", func() {
+		fs := machinery.Filesystem{FS: afero.NewMemMapFs()}
+		Expect(afero.WriteFile(fs.FS, "Dockerfile", []byte("FROM gcr.io/distroless/static:nonroot\n"), 0644)).To(Succeed())
+		Expect(afero.WriteFile(fs.FS, authProxyPatchPath, []byte("image: quay.io/example/proxy:v1\n"), 0644)).To(Succeed())
+
+		opts := defaultImageOptions()
+		opts.annotate = true
+		for i := 0; i < 2; i++ {
+			_, err := replaceImages(fs, opts)
+			Expect(err).NotTo(HaveOccurred())
+		}
+		b, err := afero.ReadFile(fs.FS, "Dockerfile")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(Equal("# modified by sdk.x-openshift.io/v1 plugin, ocp=" + ocpProductVersion + "\n" +
+			"FROM registry.access.redhat.com/ubi8/ubi-minimal:" + ubiMinimalVersion + "\n"))
+		b, err = afero.ReadFile(fs.FS, authProxyPatchPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(Equal("image: quay.io/example/proxy:v1\n"))
+	})
+})
@@ -0,0 +1,70 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+	"sigs.k8s.io/kubebuilder/v3/pkg/config"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+	"sigs.k8s.io/kubebuilder/v3/pkg/model/resource"
+	"sigs.k8s.io/kubebuilder/v3/pkg/plugin"
+)
+
+var _ plugin.CreateAPISubcommand = &createAPISubcommand{}
+
+type createAPISubcommand struct {
+	config   config.Config
+	resource *resource.Resource
+
+	// ctx cancels image substitutions, if set.
+	ctx context.Context
+}
+
+func (s *createAPISubcommand) InjectConfig(c config.Config) error {
+	s.config = c
+	return nil
+}
+
+func (s *createAPISubcommand) InjectResource(res *resource.Resource) error {
+	s.resource = res
+	return nil
+}
+
+// Scaffold re-applies image substitutions to files that API scaffolding may have
+// created or modified, using the versions recorded when the project was initialized.
+func (s *createAPISubcommand) Scaffold(fs machinery.Filesystem) error {
+	cfg, err := migrateConfig(s.config)
+	if err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	if cfg.Reversed {
+		log.Debugf("Skipping image substitutions, upstream images were restored")
+		return nil
+	}
+
+	opts := cfg.imageOptions()
+	opts.authProxyOptional = mayOmitAuthProxy(s.config)
+	opts.ProjectType = projectType(s.config)
+	opts.ctx = s.ctx
+	opts.passthrough = passthroughFromEnv()
+	_, err = replaceImages(fs, opts)
+	return err
+}
@@ -0,0 +1,71 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+	cfgv3 "sigs.k8s.io/kubebuilder/v3/pkg/config/v3"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+var _ = Describe("RunCreateAPI", func() {
+
+	Describe("Scaffold", func() {
+		var fs machinery.Filesystem
+
+		BeforeEach(func() {
+			fs = machinery.Filesystem{FS: afero.NewMemMapFs()}
+			Expect(afero.WriteFile(fs.FS, "Dockerfile", []byte(dockerfileAll), 0644)).To(Succeed())
+			Expect(afero.WriteFile(fs.FS, "config/default/manager_auth_proxy_patch.yaml", []byte(proxyPatch), 0644)).To(Succeed())
+		})
+
+		It("substitutes images with the versions recorded at init", func() {
+			c := cfgv3.New()
+			Expect(c.EncodePluginConfig(pluginKey, Config{OCPVersion: "4.15", UBIVersion: "9.2", UBIMajor: 9})).To(Succeed())
+			s := &createAPISubcommand{}
+			Expect(s.InjectConfig(c)).To(Succeed())
+			Expect(s.Scaffold(fs)).To(Succeed())
+
+			dockerfileOut, err := afero.ReadFile(fs.FS, "Dockerfile")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(dockerfileOut)).To(ContainSubstring("FROM registry.redhat.io/openshift4/ose-helm-operator:v4.15\n"))
+			Expect(string(dockerfileOut)).To(ContainSubstring("FROM registry.access.redhat.com/ubi9/ubi-minimal:9.2\n"))
+		})
+
+		It("fails if the recorded config is invalid", func() {
+			c := cfgv3.New()
+			Expect(c.EncodePluginConfig(pluginKey, Config{OCPVersion: "4.15", UBIVersion: "9.2", UBIMajor: 8})).To(Succeed())
+			s := &createAPISubcommand{}
+			Expect(s.InjectConfig(c)).To(Succeed())
+			Expect(s.Scaffold(fs)).To(MatchError(ContainSubstring(`ubiVersion "9.2" is not a UBI 8 version`)))
+
+			dockerfileOut, err := afero.ReadFile(fs.FS, "Dockerfile")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(dockerfileOut)).To(Equal(dockerfileAll))
+		})
+
+		It("substitutes images with default versions in projects without a plugin config", func() {
+			s := &createAPISubcommand{}
+			Expect(s.InjectConfig(cfgv3.New())).To(Succeed())
+			Expect(s.Scaffold(fs)).To(Succeed())
+
+			dockerfileOut, err := afero.ReadFile(fs.FS, "Dockerfile")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(dockerfileOut)).To(ContainSubstring(dockerfileAllExp))
+		})
+	})
+})
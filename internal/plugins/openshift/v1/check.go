@@ -0,0 +1,88 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+)
+
+// defaultCheckImagesTimeout bounds the time spent checking that all downstream images exist.
+const defaultCheckImagesTimeout = 30 * time.Second
+
+// imageExistsFunc returns an error if image cannot be found in its registry.
+type imageExistsFunc func(ctx context.Context, image string) error
+
+// remoteImageExists requests image's manifest from its registry, authenticating
+// with credentials from the Docker config file (~/.docker/config.json) if any.
+func remoteImageExists(ctx context.Context, image string) error {
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return err
+	}
+	_, err = remote.Head(ref, remote.WithContext(ctx), remote.WithAuthFromKeychain(authn.DefaultKeychain))
+	var terr *transport.Error
+	if errors.As(err, &terr) && terr.StatusCode == http.StatusNotFound {
+		return errors.New("image does not exist")
+	}
+	return err
+}
+
+// checkImages returns an error listing each downstream image in results that replaced
+// an upstream image but cannot be found by exists within timeout. Checks stop if parent is canceled.
+func checkImages(parent context.Context, results []SubstitutionResult, exists imageExistsFunc, timeout time.Duration) error {
+	if exists == nil {
+		exists = remoteImageExists
+	}
+	if timeout <= 0 {
+		timeout = defaultCheckImagesTimeout
+	}
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+
+	seen := map[string]bool{}
+	var images []string
+	for _, result := range results {
+		if result.Count > 0 && !seen[result.Image] {
+			seen[result.Image] = true
+			images = append(images, result.Image)
+		}
+	}
+	sort.Strings(images)
+
+	var failures []string
+	for _, image := range images {
+		if err := canceled(parent, "image check"); err != nil {
+			return err
+		}
+		if err := exists(ctx, image); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", image, err))
+		}
+	}
+	if len(failures) != 0 {
+		return fmt.Errorf("error checking substituted images:\n%s", strings.Join(failures, "\n"))
+	}
+	return nil
+}
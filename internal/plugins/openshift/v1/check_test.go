@@ -0,0 +1,103 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+	cfgv3 "sigs.k8s.io/kubebuilder/v3/pkg/config/v3"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+var _ = Describe("checkImages", func() {
+	var checked []string
+
+	// existsExcept returns an imageExistsFunc that records checked images and fails for missing ones.
+	existsExcept := func(missing ...string) imageExistsFunc {
+		return func(_ context.Context, image string) error {
+			checked = append(checked, image)
+			for _, m := range missing {
+				if image == m {
+					return errors.New("image does not exist")
+				}
+			}
+			return nil
+		}
+	}
+
+	BeforeEach(func() {
+		checked = nil
+	})
+
+	It("checks each distinct image that replaced an upstream image", func() {
+		results := []SubstitutionResult{
+			{Path: "b", Image: "registry.example.com/b:v1", Count: 1},
+			{Path: "a", Image: "registry.example.com/a:v1", Count: 2},
+			{Path: "c", Image: "registry.example.com/b:v1", Count: 1},
+			{Path: "d", Image: "registry.example.com/unused:v1", Count: 0},
+		}
+		Expect(checkImages(context.Background(), results, existsExcept(), time.Second)).To(Succeed())
+		Expect(checked).To(Equal([]string{"registry.example.com/a:v1", "registry.example.com/b:v1"}))
+	})
+
+	It("reports every image that cannot be found", func() {
+		results := []SubstitutionResult{
+			{Image: "registry.example.com/a:v1", Count: 1},
+			{Image: "registry.example.com/b:v1", Count: 1},
+			{Image: "registry.example.com/c:v1", Count: 1},
+		}
+		err := checkImages(context.Background(), results, existsExcept("registry.example.com/a:v1", "registry.example.com/c:v1"), time.Second)
+		Expect(err).To(MatchError(ContainSubstring("registry.example.com/a:v1: image does not exist")))
+		Expect(err).To(MatchError(ContainSubstring("registry.example.com/c:v1: image does not exist")))
+		Expect(err.Error()).NotTo(ContainSubstring("registry.example.com/b:v1"))
+	})
+
+	It("gives up after the timeout", func() {
+		results := []SubstitutionResult{{Image: "registry.example.com/a:v1", Count: 1}}
+		blocking := func(ctx context.Context, _ string) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		Expect(checkImages(context.Background(), results, blocking, time.Millisecond)).To(MatchError(ContainSubstring("deadline exceeded")))
+	})
+
+	It("stops if the context is canceled", func() {
+		results := []SubstitutionResult{{Image: "registry.example.com/a:v1", Count: 1}}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := checkImages(ctx, results, existsExcept(), time.Second)
+		Expect(err).To(MatchError(context.Canceled))
+		Expect(err).To(MatchError(ContainSubstring("image check canceled")))
+		Expect(checked).To(BeEmpty())
+	})
+
+	It("fails init scaffolding if a substituted image cannot be found", func() {
+		fs := machinery.Filesystem{FS: afero.NewMemMapFs()}
+		Expect(afero.WriteFile(fs.FS, "Dockerfile", []byte(dockerfileAll), 0644)).To(Succeed())
+		s := &initSubcommand{options: imageOptions{
+			Options:     Options{OCPVersion: "4.99"},
+			checkImages: true,
+			imageExists: existsExcept("registry.redhat.io/openshift4/ose-helm-operator:v4.99"),
+		}}
+		Expect(s.InjectConfig(cfgv3.New())).To(Succeed())
+		Expect(s.PreScaffold(fs)).To(Succeed())
+		Expect(s.Scaffold(fs)).To(MatchError(ContainSubstring("ose-helm-operator:v4.99: image does not exist")))
+	})
+})
@@ -0,0 +1,124 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"bytes"
+	"path/filepath"
+)
+
+// commentRanges returns the byte ranges of the comments in content, in order, according to the
+// comment syntax of filePath. Files of unknown syntax have no comments.
+func commentRanges(filePath string, content []byte) []byteRange {
+	var (
+		ranges []byteRange
+		inLine func(line []byte) int
+	)
+	switch {
+	case filepath.Base(filePath) == "go.mod":
+		inLine = func(line []byte) int { return bytes.Index(line, []byte("//")) }
+	case isDockerfile(filePath):
+		// Dockerfile comments are whole lines, so a # elsewhere is part of an instruction.
+		inLine = func(line []byte) int {
+			if trimmed := bytes.TrimLeft(line, " \t"); bytes.HasPrefix(trimmed, []byte("#")) {
+				return len(line) - len(trimmed)
+			}
+			return -1
+		}
+	case isYAMLFile(filePath):
+		inLine = yamlCommentStart
+	case filepath.Base(filePath) == makefilePath:
+		inLine = makefileCommentStart
+	default:
+		return nil
+	}
+
+	start := 0
+	for _, line := range bytes.SplitAfter(content, []byte("\n")) {
+		text := bytes.TrimSuffix(line, []byte("\n"))
+		if i := inLine(text); i >= 0 {
+			ranges = append(ranges, byteRange{start: start + i, end: start + len(text)})
+		}
+		start += len(line)
+	}
+	return ranges
+}
+
+// yamlCommentStart returns the index of the comment in a YAML line, or -1 if it has none.
+// A # starts a comment at the start of the line or after whitespace, outside of quoted scalars.
+func yamlCommentStart(line []byte) int {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case (c == '"' || c == '\'') && (i == 0 || bytes.IndexByte([]byte(" \t[{,:"), line[i-1]) >= 0):
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return i
+		}
+	}
+	return -1
+}
+
+// makefileCommentStart returns the index of the comment in a Makefile line, or -1 if it has none.
+// A # that is not escaped by a backslash starts a comment anywhere in a line.
+func makefileCommentStart(line []byte) int {
+	for i, c := range line {
+		if c == '#' && (i == 0 || line[i-1] != '\\') {
+			return i
+		}
+	}
+	return -1
+}
+
+// substituteCode applies subs to content like substitute, but leaves comments unchanged
+// according to the comment syntax of filePath.
+func substituteCode(filePath string, content []byte, subs []Substitution) ([]byte, [][][]byte) {
+	comments := commentRanges(filePath, content)
+	if len(comments) == 0 {
+		return substitute(content, subs)
+	}
+	substMatches := make([][][]byte, len(subs))
+	out := make([]byte, 0, len(content))
+	last := 0
+	for _, r := range append(comments, byteRange{start: len(content), end: len(content)}) {
+		code, matches := substitute(content[last:r.start], subs)
+		for i := range matches {
+			substMatches[i] = append(substMatches[i], matches[i]...)
+		}
+		out = append(out, code...)
+		out = append(out, content[r.start:r.end]...)
+		last = r.end
+	}
+	return out, substMatches
+}
+
+// blankComments returns a copy of content with the comments of filePath's syntax replaced
+// by spaces, so that offsets and line numbers are unchanged.
+func blankComments(filePath string, content []byte) []byte {
+	out := append([]byte{}, content...)
+	for _, r := range commentRanges(filePath, content) {
+		for i := r.start; i < r.end; i++ {
+			out[i] = ' '
+		}
+	}
+	return out
+}
@@ -0,0 +1,89 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+var _ = Describe("commentRanges", func() {
+	It("finds comments in each file's syntax", func() {
+		cases := []struct {
+			filePath string
+			content  string
+			comments []string
+		}{
+			{"go.mod", "module example.com/m // pinned\n\ngo 1.20\n", []string{"// pinned"}},
+			{"Dockerfile", "# syntax\nFROM golang:1.20 # not a comment\n  # indented\n", []string{"# syntax", "# indented"}},
+			{"config/manager/manager.yaml", "# header\nimage: foo#bar # trailing\nname: \"a # b\" # quoted\n",
+				[]string{"# header", "# trailing", "# quoted"}},
+			{"config/manager/manager.yaml", "description: it's # plain\n", []string{"# plain"}},
+			{makefilePath, "IMG ?= foo:v1# pinned\nESCAPED = \\#x\n", []string{"# pinned"}},
+			{"README.md", "# Title\n", nil},
+		}
+		for _, c := range cases {
+			var comments []string
+			for _, r := range commentRanges(c.filePath, []byte(c.content)) {
+				comments = append(comments, c.content[r.start:r.end])
+			}
+			Expect(comments).To(Equal(c.comments), c.filePath+": "+c.content)
+		}
+	})
+})
+
+var _ = Describe("substituting images in files with comments", func() {
+	var fs machinery.Filesystem
+
+	const dockerfile = "# Provenance: gcr.io/distroless/static@sha256:abc123 was gcr.io/distroless/static:nonroot\n" +
+		"FROM gcr.io/distroless/static:nonroot\n"
+
+	BeforeEach(func() {
+		fs = machinery.Filesystem{FS: afero.NewMemMapFs()}
+		Expect(afero.WriteFile(fs.FS, "Dockerfile", []byte(dockerfile), 0644)).To(Succeed())
+		Expect(afero.WriteFile(fs.FS, authProxyPatchPath, []byte("# gcr.io/kubebuilder/kube-rbac-proxy:v0.13.1\n"+
+			"image: gcr.io/kubebuilder/kube-rbac-proxy:v0.13.1 # gcr.io/kubebuilder/kube-rbac-proxy:v0.13.1\n"), 0644)).To(Succeed())
+	})
+
+	It("leaves comments unchanged by default", func() {
+		_, err := replaceImages(fs, defaultImageOptions())
+		Expect(err).NotTo(HaveOccurred())
+		b, err := afero.ReadFile(fs.FS, "Dockerfile")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(Equal("# Provenance: gcr.io/distroless/static@sha256:abc123 was gcr.io/distroless/static:nonroot\n" +
+			"FROM registry.access.redhat.com/ubi8/ubi-minimal:" + ubiMinimalVersion + "\n"))
+		b, err = afero.ReadFile(fs.FS, authProxyPatchPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(Equal("# gcr.io/kubebuilder/kube-rbac-proxy:v0.13.1\n" +
+			"image: registry.redhat.io/openshift4/ose-kube-rbac-proxy:v" + ocpProductVersion + " # gcr.io/kubebuilder/kube-rbac-proxy:v0.13.1\n"))
+	})
+
+	It("substitutes images in comments with --rewrite-comments", func() {
+		opts := defaultImageOptions()
+		opts.rewriteComments = true
+		opts.RewriteDigests = true
+		_, err := replaceImages(fs, opts)
+		Expect(err).NotTo(HaveOccurred())
+		b, err := afero.ReadFile(fs.FS, "Dockerfile")
+		Expect(err).NotTo(HaveOccurred())
+		ubiMinimal := "registry.access.redhat.com/ubi8/ubi-minimal:" + ubiMinimalVersion
+		Expect(string(b)).To(Equal("# Provenance: " + ubiMinimal + " was " + ubiMinimal + "\nFROM " + ubiMinimal + "\n"))
+		b, err = afero.ReadFile(fs.FS, authProxyPatchPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).NotTo(ContainSubstring("gcr.io"))
+	})
+})
@@ -0,0 +1,123 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import "fmt"
+
+// flagConflict is a combination of flags that contradict each other, where one would
+// otherwise silently win over the other.
+type flagConflict struct {
+	// flag and other name the conflicting flags, ex. "dry-run" or "disable=go-builder".
+	flag, other string
+	// set reports whether both flags were given with conflicting values.
+	set bool
+	// reason explains why the flags cannot be given together.
+	reason string
+}
+
+// checkFlagConflicts returns an error naming both flags of the first conflict that is set.
+func checkFlagConflicts(conflicts []flagConflict) error {
+	for _, c := range conflicts {
+		if c.set {
+			return fmt.Errorf("--%s cannot be set with --%s: %s", c.flag, c.other, c.reason)
+		}
+	}
+	return nil
+}
+
+// flagConflicts returns the combinations of init flags that contradict each other.
+func (s *initSubcommand) flagConflicts() []flagConflict {
+	opts := s.options
+	return []flagConflict{
+		{
+			flag: backupFlag, other: dryRunFlag,
+			set:    opts.backup && opts.dryRun,
+			reason: "dry runs write no files to back up",
+		},
+		{
+			flag: backupFlag, other: outputDirFlag,
+			set:    opts.backup && opts.outputDir != "",
+			reason: "files are not modified in place",
+		},
+		{
+			flag: tidyFlag, other: dryRunFlag,
+			set:    s.tidy && opts.dryRun,
+			reason: "dry runs do not change go.mod",
+		},
+		{
+			flag: tidyFlag, other: outputDirFlag,
+			set:    s.tidy && opts.outputDir != "",
+			reason: "go.mod is not modified in place",
+		},
+		{
+			flag: maxOCPVersionFlag, other: ocpVersionFlag + " range",
+			set:    s.maxOCPVersion != "" && s.ocpVersions.isRange(),
+			reason: "the range of supported releases is already set",
+		},
+		{
+			flag: rbacProxyVersionFlag, other: disableFlag + "=" + KubeRBACProxyCategory,
+			set:    opts.RBACProxyVersion != "" && contains(opts.Disabled, KubeRBACProxyCategory),
+			reason: "kube-rbac-proxy images are not substituted",
+		},
+		{
+			flag: goBaseImageFlag, other: disableFlag + "=" + UBIMinimalCategory,
+			set:    opts.GoBaseImage != "" && contains(opts.Disabled, UBIMinimalCategory),
+			reason: "distroless base images are not substituted",
+		},
+		{
+			flag: goBuilderVersionFlag, other: disableFlag + "=" + GoBuilderCategory,
+			set:    opts.GoBuilderVersion != "" && contains(opts.Disabled, GoBuilderCategory),
+			reason: "golang builder images are not substituted",
+		},
+		{
+			flag: fipsFlag, other: disableFlag + "=" + GoBuilderCategory,
+			set:    opts.FIPS && contains(opts.Disabled, GoBuilderCategory),
+			reason: "golang builder images are not substituted",
+		},
+		{
+			flag: fipsFlag, other: goBaseImageFlag + "=ubi-micro",
+			set:    opts.FIPS && opts.GoBaseImage == "ubi-micro",
+			reason: "ubi-micro has no OpenSSL libraries for FIPS mode",
+		},
+		{
+			flag: autoGoVersionFlag, other: disableFlag + "=" + GoBuilderCategory,
+			set:    s.autoGoVersion && contains(opts.Disabled, GoBuilderCategory),
+			reason: "golang builder images are not substituted",
+		},
+		{
+			flag: noGoModEditFlag, other: disableFlag + "=" + GoBuilderCategory,
+			set:    opts.NoGoModEdit && contains(opts.Disabled, GoBuilderCategory),
+			reason: "the go.mod go directive is already left unchanged",
+		},
+		quietFlagConflict(opts.quiet),
+	}
+}
+
+// flagConflicts returns the combinations of edit flags that contradict each other.
+func (s *editSubcommand) flagConflicts() []flagConflict {
+	return []flagConflict{
+		{
+			flag: reverseFlag, other: ocpVersionFlag,
+			set:    s.reverse && s.ocpVersion != "",
+			reason: "upstream images are not tagged with OCP versions",
+		},
+		{
+			flag: reverseFlag, other: ubiVersionFlag,
+			set:    s.reverse && s.ubiVersion != "",
+			reason: "upstream images are not tagged with UBI versions",
+		},
+		quietFlagConflict(s.quiet),
+	}
+}
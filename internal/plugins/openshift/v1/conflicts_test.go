@@ -0,0 +1,133 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+var _ = Describe("flag conflicts", func() {
+	var fs machinery.Filesystem
+
+	BeforeEach(func() {
+		fs = machinery.Filesystem{FS: afero.NewMemMapFs()}
+	})
+
+	It("rejects contradictory init flags, naming both flags", func() {
+		cases := []struct {
+			args []string
+			err  string
+		}{
+			{
+				[]string{"--" + backupFlag, "--" + dryRunFlag},
+				"--" + backupFlag + " cannot be set with --" + dryRunFlag,
+			},
+			{
+				[]string{"--" + backupFlag, "--" + outputDirFlag, "out"},
+				"--" + backupFlag + " cannot be set with --" + outputDirFlag,
+			},
+			{
+				[]string{"--" + tidyFlag, "--" + dryRunFlag},
+				"--" + tidyFlag + " cannot be set with --" + dryRunFlag,
+			},
+			{
+				[]string{"--" + tidyFlag, "--" + outputDirFlag, "out"},
+				"--" + tidyFlag + " cannot be set with --" + outputDirFlag,
+			},
+			{
+				[]string{"--" + ocpVersionFlag, "4.12-4.14", "--" + withCSVAnnotationsFlag, "--" + maxOCPVersionFlag, "4.16"},
+				"--" + maxOCPVersionFlag + " cannot be set with --" + ocpVersionFlag + " range",
+			},
+			{
+				[]string{"--" + rbacProxyVersionFlag, "4.13", "--" + disableFlag, KubeRBACProxyCategory},
+				"--" + rbacProxyVersionFlag + " cannot be set with --" + disableFlag + "=" + KubeRBACProxyCategory,
+			},
+			{
+				[]string{"--" + goBaseImageFlag, "ubi-micro", "--" + disableFlag, UBIMinimalCategory},
+				"--" + goBaseImageFlag + " cannot be set with --" + disableFlag + "=" + UBIMinimalCategory,
+			},
+			{
+				[]string{"--" + goBuilderVersionFlag, "1.21", "--" + disableFlag, GoBuilderCategory},
+				"--" + goBuilderVersionFlag + " cannot be set with --" + disableFlag + "=" + GoBuilderCategory,
+			},
+			{
+				[]string{"--" + noGoModEditFlag, "--" + disableFlag, GoBuilderCategory + "," + HelmOperatorCategory},
+				"--" + noGoModEditFlag + " cannot be set with --" + disableFlag + "=" + GoBuilderCategory,
+			},
+			{
+				[]string{"--" + fipsFlag, "--" + disableFlag, GoBuilderCategory},
+				"--" + fipsFlag + " cannot be set with --" + disableFlag + "=" + GoBuilderCategory,
+			},
+			{
+				[]string{"--" + fipsFlag, "--" + goBaseImageFlag, "ubi-micro"},
+				"--" + fipsFlag + " cannot be set with --" + goBaseImageFlag + "=ubi-micro",
+			},
+			{
+				[]string{"--" + autoGoVersionFlag, "--" + disableFlag, GoBuilderCategory},
+				"--" + autoGoVersionFlag + " cannot be set with --" + disableFlag + "=" + GoBuilderCategory,
+			},
+			{
+				[]string{"--" + redHatRegistryFlag, redHatConnectRegistry, "--" + registryFlag, "mirror.example.com"},
+				"--" + redHatRegistryFlag + " and --" + registryFlag + " are mutually exclusive",
+			},
+		}
+		for _, c := range cases {
+			s := &initSubcommand{}
+			flags := pflag.NewFlagSet("init", pflag.ContinueOnError)
+			s.BindFlags(flags)
+			Expect(flags.Parse(c.args)).To(Succeed())
+			Expect(s.PreScaffold(fs)).To(MatchError(ContainSubstring(c.err)), c.err)
+		}
+	})
+
+	It("accepts init flags for categories that are not disabled", func() {
+		s := &initSubcommand{}
+		flags := pflag.NewFlagSet("init", pflag.ContinueOnError)
+		s.BindFlags(flags)
+		Expect(flags.Parse([]string{"--" + rbacProxyVersionFlag, "4.13", "--" + disableFlag, UBIMinimalCategory})).To(Succeed())
+		Expect(s.PreScaffold(fs)).To(Succeed())
+	})
+
+	It("rejects contradictory edit flags, naming both flags", func() {
+		cases := []struct {
+			args []string
+			err  string
+		}{
+			{
+				[]string{"--" + reverseFlag, "--" + ocpVersionFlag, "4.15"},
+				"--" + reverseFlag + " cannot be set with --" + ocpVersionFlag,
+			},
+			{
+				[]string{"--" + reverseFlag, "--" + ubiVersionFlag, "8.9"},
+				"--" + reverseFlag + " cannot be set with --" + ubiVersionFlag,
+			},
+			{
+				[]string{"--" + upstreamTagFlag, "v1.30.0"},
+				"--" + upstreamTagFlag + " requires --" + reverseFlag,
+			},
+		}
+		for _, c := range cases {
+			s := &editSubcommand{}
+			flags := pflag.NewFlagSet("edit", pflag.ContinueOnError)
+			s.BindFlags(flags)
+			Expect(flags.Parse(c.args)).To(Succeed())
+			Expect(s.PreScaffold(fs)).To(MatchError(ContainSubstring(c.err)), c.err)
+		}
+	})
+})
@@ -0,0 +1,146 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+)
+
+// managerManifestGlobs match the manager Deployment and the kustomize patches applied to it,
+// whose containers may run upstream images besides the kube-rbac-proxy sidecar.
+var managerManifestGlobs = []string{
+	filepath.Join("config", "manager", "*.yaml"),
+	filepath.Join("config", "default", "*.yaml"),
+}
+
+// Keys of pod spec fields listing containers whose images are substituted.
+const (
+	containersKey     = "containers"
+	initContainersKey = "initContainers"
+)
+
+// managerSubstitutions returns substs with substitutions added for each manager manifest in fs with
+// a container or init container that runs an upstream image. The built-in substitutions matching
+// those images are added. Files in substs are left as they are, since their substitutions are already known.
+func managerSubstitutions(fs afero.Fs, opts imageOptions, substs map[string][]Substitution) (map[string][]Substitution, error) {
+	if len(opts.paths) > 0 {
+		return substs, nil
+	}
+	var filePaths []string
+	for _, pattern := range managerManifestGlobs {
+		matches, err := afero.Glob(fs, pattern)
+		if err != nil {
+			return nil, fmt.Errorf("error finding manager manifests: %v", err)
+		}
+		filePaths = append(filePaths, matches...)
+	}
+
+	candidates := distinctSubstitutions(BuildSubstitutions(opts.Options))
+	withManager := map[string][]Substitution{}
+	for filePath, fileSubsts := range substs {
+		withManager[filePath] = fileSubsts
+	}
+	for _, filePath := range filePaths {
+		if _, ok := withManager[filePath]; ok {
+			continue
+		}
+		b, err := afero.ReadFile(fs, filePath)
+		if err != nil {
+			return nil, fmt.Errorf("error reading manager manifest: %v", err)
+		}
+		// Manifests that are not valid YAML are left to kustomize to report.
+		images, err := containerImages(b)
+		if err != nil {
+			continue
+		}
+		for _, subst := range candidates {
+			for _, image := range images {
+				if isUpstreamImage(image) && subst.FromTagRE.MatchString(image) {
+					withManager[filePath] = append(withManager[filePath], subst)
+					break
+				}
+			}
+		}
+	}
+	return withManager, nil
+}
+
+// isUpstreamImage reports whether image must not be referenced by an OpenShift project.
+func isUpstreamImage(image string) bool {
+	for _, re := range upstreamImageREs {
+		if re.MatchString(image) {
+			return true
+		}
+	}
+	return false
+}
+
+// containerImages returns the images of the containers and init containers in every YAML document
+// of content, in order.
+func containerImages(content []byte) ([]string, error) {
+	var images []string
+	dec := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		var doc yaml.Node
+		if err := dec.Decode(&doc); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		images = appendContainerImages(images, &doc)
+	}
+	return images, nil
+}
+
+// appendContainerImages appends the images of containers listed in the tree rooted at n to images.
+func appendContainerImages(images []string, n *yaml.Node) []string {
+	if n.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			if (key.Value != containersKey && key.Value != initContainersKey) || value.Kind != yaml.SequenceNode {
+				continue
+			}
+			for _, container := range value.Content {
+				if image := mappingValue(container, imageKey); image != nil && image.Kind == yaml.ScalarNode {
+					images = append(images, image.Value)
+				}
+			}
+		}
+	}
+	for _, child := range n.Content {
+		images = appendContainerImages(images, child)
+	}
+	return images
+}
+
+// mappingValue returns the value of key in mapping node n, or nil if n is not a mapping or has no key.
+func mappingValue(n *yaml.Node, key string) *yaml.Node {
+	if n.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+var _ = Describe("managerSubstitutions", func() {
+	var (
+		fs          afero.Fs
+		managerPath = filepath.Join("config", "manager", "manager.yaml")
+	)
+
+	BeforeEach(func() {
+		fs = afero.NewMemMapFs()
+	})
+
+	It("substitutes upstream images of sidecars and init containers", func() {
+		Expect(afero.WriteFile(fs, managerPath, []byte(managerSidecar), 0644)).To(Succeed())
+		opts := defaultImageOptions()
+		_, err := replaceImages(machinery.Filesystem{FS: fs}, opts)
+		Expect(err).NotTo(HaveOccurred())
+		b, err := afero.ReadFile(fs, managerPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(Equal(managerSidecarExp))
+	})
+
+	It("only adds built-in substitutions matching container images", func() {
+		Expect(afero.WriteFile(fs, managerPath, []byte(managerSidecar), 0644)).To(Succeed())
+		Expect(afero.WriteFile(fs, filepath.Join("config", "default", "manager_config_patch.yaml"),
+			[]byte("# gcr.io/kubebuilder/kube-rbac-proxy:v0.13.1\nspec:\n  containers:\n  - name: manager\n    image: controller:latest\n"), 0644)).To(Succeed())
+		opts := defaultImageOptions()
+		substs, err := managerSubstitutions(fs, opts, imageSubstitutions(opts))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(substs).NotTo(HaveKey(filepath.Join("config", "default", "manager_config_patch.yaml")))
+		Expect(substs[managerPath]).To(HaveLen(2))
+		Expect(substs[managerPath][0].Category).To(Equal(AnsibleOperatorCategory))
+		Expect(substs[managerPath][1].Category).To(Equal(KubeRBACProxyCategory))
+	})
+
+	It("leaves files with known substitutions as they are", func() {
+		Expect(afero.WriteFile(fs, authProxyPatchPath, []byte(proxyPatch), 0644)).To(Succeed())
+		opts := defaultImageOptions()
+		substs, err := managerSubstitutions(fs, opts, imageSubstitutions(opts))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(substs[authProxyPatchPath]).To(Equal(imageSubstitutions(opts)[authProxyPatchPath]))
+	})
+})
+
+const managerSidecar = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: controller-manager
+spec:
+  template:
+    spec:
+      initContainers:
+      - name: setup
+        image: quay.io/operator-framework/ansible-operator:v1.31.0
+      containers:
+      - name: manager
+        image: controller:latest
+      - name: metrics-proxy
+        image: gcr.io/kubebuilder/kube-rbac-proxy:v0.13.1
+`
+
+var managerSidecarExp = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: controller-manager
+spec:
+  template:
+    spec:
+      initContainers:
+      - name: setup
+        image: registry.redhat.io/openshift4/ose-ansible-operator:v` + ocpProductVersion + `
+      containers:
+      - name: manager
+        image: controller:latest
+      - name: metrics-proxy
+        image: registry.redhat.io/openshift4/ose-kube-rbac-proxy:v` + ocpProductVersion + `
+`
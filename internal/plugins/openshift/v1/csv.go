@@ -0,0 +1,178 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"golang.org/x/mod/semver"
+	"sigs.k8s.io/kubebuilder/v3/pkg/config"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+
+	"github.com/operator-framework/operator-sdk/internal/plugins/openshift/v1/templates/config/manifests"
+)
+
+// csvKustomizePatches registers openshift_csv_patch.yaml with the manifests kustomization.
+const csvKustomizePatches = `
+patches:
+# [OPENSHIFT] Add Red Hat certified catalog annotations to the ClusterServiceVersion.
+- path: bases/openshift_csv_patch.yaml
+  target:
+    kind: ClusterServiceVersion
+`
+
+// defaultChannel is the default OLM channel the operator's bundle is published to.
+const defaultChannel = "stable"
+
+// channelRE matches OLM channel names, ex. stable or candidate-v1.2.
+var channelRE = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9._-]*[a-zA-Z0-9])?$`)
+
+// manifestsKustomizationPath is the path of the kustomization that bundle manifests are built from.
+var manifestsKustomizationPath = filepath.Join("config", "manifests", "kustomization.yaml")
+
+// validateChannel returns an error if channel is not an OLM channel name.
+func validateChannel(channel string) error {
+	if !channelRE.MatchString(channel) {
+		return fmt.Errorf("invalid --%s value %q: must be a channel name, ex. %s", channelFlag, channel, defaultChannel)
+	}
+	return nil
+}
+
+// validateMaxOCPVersion returns an error if maxVersion is set and is not an OCP release version
+// at least minVersion.
+func validateMaxOCPVersion(minVersion, maxVersion string) error {
+	if maxVersion == "" {
+		return nil
+	}
+	if !ocpVersionRE.MatchString(maxVersion) {
+		return fmt.Errorf("invalid --%s value %q: must be of the form <major>.<minor>, ex. %s",
+			maxOCPVersionFlag, maxVersion, ocpProductVersion)
+	}
+	if semver.Compare("v"+maxVersion, "v"+minVersion) < 0 {
+		return fmt.Errorf("--%s value %q is lower than the --%s value %q", maxOCPVersionFlag, maxVersion, ocpVersionFlag, minVersion)
+	}
+	return nil
+}
+
+// ocpVersionRange is a range of OCP releases an operator supports.
+type ocpVersionRange struct {
+	// min is the earliest supported release.
+	min string
+	// max is the latest supported release, or empty if only min or every release from min on is supported.
+	max string
+	// open reports whether every release from min on is supported.
+	open bool
+}
+
+// parseOCPVersionRange parses an --ocp-version value, which is a single release "4.14", a range
+// "4.12-4.16", or "4.12+" for a release and every later one. Versions in ranges may be prefixed
+// with "v", as in com.redhat.openshift.versions annotations.
+func parseOCPVersionRange(value string) (ocpVersionRange, error) {
+	invalid := fmt.Errorf("invalid --%s value %q: must be of the form <major>.<minor>, ex. %s, "+
+		"a range <min>-<max>, ex. 4.12-4.16, or <min>+ for <min> and later releases", ocpVersionFlag, value, ocpProductVersion)
+	var r ocpVersionRange
+	switch i := strings.Index(value, "-"); {
+	case i >= 0:
+		r.min, r.max = strings.TrimPrefix(value[:i], "v"), strings.TrimPrefix(value[i+1:], "v")
+		if !ocpVersionRE.MatchString(r.max) {
+			return ocpVersionRange{}, invalid
+		}
+	case strings.HasSuffix(value, "+"):
+		r.min, r.open = strings.TrimPrefix(strings.TrimSuffix(value, "+"), "v"), true
+	default:
+		r.min = value
+	}
+	if !ocpVersionRE.MatchString(r.min) {
+		return ocpVersionRange{}, invalid
+	}
+	if r.max != "" && semver.Compare("v"+r.max, "v"+r.min) < 0 {
+		return ocpVersionRange{}, fmt.Errorf("invalid --%s value %q: range ends before it starts", ocpVersionFlag, value)
+	}
+	return r, nil
+}
+
+// isRange reports whether r spans more than its earliest release.
+func (r ocpVersionRange) isRange() bool {
+	return r.open || (r.max != "" && r.max != r.min)
+}
+
+// annotation returns the com.redhat.openshift.versions annotation value for r: "=v<min>" for
+// a single release, "v<min>-v<max>" for a range, and "v<min>" for min and later releases.
+func (r ocpVersionRange) annotation() string {
+	switch {
+	case r.open:
+		return "v" + r.min
+	case r.isRange():
+		return "v" + r.min + "-v" + r.max
+	default:
+		return "=v" + r.min
+	}
+}
+
+// scaffoldCSVAnnotations scaffolds a patch adding Red Hat certified catalog annotations for the OCP releases
+// in versions to the base ClusterServiceVersion, with a note to build bundles with channel, and registers it
+// with the manifests kustomization.
+func scaffoldCSVAnnotations(fs machinery.Filesystem, c config.Config, versions ocpVersionRange, channel string) error {
+	scaffold := machinery.NewScaffold(fs,
+		// NOTE: kubebuilder's default permissions are only for root users
+		machinery.WithDirectoryPermissions(0755),
+		machinery.WithFilePermissions(0644),
+		machinery.WithConfig(c),
+	)
+	patch := &manifests.CSVPatch{OCPVersions: versions.annotation(), Channel: channel}
+	if err := scaffold.Execute(patch); err != nil {
+		return fmt.Errorf("error scaffolding CSV annotations: %w", err)
+	}
+	return addCSVKustomizePatches(fs.FS)
+}
+
+// addCSVKustomizePatches registers openshift_csv_patch.yaml with the manifests kustomization.
+// A warning is logged if the kustomization does not exist or already has patches, since
+// the user must then register the patch themselves.
+func addCSVKustomizePatches(fs afero.Fs) error {
+	b, err := afero.ReadFile(fs, manifestsKustomizationPath)
+	if errors.Is(err, os.ErrNotExist) {
+		log.Warnf("%s does not exist, add bases/openshift_csv_patch.yaml to your kustomization's patches "+
+			"to annotate the ClusterServiceVersion", manifestsKustomizationPath)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading %s: %v", manifestsKustomizationPath, err)
+	}
+	if bytes.Contains(b, []byte("openshift_csv_patch.yaml")) {
+		return nil
+	}
+	if bytes.HasPrefix(b, []byte("patches:")) || bytes.Contains(b, []byte("\npatches:")) {
+		log.Warnf("%s already has patches, add bases/openshift_csv_patch.yaml to them for "+
+			"ClusterServiceVersion targets", manifestsKustomizationPath)
+		return nil
+	}
+
+	info, err := fs.Stat(manifestsKustomizationPath)
+	if err != nil {
+		return fmt.Errorf("error reading file info of %s: %v", manifestsKustomizationPath, err)
+	}
+	out := append(bytes.TrimRight(b, "\n"), '\n')
+	out = append(out, csvKustomizePatches...)
+	return writeFile(fs, manifestsKustomizationPath, out, info.Mode())
+}
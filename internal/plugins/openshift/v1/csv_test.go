@@ -0,0 +1,141 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+	cfgv3 "sigs.k8s.io/kubebuilder/v3/pkg/config/v3"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+var _ = Describe("CSV annotations", func() {
+
+	Describe("parseOCPVersionRange", func() {
+		It("translates releases and ranges to annotation values", func() {
+			cases := []struct {
+				value, annotation string
+			}{
+				{"4.14", "=v4.14"},
+				{"4.12-4.16", "v4.12-v4.16"},
+				{"v4.12-v4.16", "v4.12-v4.16"},
+				{"4.14-4.14", "=v4.14"},
+				{"4.12+", "v4.12"},
+				{"v4.12+", "v4.12"},
+			}
+			for _, c := range cases {
+				r, err := parseOCPVersionRange(c.value)
+				Expect(err).NotTo(HaveOccurred(), c.value)
+				Expect(r.annotation()).To(Equal(c.annotation), c.value)
+				Expect(r.min).To(HavePrefix("4."), c.value)
+			}
+		})
+		It("rejects malformed releases and ranges", func() {
+			for _, value := range []string{"v4.14", "4", "4.12-", "-4.16", "4.12-4.16+", "4.12+4.16", "4.12..4.16", "4.12-latest"} {
+				_, err := parseOCPVersionRange(value)
+				Expect(err).To(MatchError(ContainSubstring("invalid --"+ocpVersionFlag)), value)
+			}
+		})
+		It("rejects ranges that end before they start", func() {
+			_, err := parseOCPVersionRange("4.16-4.12")
+			Expect(err).To(MatchError(ContainSubstring("range ends before it starts")))
+		})
+	})
+
+	Describe("validateMaxOCPVersion", func() {
+		It("accepts no version and versions at least the minimum", func() {
+			Expect(validateMaxOCPVersion("4.14", "")).To(Succeed())
+			Expect(validateMaxOCPVersion("4.14", "4.14")).To(Succeed())
+			Expect(validateMaxOCPVersion("4.9", "4.10")).To(Succeed())
+		})
+		It("rejects malformed versions", func() {
+			Expect(validateMaxOCPVersion("4.14", "v4.16")).To(MatchError(ContainSubstring("--" + maxOCPVersionFlag)))
+		})
+		It("rejects versions lower than the minimum", func() {
+			Expect(validateMaxOCPVersion("4.14", "4.9")).To(MatchError(ContainSubstring("is lower than")))
+		})
+	})
+
+	Describe("validateChannel", func() {
+		It("accepts channel names", func() {
+			Expect(validateChannel("stable")).To(Succeed())
+			Expect(validateChannel("candidate-v1.2")).To(Succeed())
+		})
+		It("rejects lists and malformed names", func() {
+			Expect(validateChannel("stable,fast")).To(MatchError(ContainSubstring("--" + channelFlag)))
+			Expect(validateChannel("-stable")).To(MatchError(ContainSubstring("--" + channelFlag)))
+		})
+	})
+
+	Describe("scaffoldCSVAnnotations", func() {
+		var fs machinery.Filesystem
+
+		BeforeEach(func() {
+			fs = machinery.Filesystem{FS: afero.NewMemMapFs()}
+		})
+
+		It("scaffolds a CSV patch and registers it with the manifests kustomization", func() {
+			Expect(afero.WriteFile(fs.FS, manifestsKustomizationPath, []byte(manifestsKustomization), 0644)).To(Succeed())
+			c := cfgv3.New()
+			Expect(c.SetProjectName("memcached-operator")).To(Succeed())
+			Expect(scaffoldCSVAnnotations(fs, c, ocpVersionRange{min: "4.14", max: "4.16"}, "stable")).To(Succeed())
+
+			patchOut, err := afero.ReadFile(fs.FS, "config/manifests/bases/openshift_csv_patch.yaml")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(patchOut)).To(ContainSubstring("  name: memcached-operator.v0.0.0\n"))
+			Expect(string(patchOut)).To(ContainSubstring(`    com.redhat.openshift.versions: "v4.14-v4.16"` + "\n"))
+			Expect(string(patchOut)).To(ContainSubstring(`    features.operators.openshift.io/disconnected: "false"` + "\n"))
+			Expect(string(patchOut)).To(ContainSubstring(`"make bundle CHANNELS=stable DEFAULT_CHANNEL=stable"`))
+			Expect(string(patchOut)).NotTo(ContainSubstring("operators.operatorframework.io.bundle.channel.default.v1"))
+
+			kustomizationOut, err := afero.ReadFile(fs.FS, manifestsKustomizationPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(kustomizationOut)).To(Equal(manifestsKustomization + csvKustomizePatches))
+
+			Expect(scaffoldCSVAnnotations(fs, c, ocpVersionRange{min: "4.14", max: "4.16"}, "stable")).To(Succeed())
+			kustomizationOut, err = afero.ReadFile(fs.FS, manifestsKustomizationPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(kustomizationOut)).To(Equal(manifestsKustomization + csvKustomizePatches))
+		})
+
+		It("leaves a manifests kustomization that already has patches unchanged", func() {
+			kustomization := manifestsKustomization + "patches:\n- path: bases/other_patch.yaml\n"
+			Expect(afero.WriteFile(fs.FS, manifestsKustomizationPath, []byte(kustomization), 0644)).To(Succeed())
+			Expect(scaffoldCSVAnnotations(fs, cfgv3.New(), ocpVersionRange{min: "4.14"}, "stable")).To(Succeed())
+
+			kustomizationOut, err := afero.ReadFile(fs.FS, manifestsKustomizationPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(kustomizationOut)).To(Equal(kustomization))
+			Expect(afero.Exists(fs.FS, "config/manifests/bases/openshift_csv_patch.yaml")).To(BeTrue())
+		})
+
+		It("scaffolds a CSV patch without a manifests kustomization", func() {
+			Expect(scaffoldCSVAnnotations(fs, cfgv3.New(), ocpVersionRange{min: "4.14"}, "stable")).To(Succeed())
+			patchOut, err := afero.ReadFile(fs.FS, "config/manifests/bases/openshift_csv_patch.yaml")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(patchOut)).To(ContainSubstring(`    com.redhat.openshift.versions: "=v4.14"` + "\n"))
+		})
+	})
+})
+
+const manifestsKustomization = `# These resources constitute the fully configured set of manifests
+# used to generate the 'manifests/' directory in a bundle.
+resources:
+- bases/memcached-operator.clusterserviceversion.yaml
+- ../default
+- ../samples
+- ../scorecard
+`
@@ -0,0 +1,66 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/spf13/afero"
+)
+
+// stdoutPath is the --diff-output value that writes to stdout.
+const stdoutPath = "-"
+
+// writeUnifiedDiff writes a unified diff of path's contents from a to b to w.
+// Paths are prefixed with "a/" and "b/" so the diff can be applied with "git apply".
+func writeUnifiedDiff(w io.Writer, path string, a, b []byte) error {
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(a),
+		B:        splitLines(b),
+		FromFile: "a/" + path,
+		ToFile:   "b/" + path,
+		Context:  3,
+	})
+	if err != nil {
+		return fmt.Errorf("error computing diff of %s: %v", path, err)
+	}
+	_, err = io.WriteString(w, diff)
+	return err
+}
+
+// writeDiffOutput writes diff to path in fs, or to stdout if path is "-".
+func writeDiffOutput(fs afero.Fs, path string, diff []byte, stdout io.Writer) error {
+	if path == stdoutPath {
+		_, err := stdout.Write(diff)
+		return err
+	}
+	if err := afero.WriteFile(fs, path, diff, 0644); err != nil {
+		return fmt.Errorf("error writing diff output: %v", err)
+	}
+	return nil
+}
+
+// splitLines splits b after each newline. Unlike difflib.SplitLines,
+// no empty line follows a trailing newline.
+func splitLines(b []byte) []string {
+	lines := strings.SplitAfter(string(b), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
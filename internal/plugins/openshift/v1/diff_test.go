@@ -0,0 +1,79 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+	cfgv3 "sigs.k8s.io/kubebuilder/v3/pkg/config/v3"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+var _ = Describe("Diff output", func() {
+	var fs machinery.Filesystem
+
+	const dockerfileGo = "FROM golang:1.19 as builder\n\nFROM gcr.io/distroless/static:nonroot\nWORKDIR /\n"
+
+	expDiff := `--- a/Dockerfile
++++ b/Dockerfile
+@@ -1,4 +1,4 @@
+ FROM golang:1.19 as builder
+ 
+-FROM gcr.io/distroless/static:nonroot
++FROM registry.access.redhat.com/ubi8/ubi-minimal:` + ubiMinimalVersion + `
+ WORKDIR /
+`
+
+	BeforeEach(func() {
+		fs = machinery.Filesystem{FS: afero.NewMemMapFs()}
+		Expect(afero.WriteFile(fs.FS, "Dockerfile", []byte(dockerfileGo), 0644)).To(Succeed())
+	})
+
+	It("writes a unified diff of changed files to a path", func() {
+		s := &initSubcommand{options: imageOptions{diffOutput: "images.patch"}}
+		Expect(s.InjectConfig(cfgv3.New())).To(Succeed())
+		Expect(s.PreScaffold(fs)).To(Succeed())
+		Expect(s.Scaffold(fs)).To(Succeed())
+		diffOut, err := afero.ReadFile(fs.FS, "images.patch")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(diffOut)).To(Equal(expDiff))
+	})
+
+	It("writes a unified diff to stdout in dry-run mode", func() {
+		out := &bytes.Buffer{}
+		s := &initSubcommand{options: imageOptions{diffOutput: stdoutPath, dryRun: true, out: out}}
+		Expect(s.InjectConfig(cfgv3.New())).To(Succeed())
+		Expect(s.PreScaffold(fs)).To(Succeed())
+		Expect(ExitCode(s.Scaffold(fs))).To(Equal(ExitCodeSubstitutionsNeeded))
+		Expect(out.String()).To(HaveSuffix(expDiff))
+		dockerfileOut, err := afero.ReadFile(fs.FS, "Dockerfile")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(dockerfileOut)).To(Equal(dockerfileGo))
+	})
+
+	It("writes an empty diff if nothing changed", func() {
+		s := &initSubcommand{options: imageOptions{diffOutput: "images.patch"}}
+		Expect(s.InjectConfig(cfgv3.New())).To(Succeed())
+		Expect(s.PreScaffold(fs)).To(Succeed())
+		Expect(s.Scaffold(fs)).To(Succeed())
+		Expect(s.Scaffold(fs)).To(Succeed())
+		diffOut, err := afero.ReadFile(fs.FS, "images.patch")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(diffOut).To(BeEmpty())
+	})
+})
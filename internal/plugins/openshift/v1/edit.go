@@ -0,0 +1,231 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/kubebuilder/v3/pkg/config"
+	"sigs.k8s.io/kubebuilder/v3/pkg/config/store"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+	"sigs.k8s.io/kubebuilder/v3/pkg/plugin"
+
+	"github.com/operator-framework/operator-sdk/internal/flags"
+)
+
+var _ plugin.EditSubcommand = &editSubcommand{}
+
+type editSubcommand struct {
+	config config.Config
+
+	// flags are the flags bound by BindFlags, used to tell which flags were given.
+	flags *pflag.FlagSet
+
+	// ocpVersion and ubiVersion replace the recorded versions if set.
+	ocpVersion string
+	ubiVersion string
+	// noVersionWarnings silences warnings about end of life and untested OCP releases.
+	noVersionWarnings bool
+
+	// reverse restores upstream images, tagging operator-framework images with upstreamTag.
+	reverse     bool
+	upstreamTag string
+
+	// quiet logs only errors.
+	quiet bool
+	// projectRoot is the directory of the project, resolved to the working directory if it is not given.
+	projectRoot string
+	// projectConfig is the config of the project at projectRoot if it is not the working directory,
+	// which replaces config and is saved once the new versions are recorded in it.
+	projectConfig store.Store
+
+	// ctx cancels image substitutions, if set.
+	ctx context.Context
+}
+
+func (s *editSubcommand) UpdateMetadata(cliMeta plugin.CLIMetadata, subcmdMeta *plugin.SubcommandMetadata) {
+	subcmdMeta.Description = `Re-tag the OpenShift images of an existing project with new OCP and UBI versions.
+Only the image versions recorded in the project's plugin config are changed. Versions set in the
+project's .openshiftplugin.yaml are used if their flags are not given.
+`
+	subcmdMeta.Examples = fmt.Sprintf(`  # Move a project's images to OCP 4.15, and the UBI version known to work with it
+  $ %[1]s edit --plugins=%[2]s --%[3]s=4.15
+
+  # Move a project's UBI base images to UBI 8.9
+  $ %[1]s edit --plugins=%[2]s --%[4]s=8.9
+
+  # Restore a project's upstream images for local testing, then substitute downstream images again
+  $ %[1]s edit --plugins=%[2]s --%[5]s
+  $ %[1]s edit --plugins=%[2]s
+`, cliMeta.CommandName, pluginKey, ocpVersionFlag, ubiVersionFlag, reverseFlag)
+}
+
+func (s *editSubcommand) BindFlags(fs *pflag.FlagSet) {
+	s.flags = fs
+	fs.SortFlags = false
+	fs.StringVar(&s.ocpVersion, ocpVersionFlag, "",
+		"OCP release version to re-tag downstream (registry.redhat.io/openshift4/ose-*) images with, ex. 4.15 "+
+			"(default ocpVersion in "+localConfigPath+" if set, otherwise the recorded version)")
+	fs.BoolVar(&s.noVersionWarnings, noVersionWarningsFlag, false,
+		"do not warn if --"+ocpVersionFlag+" is an end of life or untested OCP release")
+	fs.StringVar(&s.ubiVersion, ubiVersionFlag, "",
+		"version to re-tag UBI base images with, ex. 8.9 (default ubiVersion in "+localConfigPath+
+			" if set, otherwise the UBI version known to work with --"+ocpVersionFlag+" if set, otherwise the recorded version)")
+	fs.BoolVar(&s.reverse, reverseFlag, false,
+		"restore the upstream images that downstream images were substituted for; "+
+			"images are substituted again by editing without --"+reverseFlag)
+	fs.StringVar(&s.upstreamTag, upstreamTagFlag, defaultUpstreamTag,
+		"tag of quay.io/operator-framework images restored by --"+reverseFlag)
+	fs.BoolVar(&s.quiet, quietFlag, false,
+		"print only errors, no warnings or progress; cannot be set with --"+flags.VerboseOpt)
+	fs.StringVar(&s.projectRoot, projectRootFlag, "",
+		"path of the project's root directory, containing its PROJECT file, to re-tag images in when not run from it; "+
+			"the recorded versions are read from and written to its PROJECT file (default the working directory)")
+}
+
+func (s *editSubcommand) InjectConfig(c config.Config) error {
+	s.config = c
+	return nil
+}
+
+// PreScaffold validates flag values before any files are changed. Versions whose flags were
+// not given are set from the local config file, if any, unless upstream images are restored.
+func (s *editSubcommand) PreScaffold(fs machinery.Filesystem) error {
+	if err := checkFlagConflicts(s.flagConflicts()); err != nil {
+		return err
+	}
+	if s.quiet {
+		logOnlyErrors(nil)
+	}
+	if s.flags != nil && s.flags.Changed(upstreamTagFlag) && !s.reverse {
+		return fmt.Errorf("--%s requires --%s", upstreamTagFlag, reverseFlag)
+	}
+	root, err := resolveProjectRoot(fs.FS, s.projectRoot)
+	if err != nil {
+		return err
+	}
+	if root != "." {
+		if s.projectConfig, err = loadProjectRootConfig(fs.FS, root); err != nil {
+			return err
+		}
+		s.config = s.projectConfig.Config()
+	}
+	s.projectRoot, fs = root, machinery.Filesystem{FS: newProjectRootFs(fs.FS, root)}
+	if !s.reverse {
+		local, err := loadLocalConfig(fs.FS)
+		if err != nil {
+			return err
+		}
+		if s.ocpVersion == "" {
+			s.ocpVersion = local.OCPVersion
+		}
+		if s.ubiVersion == "" {
+			s.ubiVersion = local.UBIVersion
+		}
+	}
+	if s.ocpVersion != "" {
+		if err := validateOCPVersion(s.ocpVersion); err != nil {
+			return err
+		}
+		if warning := ocpVersionWarning(s.ocpVersion); warning != "" && !s.noVersionWarnings {
+			log.Warn(warning)
+		}
+	}
+	return nil
+}
+
+// Scaffold re-tags images substituted with the recorded versions, substitutes any remaining
+// upstream images, and records the new versions. If reverse is set, upstream images are
+// restored instead, and the project is recorded as reversed.
+func (s *editSubcommand) Scaffold(fs machinery.Filesystem) error {
+	cfg, err := decodeConfig(s.config)
+	if err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	// Retagging images would leave the recorded config out of step with the project.
+	if passthroughFromEnv() {
+		log.Infof("Skipping edit in passthrough mode set by $%s", passthroughEnv)
+		return nil
+	}
+
+	newCfg := cfg
+	if s.ocpVersion != "" {
+		newCfg.OCPVersion = s.ocpVersion
+		newCfg.UBIVersion = ubiVersionForOCP(s.ocpVersion, cfg.UBIMajor)
+	}
+	if s.ubiVersion != "" {
+		newCfg.UBIVersion = s.ubiVersion
+	}
+	if err := validateUBIVersion(newCfg.UBIMajor, newCfg.UBIVersion); err != nil {
+		return err
+	}
+	newCfg.Reversed = s.reverse
+	if err := newCfg.Validate(); err != nil {
+		return err
+	}
+
+	from, opts := cfg.imageOptions(), newCfg.imageOptions()
+	from.ProjectType, opts.ProjectType = projectType(s.config), projectType(s.config)
+	opts.authProxyOptional = mayOmitAuthProxy(s.config)
+	opts.ctx = s.ctx
+	if s.reverse {
+		opts.reverse, opts.upstreamTag = true, s.upstreamTag
+	} else {
+		opts.extraSubstitutions = retagSubstitutions(from.Options, opts.Options)
+	}
+	if _, err := replaceImages(machinery.Filesystem{FS: newProjectRootFs(fs.FS, s.projectRoot)}, opts); err != nil {
+		return err
+	}
+
+	if err := s.config.EncodePluginConfig(pluginKey, newCfg); err != nil && !errors.As(err, &config.UnsupportedFieldError{}) {
+		return fmt.Errorf("error writing plugin config for %s: %v", pluginKey, err)
+	}
+	return saveProjectRootConfig(s.projectConfig)
+}
+
+// retagSubstitutions returns substitutions that replace each image the built-in substitutions
+// produce with from by the image they produce with to. Both sets list each path's substitutions
+// in the same order, so substitutions are paired by index.
+func retagSubstitutions(from, to Options) map[string][]Substitution {
+	fromSubsts, toSubsts := BuildSubstitutions(from), BuildSubstitutions(to)
+	substs := map[string][]Substitution{}
+	for filePath, fileSubsts := range fromSubsts {
+		toFileSubsts := toSubsts[filePath]
+		for i, subst := range fileSubsts {
+			if i >= len(toFileSubsts) || toFileSubsts[i].Category != subst.Category {
+				continue
+			}
+			toTag := toFileSubsts[i].ToTag
+			if toTag == subst.ToTag {
+				continue
+			}
+			substs[filePath] = append(substs[filePath], Substitution{
+				Category: subst.Category,
+				// Do not match a longer tag that the image's tag is a prefix of.
+				FromTagRE: mustCompileCached(regexp.QuoteMeta(subst.ToTag) + `\b`),
+				ToTag:     toTag,
+			})
+		}
+	}
+	return substs
+}
@@ -0,0 +1,173 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+	"sigs.k8s.io/kubebuilder/v3/pkg/config"
+	cfgv3 "sigs.k8s.io/kubebuilder/v3/pkg/config/v3"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+var _ = Describe("RunEdit", func() {
+
+	Describe("Scaffold", func() {
+		var (
+			fs machinery.Filesystem
+			c  config.Config
+		)
+
+		BeforeEach(func() {
+			fs = machinery.Filesystem{FS: afero.NewMemMapFs()}
+			Expect(afero.WriteFile(fs.FS, "Dockerfile", []byte(dockerfileAll), 0644)).To(Succeed())
+			Expect(afero.WriteFile(fs.FS, authProxyPatchPath, []byte(proxyPatch), 0644)).To(Succeed())
+
+			c = cfgv3.New()
+			s := &initSubcommand{}
+			Expect(s.InjectConfig(c)).To(Succeed())
+			Expect(s.PreScaffold(fs)).To(Succeed())
+			Expect(s.Scaffold(fs)).To(Succeed())
+		})
+
+		It("re-tags substituted images with the OCP version of the local config file if --ocp-version is not given", func() {
+			Expect(afero.WriteFile(fs.FS, localConfigPath, []byte("ocpVersion: \"4.15\"\n"), 0644)).To(Succeed())
+			s := &editSubcommand{}
+			Expect(s.InjectConfig(c)).To(Succeed())
+			Expect(s.PreScaffold(fs)).To(Succeed())
+			Expect(s.Scaffold(fs)).To(Succeed())
+
+			dockerfileOut, err := afero.ReadFile(fs.FS, "Dockerfile")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(dockerfileOut)).To(ContainSubstring("FROM registry.redhat.io/openshift4/ose-ansible-operator:v4.15\n"))
+			cfg, err := decodeConfig(c)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.OCPVersion).To(Equal("4.15"))
+		})
+
+		It("re-tags substituted images with a new OCP version and its UBI version", func() {
+			s := &editSubcommand{ocpVersion: "4.15"}
+			Expect(s.InjectConfig(c)).To(Succeed())
+			Expect(s.PreScaffold(fs)).To(Succeed())
+			Expect(s.Scaffold(fs)).To(Succeed())
+
+			dockerfileOut, err := afero.ReadFile(fs.FS, "Dockerfile")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(dockerfileOut)).To(ContainSubstring("FROM registry.redhat.io/openshift4/ose-ansible-operator:v4.15\n"))
+			Expect(string(dockerfileOut)).To(ContainSubstring("FROM registry.redhat.io/openshift4/ose-helm-operator:v4.15\n"))
+			Expect(string(dockerfileOut)).To(ContainSubstring("FROM registry.access.redhat.com/ubi8/ubi-minimal:8.9\n"))
+			Expect(string(dockerfileOut)).To(ContainSubstring("FROM registry.access.redhat.com/ubi8/ubi-micro:8.9\n"))
+			Expect(string(dockerfileOut)).NotTo(ContainSubstring("v" + ocpProductVersion))
+			proxyPatchOut, err := afero.ReadFile(fs.FS, authProxyPatchPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(proxyPatchOut)).To(ContainSubstring("image: registry.redhat.io/openshift4/ose-kube-rbac-proxy:v4.15\n"))
+
+			cfg, err := decodeConfig(c)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.OCPVersion).To(Equal("4.15"))
+			Expect(cfg.UBIVersion).To(Equal("8.9"))
+			Expect(cfg.UBIMajor).To(Equal(8))
+		})
+
+		It("re-tags each of several substituted images of one category in the same file", func() {
+			Expect(afero.WriteFile(fs.FS, "config/prometheus/exporter.yaml", []byte(prometheusExporterExp), 0644)).To(Succeed())
+			s := &editSubcommand{ocpVersion: "4.15"}
+			Expect(s.InjectConfig(c)).To(Succeed())
+			Expect(s.PreScaffold(fs)).To(Succeed())
+			Expect(s.Scaffold(fs)).To(Succeed())
+
+			exporterOut, err := afero.ReadFile(fs.FS, "config/prometheus/exporter.yaml")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(exporterOut)).To(ContainSubstring("image: registry.redhat.io/openshift4/ose-prometheus-node-exporter:v4.15\n"))
+			Expect(string(exporterOut)).To(ContainSubstring("image: \"registry.redhat.io/openshift4/ose-kube-state-metrics:v4.15\"\n"))
+			Expect(string(exporterOut)).To(ContainSubstring("image: quay.io/prometheus/blackbox-exporter:v0.24.0\n"))
+		})
+
+		It("re-tags only UBI images with a new UBI version", func() {
+			s := &editSubcommand{ubiVersion: "8.10"}
+			Expect(s.InjectConfig(c)).To(Succeed())
+			Expect(s.PreScaffold(fs)).To(Succeed())
+			Expect(s.Scaffold(fs)).To(Succeed())
+
+			dockerfileOut, err := afero.ReadFile(fs.FS, "Dockerfile")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(dockerfileOut)).To(ContainSubstring("FROM registry.access.redhat.com/ubi8/ubi-minimal:8.10\n"))
+			Expect(string(dockerfileOut)).To(ContainSubstring("FROM registry.redhat.io/openshift4/ose-helm-operator:v" + ocpProductVersion + "\n"))
+
+			cfg, err := decodeConfig(c)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.OCPVersion).To(Equal(ocpProductVersion))
+			Expect(cfg.UBIVersion).To(Equal("8.10"))
+		})
+
+		It("rejects a UBI version that does not match the recorded UBI major version", func() {
+			s := &editSubcommand{ubiVersion: "9.2"}
+			Expect(s.InjectConfig(c)).To(Succeed())
+			Expect(s.PreScaffold(fs)).To(Succeed())
+			Expect(s.Scaffold(fs)).To(MatchError(ContainSubstring("--" + ubiVersionFlag)))
+		})
+
+		It("rejects malformed OCP versions", func() {
+			s := &editSubcommand{ocpVersion: "v4.15"}
+			Expect(s.PreScaffold(fs)).To(MatchError(ContainSubstring("--" + ocpVersionFlag)))
+		})
+
+		It("restores upstream images, and substitutes them again", func() {
+			s := &editSubcommand{reverse: true, upstreamTag: "v1.31.0"}
+			Expect(s.InjectConfig(c)).To(Succeed())
+			Expect(s.PreScaffold(fs)).To(Succeed())
+			Expect(s.Scaffold(fs)).To(Succeed())
+
+			dockerfileOut, err := afero.ReadFile(fs.FS, "Dockerfile")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(dockerfileOut)).To(ContainSubstring("FROM quay.io/operator-framework/ansible-operator:v1.31.0\n"))
+			Expect(string(dockerfileOut)).To(ContainSubstring("FROM quay.io/operator-framework/helm-operator:v1.31.0\n"))
+			Expect(string(dockerfileOut)).To(ContainSubstring("FROM gcr.io/distroless/static:nonroot\n"))
+			Expect(string(dockerfileOut)).NotTo(ContainSubstring("registry.redhat.io"))
+			proxyPatchOut, err := afero.ReadFile(fs.FS, authProxyPatchPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(proxyPatchOut)).To(ContainSubstring("image: gcr.io/kubebuilder/kube-rbac-proxy:" + upstreamRBACProxyTag + "\n"))
+			cfg, err := decodeConfig(c)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Reversed).To(BeTrue())
+
+			// Creating an API must not substitute downstream images in a reversed project.
+			api := &createAPISubcommand{}
+			Expect(api.InjectConfig(c)).To(Succeed())
+			Expect(api.Scaffold(fs)).To(Succeed())
+			dockerfileAPIOut, err := afero.ReadFile(fs.FS, "Dockerfile")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(dockerfileAPIOut).To(Equal(dockerfileOut))
+
+			s = &editSubcommand{}
+			Expect(s.InjectConfig(c)).To(Succeed())
+			Expect(s.PreScaffold(fs)).To(Succeed())
+			Expect(s.Scaffold(fs)).To(Succeed())
+			dockerfileOut, err = afero.ReadFile(fs.FS, "Dockerfile")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(dockerfileOut)).To(ContainSubstring("FROM registry.redhat.io/openshift4/ose-ansible-operator:v" + ocpProductVersion + "\n"))
+			Expect(string(dockerfileOut)).To(ContainSubstring("FROM registry.access.redhat.com/ubi8/ubi-minimal:" + ubiMinimalVersion + "\n"))
+			cfg, err = decodeConfig(c)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Reversed).To(BeFalse())
+		})
+
+		It("rejects --reverse with new versions", func() {
+			s := &editSubcommand{reverse: true, ocpVersion: "4.15"}
+			Expect(s.PreScaffold(fs)).To(MatchError(ContainSubstring("--" + reverseFlag)))
+		})
+	})
+})
@@ -0,0 +1,161 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"sigs.k8s.io/kubebuilder/v3/pkg/config"
+	"sigs.k8s.io/kubebuilder/v3/pkg/config/store/yaml"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+const (
+	// defaultSource is the source of settings that are not set by any config source.
+	defaultSource = "default"
+	// projectSource is the source of settings recorded in the PROJECT file's plugin config.
+	projectSource = "PROJECT"
+)
+
+// EffectiveConfig is the plugin config resolved for a project, with the source of each setting.
+type EffectiveConfig struct {
+	Config Config `json:"config"`
+	// Sources map the names of Config's settings to the source their value was resolved from.
+	Sources map[string]string `json:"sources"`
+}
+
+// ResolveConfig resolves the plugin config of the project in fs from, in order of precedence,
+// the local config file, the plugin config recorded in the PROJECT file, the environment,
+// and defaults. Flags given to a subcommand take precedence over all of these.
+// UBI versions that are not set by the OCP version's source default to the UBI version
+// known to work with it.
+func ResolveConfig(fs machinery.Filesystem) (EffectiveConfig, error) {
+	cfg := newConfig(defaultImageOptions())
+	sources := map[string]string{}
+
+	if v, ok := os.LookupEnv(ocpVersionEnv); ok {
+		cfg.OCPVersion = v
+		sources["ocpVersion"] = "$" + ocpVersionEnv
+	}
+	if v, ok := os.LookupEnv(ubiVersionEnv); ok {
+		cfg.UBIVersion = v
+		sources["ubiVersion"] = "$" + ubiVersionEnv
+	} else if _, ok := sources["ocpVersion"]; ok {
+		cfg.UBIVersion = ubiVersionForOCP(cfg.OCPVersion, cfg.UBIMajor)
+	}
+
+	c, err := loadProjectConfig(fs)
+	if err != nil {
+		return EffectiveConfig{}, err
+	}
+	if c != nil {
+		var recorded map[string]interface{}
+		err := c.DecodePluginConfig(pluginKey, &recorded)
+		if err != nil && !errors.As(err, &config.PluginKeyNotFoundError{}) && !errors.As(err, &config.UnsupportedFieldError{}) {
+			return EffectiveConfig{}, fmt.Errorf("error reading plugin config for %s: %v", pluginKey, err)
+		}
+		// Recorded plugin configs replace the environment, which only applies to new projects.
+		if err == nil {
+			if cfg, err = decodeConfig(c); err != nil {
+				return EffectiveConfig{}, err
+			}
+			sources = map[string]string{}
+			for key := range recorded {
+				sources[key] = projectSource
+			}
+		}
+	}
+
+	local, err := loadLocalConfig(fs.FS)
+	if err != nil {
+		return EffectiveConfig{}, err
+	}
+	if local.OCPVersion != "" {
+		cfg.OCPVersion = local.OCPVersion
+		sources["ocpVersion"] = localConfigPath
+		if local.UBIVersion == "" {
+			cfg.UBIVersion = ubiVersionForOCP(cfg.OCPVersion, cfg.UBIMajor)
+			delete(sources, "ubiVersion")
+		}
+	}
+	if local.UBIVersion != "" {
+		cfg.UBIVersion = local.UBIVersion
+		sources["ubiVersion"] = localConfigPath
+	}
+	if local.Registry != "" {
+		cfg.Registry = local.Registry
+		sources["registry"] = localConfigPath
+	}
+	if local.Disable != nil {
+		cfg.Disabled = local.Disable
+		sources["disabled"] = localConfigPath
+	}
+
+	// Only settings that are set are printed, so only those have a source.
+	b, err := json.Marshal(cfg)
+	if err != nil {
+		return EffectiveConfig{}, fmt.Errorf("error encoding plugin config: %v", err)
+	}
+	var settings map[string]interface{}
+	if err := json.Unmarshal(b, &settings); err != nil {
+		return EffectiveConfig{}, fmt.Errorf("error decoding plugin config: %v", err)
+	}
+	effective := EffectiveConfig{Config: cfg, Sources: map[string]string{}}
+	for key := range settings {
+		effective.Sources[key] = defaultSource
+		if source, ok := sources[key]; ok {
+			effective.Sources[key] = source
+		}
+	}
+	return effective, nil
+}
+
+// ProjectConfig returns the plugin config recorded in the PROJECT file of the project in fs,
+// exactly as recorded: unlike ResolveConfig, no defaults or other config sources are applied.
+func ProjectConfig(fs machinery.Filesystem) (Config, error) {
+	c, err := loadProjectConfig(fs)
+	if err != nil {
+		return Config{}, err
+	}
+	if c == nil {
+		return Config{}, fmt.Errorf("no PROJECT file found")
+	}
+	var cfg Config
+	err = c.DecodePluginConfig(pluginKey, &cfg)
+	switch {
+	case errors.As(err, &config.UnsupportedFieldError{}):
+		return Config{}, fmt.Errorf("PROJECT file version %s does not record plugin configs", c.GetVersion())
+	case errors.As(err, &config.PluginKeyNotFoundError{}):
+		return Config{}, fmt.Errorf("PROJECT file records no plugin config for %s", pluginKey)
+	case err != nil:
+		return Config{}, fmt.Errorf("error reading plugin config for %s: %v", pluginKey, err)
+	}
+	return cfg, nil
+}
+
+// loadProjectConfig reads the PROJECT file in fs. It returns nil if the project has none.
+func loadProjectConfig(fs machinery.Filesystem) (config.Config, error) {
+	store := yaml.New(fs)
+	if err := store.Load(); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading project config: %v", err)
+	}
+	return store.Config(), nil
+}
@@ -0,0 +1,128 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+	"sigs.k8s.io/kubebuilder/v3/pkg/config"
+	cfgv3 "sigs.k8s.io/kubebuilder/v3/pkg/config/v3"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+var _ = Describe("ResolveConfig", func() {
+	var fs machinery.Filesystem
+
+	BeforeEach(func() {
+		fs = machinery.Filesystem{FS: afero.NewMemMapFs()}
+	})
+
+	writeProject := func(cfg Config) {
+		c := cfgv3.New()
+		Expect(c.SetDomain("example.com")).To(Succeed())
+		Expect(c.EncodePluginConfig(pluginKey, cfg)).To(Succeed())
+		b, err := c.MarshalYAML()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(afero.WriteFile(fs.FS, "PROJECT", b, 0644)).To(Succeed())
+	}
+
+	It("resolves defaults without any config source", func() {
+		effective, err := ResolveConfig(fs)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(effective.Config).To(Equal(newConfig(defaultImageOptions())))
+		Expect(effective.Sources).To(Equal(map[string]string{
+			"ocpVersion": defaultSource,
+			"ubiVersion": defaultSource,
+			"ubiMajor":   defaultSource,
+		}))
+	})
+
+	Context("with OCP and UBI versions in the environment", func() {
+		BeforeEach(func() {
+			Expect(os.Setenv(ocpVersionEnv, "4.13")).To(Succeed())
+		})
+		AfterEach(func() {
+			Expect(os.Unsetenv(ocpVersionEnv)).To(Succeed())
+		})
+
+		It("uses the environment for new projects", func() {
+			effective, err := ResolveConfig(fs)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(effective.Config.OCPVersion).To(Equal("4.13"))
+			Expect(effective.Config.UBIVersion).To(Equal(ubiVersionForOCP("4.13", 8)))
+			Expect(effective.Sources["ocpVersion"]).To(Equal("$" + ocpVersionEnv))
+			Expect(effective.Sources["ubiVersion"]).To(Equal(defaultSource))
+		})
+
+		It("prefers the recorded plugin config, and the local config file over both", func() {
+			writeProject(Config{OCPVersion: "4.14", UBIVersion: "8.8", UBIMajor: 8, Registry: "mirror.example.com"})
+			Expect(afero.WriteFile(fs.FS, localConfigPath, []byte("ocpVersion: \"4.15\"\n"), 0644)).To(Succeed())
+			effective, err := ResolveConfig(fs)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(effective.Config.OCPVersion).To(Equal("4.15"))
+			Expect(effective.Config.UBIVersion).To(Equal(ubiVersionForOCP("4.15", 8)))
+			Expect(effective.Config.Registry).To(Equal("mirror.example.com"))
+			Expect(effective.Sources).To(Equal(map[string]string{
+				"ocpVersion": localConfigPath,
+				"ubiVersion": defaultSource,
+				"ubiMajor":   projectSource,
+				"registry":   projectSource,
+			}))
+		})
+	})
+
+	It("fails on an invalid local config file", func() {
+		Expect(afero.WriteFile(fs.FS, localConfigPath, []byte("ocpVersoin: \"4.15\"\n"), 0644)).To(Succeed())
+		_, err := ResolveConfig(fs)
+		Expect(err).To(MatchError(ContainSubstring("error parsing " + localConfigPath)))
+	})
+})
+
+var _ = Describe("ProjectConfig", func() {
+	var fs machinery.Filesystem
+
+	BeforeEach(func() {
+		fs = machinery.Filesystem{FS: afero.NewMemMapFs()}
+	})
+
+	writeProject := func(c config.Config) {
+		b, err := c.MarshalYAML()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(afero.WriteFile(fs.FS, "PROJECT", b, 0644)).To(Succeed())
+	}
+
+	It("returns the recorded plugin config without defaults", func() {
+		c := cfgv3.New()
+		Expect(c.EncodePluginConfig(pluginKey, Config{OCPVersion: "4.14", Registry: "mirror.example.com"})).To(Succeed())
+		writeProject(c)
+		cfg, err := ProjectConfig(fs)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg).To(Equal(Config{OCPVersion: "4.14", Registry: "mirror.example.com"}))
+	})
+
+	It("fails if there is no PROJECT file", func() {
+		_, err := ProjectConfig(fs)
+		Expect(err).To(MatchError("no PROJECT file found"))
+	})
+
+	It("fails if the PROJECT file records no plugin config", func() {
+		writeProject(cfgv3.New())
+		_, err := ProjectConfig(fs)
+		Expect(err).To(MatchError("PROJECT file records no plugin config for " + pluginKey))
+	})
+})
@@ -0,0 +1,51 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import "errors"
+
+// ExitCodeSubstitutionsNeeded is the exit code of a dry run that would substitute images,
+// and of verification that found upstream images. A project that needs no substitutions exits
+// with 0, and any other error exits with 1.
+const ExitCodeSubstitutionsNeeded = 2
+
+// ExitError is an error that sets the exit code of the operator-sdk binary.
+type ExitError struct {
+	// Code is the exit code.
+	Code int
+	// Err is the error reported before exiting.
+	Err error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the exit code for err: 0 if err is nil, the code of the first ExitError
+// in err's chain, or otherwise 1.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	return 1
+}
@@ -0,0 +1,42 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"errors"
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ExitCode", func() {
+	It("returns the exit code of an error", func() {
+		exitErr := &ExitError{Code: ExitCodeSubstitutionsNeeded, Err: errors.New("needs substitutions")}
+		cases := []struct {
+			err  error
+			code int
+		}{
+			{nil, 0},
+			{errors.New("failed"), 1},
+			{exitErr, ExitCodeSubstitutionsNeeded},
+			{fmt.Errorf("init: %w", exitErr), ExitCodeSubstitutionsNeeded},
+		}
+		for _, c := range cases {
+			Expect(ExitCode(c.err)).To(Equal(c.code))
+		}
+		Expect(exitErr).To(MatchError("needs substitutions"))
+	})
+})
@@ -0,0 +1,128 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// backupSuffix is appended to the path of a file to get the path of its backup.
+const backupSuffix = ".orig"
+
+// writeRetryDelay is how long writeFile waits before retrying a failed write.
+var writeRetryDelay = 100 * time.Millisecond
+
+// writeFile writes b to path atomically, retrying once after writeRetryDelay if the write
+// fails with an error that may be transient. Errors include path.
+func writeFile(fs afero.Fs, path string, b []byte, mode os.FileMode) error {
+	dir := filepath.Dir(path)
+	if info, err := fs.Stat(dir); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("error writing %s: directory %s does not exist", path, dir)
+		}
+		return fmt.Errorf("error writing %s: %v", path, err)
+	} else if !info.IsDir() {
+		return fmt.Errorf("error writing %s: %s is not a directory", path, dir)
+	}
+
+	err := writeFileAtomic(fs, path, b, mode)
+	if err != nil && isTransient(err) {
+		time.Sleep(writeRetryDelay)
+		err = writeFileAtomic(fs, path, b, mode)
+	}
+	if err != nil {
+		return fmt.Errorf("error writing %s: %v", path, err)
+	}
+	return nil
+}
+
+// isTransient reports whether a write that failed with err may succeed if retried,
+// which is not the case for missing files, denied permissions, or read-only filesystems.
+func isTransient(err error) bool {
+	return !errors.Is(err, os.ErrNotExist) && !errors.Is(err, os.ErrPermission) && !errors.Is(err, syscall.EROFS)
+}
+
+// writeFileAtomic writes b to path by writing a temporary file in the same directory
+// then renaming it to path, so path never contains partially written data.
+func writeFileAtomic(fs afero.Fs, path string, b []byte, mode os.FileMode) error {
+	tmp, err := afero.TempFile(fs, filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	_, err = tmp.Write(b)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = fs.Chmod(tmpPath, mode)
+	}
+	if err == nil {
+		err = fs.Rename(tmpPath, path)
+	}
+	if err != nil {
+		_ = fs.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// newOutputDirFs returns a filesystem that reads files from fs, but writes them to the same
+// relative paths under dir instead, so that fs is left unchanged. Files written earlier are
+// read back from dir.
+func newOutputDirFs(fs afero.Fs, dir string) afero.Fs {
+	return afero.NewCopyOnWriteFs(afero.NewReadOnlyFs(fs), afero.NewBasePathFs(fs, dir))
+}
+
+// validateOutputDir returns an error if dir is set to the project root, which would
+// overwrite files in place.
+func validateOutputDir(dir string) error {
+	if dir != "" && filepath.Clean(dir) == "." {
+		return fmt.Errorf("invalid --%s value %q: must not be the project root", outputDirFlag, dir)
+	}
+	return nil
+}
+
+// fileBackup records the contents of a file before it was modified.
+type fileBackup struct {
+	path string
+	b    []byte
+	mode os.FileMode
+}
+
+// writeBackup writes a copy of a file's original contents to its backup path.
+func writeBackup(fs afero.Fs, backup fileBackup) error {
+	if err := writeFileAtomic(fs, backup.path+backupSuffix, backup.b, backup.mode); err != nil {
+		return fmt.Errorf("error backing up %s: %v", backup.path, err)
+	}
+	return nil
+}
+
+// restoreBackups writes the original contents of each backed up file back to its path.
+func restoreBackups(fs afero.Fs, backups []fileBackup) error {
+	for _, backup := range backups {
+		if err := writeFileAtomic(fs, backup.path, backup.b, backup.mode); err != nil {
+			return fmt.Errorf("error restoring %s: %v", backup.path, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/spf13/afero"
+)
+
+// fipsBuildNote is added above a Dockerfile's Go build command when cgo is enabled for FIPS mode.
+const fipsBuildNote = `# cgo is enabled for FIPS mode, so that the go-toolset toolchain uses the OpenSSL libraries
+# of the runtime image. Add GOEXPERIMENT=strictfipsruntime to fail at startup outside FIPS mode.
+`
+
+var (
+	// goToolsetFromRE matches the FROM line of a go-toolset builder stage and the line after it.
+	goToolsetFromRE = regexp.MustCompile(`(?m)^FROM [^ \n]+/go-toolset:[^\n]*\n(USER root\n)?`)
+	// cgoDisabledRE matches a RUN instruction that disables cgo, capturing the text before the setting.
+	cgoDisabledRE = regexp.MustCompile(`(?m)^(RUN [^\n]*)CGO_ENABLED=0`)
+)
+
+// addFIPSBuildSettings changes the Dockerfile's go-toolset builder stages to build in FIPS mode:
+// they run as root, since go-toolset images otherwise run as a user that cannot write to the
+// build's working directory, and build with cgo, which FIPS mode requires. Settings that are
+// already made are left unchanged. Projects without a Dockerfile are not changed.
+func addFIPSBuildSettings(fs afero.Fs) error {
+	b, err := afero.ReadFile(fs, "Dockerfile")
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("error reading Dockerfile: %v", err)
+	}
+
+	out := goToolsetFromRE.ReplaceAllFunc(b, func(match []byte) []byte {
+		if bytes.HasSuffix(match, []byte("USER root\n")) {
+			return match
+		}
+		return append(append([]byte{}, match...), "USER root\n"...)
+	})
+	out = cgoDisabledRE.ReplaceAll(out, []byte(fipsBuildNote+"${1}CGO_ENABLED=1"))
+	if bytes.Equal(out, b) {
+		return nil
+	}
+	info, err := fs.Stat("Dockerfile")
+	if err != nil {
+		return fmt.Errorf("error reading file info of Dockerfile: %v", err)
+	}
+	return writeFile(fs, "Dockerfile", out, info.Mode())
+}
@@ -0,0 +1,121 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+	cfgv3 "sigs.k8s.io/kubebuilder/v3/pkg/config/v3"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+var _ = Describe("addFIPSBuildSettings", func() {
+	var fs afero.Fs
+
+	BeforeEach(func() {
+		fs = afero.NewMemMapFs()
+	})
+
+	It("runs go-toolset builder stages as root and enables cgo, once", func() {
+		Expect(afero.WriteFile(fs, "Dockerfile", []byte(goToolsetDockerfile), 0644)).To(Succeed())
+		for i := 0; i < 2; i++ {
+			Expect(addFIPSBuildSettings(fs)).To(Succeed())
+			b, err := afero.ReadFile(fs, "Dockerfile")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(Equal(goToolsetDockerfileExp))
+		}
+	})
+
+	It("leaves projects without a Dockerfile unchanged", func() {
+		Expect(addFIPSBuildSettings(fs)).To(Succeed())
+		Expect(afero.Exists(fs, "Dockerfile")).To(BeFalse())
+	})
+
+	It("replaces only golang images of Docker Hub with --fips", func() {
+		mfs := machinery.Filesystem{FS: fs}
+		const dockerfile = "ARG BUILDER=golang:1.22\n" +
+			"FROM docker.io/library/golang:1.20 AS builder\n" +
+			"FROM mirror.example.com/golang:1.21 AS tools\n" +
+			"FROM example.com/library/golang:1.21 AS lint\n"
+		Expect(afero.WriteFile(fs, "Dockerfile", []byte(dockerfile), 0644)).To(Succeed())
+		s := &initSubcommand{options: imageOptions{Options: Options{FIPS: true, NoGoModEdit: true}}}
+		Expect(s.InjectConfig(cfgv3.New())).To(Succeed())
+		Expect(s.PreScaffold(mfs)).To(Succeed())
+		results, err := replaceImages(mfs, s.options)
+		Expect(err).NotTo(HaveOccurred())
+		b, err := afero.ReadFile(fs, "Dockerfile")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(Equal("ARG BUILDER=registry.access.redhat.com/ubi8/go-toolset:1.22\n" +
+			"FROM registry.access.redhat.com/ubi8/go-toolset:1.20 AS builder\n" +
+			"FROM mirror.example.com/golang:1.21 AS tools\n" +
+			"FROM example.com/library/golang:1.21 AS lint\n"))
+		var from []string
+		for _, result := range results {
+			if result.Category == GoBuilderCategory {
+				from = append(from, result.From...)
+			}
+		}
+		Expect(from).To(ConsistOf("golang:1.22", "docker.io/library/golang:1.20"))
+	})
+
+	It("replaces the golang builder image of init with --fips", func() {
+		mfs := machinery.Filesystem{FS: fs}
+		cases := []struct {
+			goBuilderVersion string
+			image            string
+		}{
+			{"", "registry.access.redhat.com/ubi8/go-toolset:1.20"},
+			{"1.21.3", "registry.access.redhat.com/ubi8/go-toolset:1.21"},
+		}
+		for _, c := range cases {
+			Expect(afero.WriteFile(fs, "Dockerfile", []byte(goDockerfile), 0644)).To(Succeed())
+			s := &initSubcommand{options: imageOptions{Options: Options{FIPS: true, GoBuilderVersion: c.goBuilderVersion}}}
+			Expect(s.InjectConfig(cfgv3.New())).To(Succeed())
+			Expect(s.PreScaffold(mfs)).To(Succeed())
+			Expect(s.Scaffold(mfs)).To(Succeed())
+			b, err := afero.ReadFile(fs, "Dockerfile")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(ContainSubstring("FROM " + c.image + " AS builder\nUSER root\n"))
+			Expect(string(b)).To(ContainSubstring("RUN CGO_ENABLED=1 GOOS="))
+			Expect(string(b)).To(ContainSubstring("FROM registry.access.redhat.com/ubi8/ubi-minimal:"))
+		}
+	})
+})
+
+const goDockerfile = `FROM golang:1.20 AS builder
+WORKDIR /workspace
+COPY go.mod go.mod
+RUN go mod download
+RUN CGO_ENABLED=0 GOOS=${TARGETOS:-linux} GOARCH=${TARGETARCH} go build -a -o manager cmd/main.go
+
+FROM gcr.io/distroless/static:nonroot
+COPY --from=builder /workspace/manager .
+`
+
+const goToolsetDockerfile = `FROM registry.access.redhat.com/ubi8/go-toolset:1.20 AS builder
+WORKDIR /workspace
+RUN CGO_ENABLED=0 GOOS=${TARGETOS:-linux} GOARCH=${TARGETARCH} go build -a -o manager cmd/main.go
+
+FROM registry.access.redhat.com/ubi8/ubi-minimal:8.8
+`
+
+const goToolsetDockerfileExp = `FROM registry.access.redhat.com/ubi8/go-toolset:1.20 AS builder
+USER root
+WORKDIR /workspace
+` + fipsBuildNote + `RUN CGO_ENABLED=1 GOOS=${TARGETOS:-linux} GOARCH=${TARGETARCH} go build -a -o manager cmd/main.go
+
+FROM registry.access.redhat.com/ubi8/ubi-minimal:8.8
+`
@@ -0,0 +1,126 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// isGlob reports whether filePath is a glob pattern, as matched by filepath.Match, rather than an exact path.
+func isGlob(filePath string) bool {
+	return strings.ContainsAny(filePath, `*?[\`)
+}
+
+// validateGlob returns an error if filePath is a malformed glob pattern.
+func validateGlob(filePath string) error {
+	if _, err := filepath.Match(filePath, ""); err != nil {
+		return fmt.Errorf("invalid path pattern %q: %v", filePath, err)
+	}
+	return nil
+}
+
+// expandGlobs returns substs with each key that is a glob pattern replaced by the files in fs it
+// matches. A file matched by several keys is given the substitutions of its exact path first, then
+// those of each pattern in sorted order, without repeating a substitution. Exact paths are kept
+// whether or not they exist, so they work as before.
+func expandGlobs(fs afero.Fs, substs map[string][]Substitution) (map[string][]Substitution, error) {
+	var patterns []string
+	expanded := map[string][]Substitution{}
+	for filePath, fileSubsts := range substs {
+		if isGlob(filePath) {
+			patterns = append(patterns, filePath)
+		} else {
+			expanded[filePath] = fileSubsts
+		}
+	}
+	sort.Strings(patterns)
+	for _, pattern := range patterns {
+		filePaths, err := afero.Glob(fs, pattern)
+		if err != nil {
+			return nil, fmt.Errorf("error finding files matching %s: %v", pattern, err)
+		}
+		for _, filePath := range filePaths {
+			expanded[filePath] = appendDistinct(expanded[filePath], substs[pattern])
+		}
+	}
+	return expanded, nil
+}
+
+// pathSubstitutions returns the substitutions expandGlobs gives filePath if it exists: those of its
+// exact path in substs, then those of each pattern key that matches it, in sorted order.
+func pathSubstitutions(substs map[string][]Substitution, filePath string) []Substitution {
+	var patterns []string
+	for pattern := range substs {
+		if matched, _ := filepath.Match(pattern, filePath); matched && isGlob(pattern) {
+			patterns = append(patterns, pattern)
+		}
+	}
+	sort.Strings(patterns)
+	fileSubsts := substs[filePath]
+	for _, pattern := range patterns {
+		fileSubsts = appendDistinct(fileSubsts, substs[pattern])
+	}
+	return fileSubsts
+}
+
+// appendDistinct appends each of substs to to whose pattern is not already in to.
+func appendDistinct(to, substs []Substitution) []Substitution {
+	seen := map[string]bool{}
+	for _, subst := range to {
+		seen[subst.FromTagRE.String()] = true
+	}
+	out := append([]Substitution{}, to...)
+	for _, subst := range substs {
+		if key := subst.FromTagRE.String(); !seen[key] {
+			seen[key] = true
+			out = append(out, subst)
+		}
+	}
+	return out
+}
+
+// globPattern returns the first glob key of substs, in sorted order, that matches filePath and
+// has a substitution with pattern, if the exact path filePath has none, which is how expandGlobs
+// assigned that substitution to filePath.
+func globPattern(substs map[string][]Substitution, filePath, pattern string) (string, bool) {
+	for _, subst := range substs[filePath] {
+		if subst.FromTagRE.String() == pattern {
+			return "", false
+		}
+	}
+	var globs []string
+	for key := range substs {
+		if isGlob(key) {
+			globs = append(globs, key)
+		}
+	}
+	sort.Strings(globs)
+	for _, glob := range globs {
+		if matched, _ := filepath.Match(glob, filePath); !matched {
+			continue
+		}
+		for _, subst := range substs[glob] {
+			if subst.FromTagRE.String() == pattern {
+				return glob, true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,87 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"regexp"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+)
+
+var _ = Describe("Globs", func() {
+	var (
+		fs       afero.Fs
+		foo, bar Substitution
+	)
+
+	BeforeEach(func() {
+		fs = afero.NewMemMapFs()
+		for _, filePath := range []string{"config/default/a_patch.yaml", "config/default/b_patch.yaml", "config/default/kustomization.yaml"} {
+			Expect(afero.WriteFile(fs, filePath, nil, 0644)).To(Succeed())
+		}
+		foo = Substitution{FromTagRE: regexp.MustCompile(`foo`), ToTag: "foo:v1"}
+		bar = Substitution{FromTagRE: regexp.MustCompile(`bar`), ToTag: "bar:v1"}
+	})
+
+	Describe("expandGlobs", func() {
+		It("replaces patterns by the files they match after their exact paths' substitutions", func() {
+			expanded, err := expandGlobs(fs, map[string][]Substitution{
+				"config/default/a_patch.yaml": {bar},
+				"config/default/*_patch.yaml": {foo, bar},
+				"config/missing/*.yaml":       {foo},
+				"Dockerfile":                  {foo},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(expanded).To(Equal(map[string][]Substitution{
+				"config/default/a_patch.yaml": {bar, foo},
+				"config/default/b_patch.yaml": {foo, bar},
+				"Dockerfile":                  {foo},
+			}))
+		})
+	})
+
+	Describe("globPattern", func() {
+		It("returns the pattern a substitution of a file was assigned by", func() {
+			substs := map[string][]Substitution{
+				"config/default/a_patch.yaml": {bar},
+				"config/default/*_patch.yaml": {foo, bar},
+			}
+			cases := []struct {
+				filePath, pattern, glob string
+				ok                      bool
+			}{
+				{"config/default/b_patch.yaml", "foo", "config/default/*_patch.yaml", true},
+				{"config/default/a_patch.yaml", "foo", "config/default/*_patch.yaml", true},
+				{"config/default/a_patch.yaml", "bar", "", false},
+				{"config/default/kustomization.yaml", "foo", "", false},
+			}
+			for _, c := range cases {
+				glob, ok := globPattern(substs, c.filePath, c.pattern)
+				Expect(glob).To(Equal(c.glob), c.filePath)
+				Expect(ok).To(Equal(c.ok), c.filePath)
+			}
+		})
+	})
+
+	Describe("validateGlob", func() {
+		It("rejects malformed patterns", func() {
+			Expect(validateGlob("config/default/*_patch.yaml")).To(Succeed())
+			Expect(validateGlob("Dockerfile")).To(Succeed())
+			Expect(validateGlob("config/[default")).To(MatchError(ContainSubstring(`invalid path pattern "config/[default"`)))
+		})
+	})
+})
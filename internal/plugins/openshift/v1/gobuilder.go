@@ -0,0 +1,111 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"golang.org/x/mod/semver"
+)
+
+var (
+	// goBuilderTagRE matches a golang builder image, capturing its Go version.
+	goBuilderTagRE = regexp.MustCompile(`golang:(\d+\.\d+(?:\.\d+)?)\b`)
+	// goDirectiveRE matches the go directive of a go.mod file, capturing its Go version.
+	goDirectiveRE = regexp.MustCompile(`(?m)^go (\d+\.\d+(?:\.\d+)?)$`)
+)
+
+// checkGoBuilderVersion compares the Go version of each golang builder image in the Dockerfile
+// with the go directive in go.mod, since a builder older than the module's minimum Go version
+// fails to build it. If opts pins the builder version, older builders are bumped to the module's
+// version, which is returned, otherwise a warning is logged. Projects without either file are not checked.
+func checkGoBuilderVersion(fs afero.Fs, opts imageOptions) (string, error) {
+	moduleVersion, err := goModVersion(fs)
+	if err != nil || moduleVersion == "" {
+		return "", err
+	}
+
+	dockerfile, err := afero.ReadFile(fs, "Dockerfile")
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("error reading Dockerfile: %v", err)
+	}
+	bump := opts.GoBuilderVersion != "" && !opts.dryRun && !contains(opts.Disabled, GoBuilderCategory)
+	bumped := false
+	out := goBuilderTagRE.ReplaceAllFunc(dockerfile, func(match []byte) []byte {
+		builderVersion := string(goBuilderTagRE.FindSubmatch(match)[1])
+		if semver.Compare("v"+builderVersion, "v"+moduleVersion) >= 0 {
+			return match
+		}
+		if !bump {
+			opts.getLogger().Warnf("Dockerfile builder image %s is older than the go.mod go directive %s, "+
+				"builds will fail until the builder is updated", match, moduleVersion)
+			return match
+		}
+		opts.getLogger().Infof("Updating Dockerfile builder image %s to the go.mod go directive %s", match, moduleVersion)
+		bumped = true
+		return []byte("golang:" + moduleVersion)
+	})
+	if !bumped {
+		return "", nil
+	}
+	info, err := fs.Stat("Dockerfile")
+	if err != nil {
+		return "", fmt.Errorf("error reading file info of Dockerfile: %v", err)
+	}
+	if err := writeFile(fs, "Dockerfile", out, info.Mode()); err != nil {
+		return "", err
+	}
+	return moduleVersion, nil
+}
+
+// goModVersion returns the Go version of the go directive in go.mod, or "" if go.mod does not
+// exist or has no go directive.
+func goModVersion(fs afero.Fs) (string, error) {
+	goMod, err := afero.ReadFile(fs, "go.mod")
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("error reading go.mod: %v", err)
+	}
+	m := goDirectiveRE.FindSubmatch(goMod)
+	if m == nil {
+		return "", nil
+	}
+	return string(m[1]), nil
+}
+
+// autoGoBuilderVersion returns the Go version of the go directive in go.mod, which golang builder
+// images are tagged with by --auto-go-version. If go.mod cannot be read or has no go directive,
+// fallback is returned.
+func autoGoBuilderVersion(fs afero.Fs, fallback string, logger *log.Entry) string {
+	version, err := goModVersion(fs)
+	if err != nil {
+		logger.Warnf("Could not read the go.mod go directive, tagging golang builder images with the --%s value: %v",
+			goBuilderVersionFlag, err)
+		return fallback
+	}
+	if version == "" {
+		logger.Infof("No go.mod go directive found, tagging golang builder images with the --%s value", goBuilderVersionFlag)
+		return fallback
+	}
+	return version
+}
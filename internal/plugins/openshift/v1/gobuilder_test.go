@@ -0,0 +1,133 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	cfgv3 "sigs.k8s.io/kubebuilder/v3/pkg/config/v3"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+var _ = Describe("checkGoBuilderVersion", func() {
+	var (
+		fs     afero.Fs
+		opts   imageOptions
+		logOut *bytes.Buffer
+	)
+
+	BeforeEach(func() {
+		fs = afero.NewMemMapFs()
+		logOut = &bytes.Buffer{}
+		logger := log.New()
+		logger.SetOutput(logOut)
+		opts = defaultImageOptions()
+		opts.logger = log.NewEntry(logger)
+		Expect(afero.WriteFile(fs, "Dockerfile", []byte("FROM golang:1.20 as builder\n"), 0644)).To(Succeed())
+		Expect(afero.WriteFile(fs, "go.mod", []byte("module example.com/m\n\ngo 1.21\n"), 0644)).To(Succeed())
+	})
+
+	It("bumps a pinned builder older than the go directive", func() {
+		opts.GoBuilderVersion = "1.20"
+		bumped, err := checkGoBuilderVersion(fs, opts)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(bumped).To(Equal("1.21"))
+		dockerfileOut, err := afero.ReadFile(fs, "Dockerfile")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(dockerfileOut)).To(Equal("FROM golang:1.21 as builder\n"))
+		Expect(logOut.String()).To(ContainSubstring("Updating Dockerfile builder image golang:1.20"))
+	})
+
+	It("warns about a builder older than the go directive that is not pinned", func() {
+		_, err := checkGoBuilderVersion(fs, opts)
+		Expect(err).NotTo(HaveOccurred())
+		dockerfileOut, err := afero.ReadFile(fs, "Dockerfile")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(dockerfileOut)).To(Equal("FROM golang:1.20 as builder\n"))
+		Expect(logOut.String()).To(ContainSubstring("level=warning"))
+		Expect(logOut.String()).To(ContainSubstring("older than the go.mod go directive 1.21"))
+	})
+
+	It("only warns in dry-run mode", func() {
+		opts.GoBuilderVersion, opts.dryRun = "1.20", true
+		_, err := checkGoBuilderVersion(fs, opts)
+		Expect(err).NotTo(HaveOccurred())
+		dockerfileOut, err := afero.ReadFile(fs, "Dockerfile")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(dockerfileOut)).To(Equal("FROM golang:1.20 as builder\n"))
+		Expect(logOut.String()).To(ContainSubstring("level=warning"))
+	})
+
+	It("accepts builders at least as new as the go directive", func() {
+		for _, dockerfile := range []string{"FROM golang:1.21 as builder\n", "FROM golang:1.21.5 as builder\n", "FROM golang:1.22\n"} {
+			Expect(afero.WriteFile(fs, "Dockerfile", []byte(dockerfile), 0644)).To(Succeed())
+			_, err := checkGoBuilderVersion(fs, opts)
+			Expect(err).NotTo(HaveOccurred())
+			dockerfileOut, err := afero.ReadFile(fs, "Dockerfile")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(dockerfileOut)).To(Equal(dockerfile))
+		}
+		Expect(logOut.String()).To(BeEmpty())
+	})
+
+	It("records the bumped builder version in the plugin config on init", func() {
+		mfs := machinery.Filesystem{FS: fs}
+		s := &initSubcommand{options: imageOptions{Options: Options{GoBuilderVersion: "1.20"}, logger: opts.logger}}
+		Expect(s.InjectConfig(cfgv3.New())).To(Succeed())
+		Expect(s.PreScaffold(mfs)).To(Succeed())
+		Expect(s.Scaffold(mfs)).To(Succeed())
+		cfg, err := decodeConfig(s.config)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg.GoBuilderVersion).To(Equal("1.21"))
+		goModOut, err := afero.ReadFile(fs, "go.mod")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(goModOut)).To(Equal("module example.com/m\n\ngo 1.21\n"))
+	})
+
+	It("skips projects without a go.mod", func() {
+		Expect(fs.Remove("go.mod")).To(Succeed())
+		_, err := checkGoBuilderVersion(fs, opts)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(logOut.String()).To(BeEmpty())
+	})
+})
+
+var _ = Describe("autoGoBuilderVersion", func() {
+	It("returns the go directive's version, or the fallback if go.mod cannot be read", func() {
+		logger := log.New()
+		logger.SetOutput(&bytes.Buffer{})
+		cases := []struct {
+			goMod    string
+			fallback string
+			version  string
+		}{
+			{"module example.com/m\n\ngo 1.21\n", "", "1.21"},
+			{"module example.com/m\n\ngo 1.21.3\n", "1.20", "1.21.3"},
+			{"module example.com/m\n", "1.20", "1.20"},
+			{"", "", ""},
+		}
+		for _, c := range cases {
+			fs := afero.NewMemMapFs()
+			if c.goMod != "" {
+				Expect(afero.WriteFile(fs, "go.mod", []byte(c.goMod), 0644)).To(Succeed())
+			}
+			Expect(autoGoBuilderVersion(fs, c.fallback, log.NewEntry(logger))).To(Equal(c.version), c.goMod)
+		}
+	})
+})
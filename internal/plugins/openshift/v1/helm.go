@@ -0,0 +1,210 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+)
+
+// helmValuesGlob matches the values files of a Helm project's charts.
+var helmValuesGlob = filepath.Join("helm-charts", "*", "values.yaml")
+
+// Keys of a Helm chart value mapping that references an image by its parts, ex.
+// "image: {registry: docker.io, repository: library/nginx, tag: 1.25}".
+const (
+	helmRegistryKey   = "registry"
+	helmRepositoryKey = "repository"
+	helmTagKey        = "tag"
+)
+
+// isHelmValuesFile reports whether filePath is the values file of a Helm project's chart.
+func isHelmValuesFile(filePath string) bool {
+	matched, _ := filepath.Match(helmValuesGlob, filePath)
+	return matched
+}
+
+// helmValuesSubstitutions returns substs with substitutions added for the values file of each
+// chart in fs, if opts is for a Helm project. Built-in substitutions, which are reversed if
+// opts.reverse is set, and ad-hoc substitutions are applied to values files.
+// Values files in substs are left as they are, since their substitutions are already known.
+func helmValuesSubstitutions(fs afero.Fs, opts imageOptions, substs map[string][]Substitution) (map[string][]Substitution, error) {
+	if opts.ProjectType != HelmProjectType || len(opts.paths) > 0 {
+		return substs, nil
+	}
+	filePaths, err := afero.Glob(fs, helmValuesGlob)
+	if err != nil {
+		return nil, fmt.Errorf("error finding Helm chart values files: %v", err)
+	}
+	if len(filePaths) == 0 {
+		return substs, nil
+	}
+
+	builtin := BuildSubstitutions(opts.Options)
+	if opts.reverse {
+		builtin = reverseSubstitutions(opts.Options, opts.upstreamTag)
+	}
+	chartSubsts := append(distinctSubstitutions(builtin), withKeepRegistries(opts.adHocSubstitutions, opts.KeepRegistries)...)
+
+	withValues := map[string][]Substitution{}
+	for filePath, fileSubsts := range substs {
+		withValues[filePath] = fileSubsts
+	}
+	for _, filePath := range filePaths {
+		if _, ok := withValues[filePath]; !ok {
+			withValues[filePath] = chartSubsts
+		}
+	}
+	return withValues, nil
+}
+
+// substituteHelmValues applies subs in order to the images referenced by Helm chart values
+// content, and returns the result and the matches replaced by each substitution. Images are
+// referenced either by the value of an image field, or by the registry, repository, and tag
+// fields of a mapping, which are substituted as the image they join to. Comments, other fields,
+// and the formatting of the file are left unchanged.
+func substituteHelmValues(content []byte, subs []Substitution) ([]byte, [][][]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, nil, err
+	}
+	ranges, err := imageValueRanges(content)
+	if err != nil {
+		return nil, nil, err
+	}
+	type edit struct {
+		r     byteRange
+		value []byte
+	}
+	var edits []edit
+	substMatches := make([][][]byte, len(subs))
+	addMatches := func(matches [][][]byte) {
+		for i := range matches {
+			substMatches[i] = append(substMatches[i], matches[i]...)
+		}
+	}
+	for _, r := range ranges {
+		value, matches := substitute(content[r.start:r.end], subs)
+		addMatches(matches)
+		edits = append(edits, edit{r: r, value: value})
+	}
+
+	starts := lineStarts(content)
+	for _, ref := range helmImageRefs(&doc) {
+		image := ref.image()
+		value, matches := substitute([]byte(image), subs)
+		if string(value) == image {
+			continue
+		}
+		parts, ok := ref.split(string(value))
+		if !ok {
+			continue
+		}
+		rangesOK := true
+		var refEdits []edit
+		for n, part := range parts {
+			r, ok := valueRange(content, starts, n)
+			if !ok {
+				rangesOK = false
+				break
+			}
+			refEdits = append(refEdits, edit{r: r, value: []byte(part)})
+		}
+		if rangesOK {
+			addMatches(matches)
+			edits = append(edits, refEdits...)
+		}
+	}
+
+	sort.Slice(edits, func(i, j int) bool { return edits[i].r.start < edits[j].r.start })
+	var (
+		out  []byte
+		last int
+	)
+	for _, e := range edits {
+		out = append(out, content[last:e.r.start]...)
+		out = append(out, e.value...)
+		last = e.r.end
+	}
+	return append(out, content[last:]...), substMatches, nil
+}
+
+// helmImageRef is a mapping of Helm chart values that references an image by its parts.
+type helmImageRef struct {
+	// registry is nil if the image's registry is part of its repository.
+	registry, repository, tag *yaml.Node
+}
+
+// image returns the image ref references.
+func (ref helmImageRef) image() string {
+	image := ref.repository.Value + ":" + ref.tag.Value
+	if ref.registry != nil {
+		image = ref.registry.Value + "/" + image
+	}
+	return image
+}
+
+// split returns the values of ref's nodes that join to image, or false if
+// image does not have a tag or a registry that ref requires. Images pinned by digest are not split.
+func (ref helmImageRef) split(image string) (map[*yaml.Node]string, bool) {
+	i := strings.LastIndex(image, ":")
+	if i < 0 || strings.Contains(image[i:], "/") || strings.Contains(image, "@") {
+		return nil, false
+	}
+	parts := map[*yaml.Node]string{ref.repository: image[:i], ref.tag: image[i+1:]}
+	if ref.registry != nil {
+		registry, repository, ok := strings.Cut(image[:i], "/")
+		if !ok {
+			return nil, false
+		}
+		parts[ref.registry], parts[ref.repository] = registry, repository
+	}
+	return parts, true
+}
+
+// helmImageRefs returns the mappings in the tree rooted at n with scalar repository and tag values,
+// and an optional scalar registry value, in order.
+func helmImageRefs(n *yaml.Node) []helmImageRef {
+	var refs []helmImageRef
+	if n.Kind == yaml.MappingNode {
+		var ref helmImageRef
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			if value.Kind != yaml.ScalarNode {
+				continue
+			}
+			switch key.Value {
+			case helmRegistryKey:
+				ref.registry = value
+			case helmRepositoryKey:
+				ref.repository = value
+			case helmTagKey:
+				ref.tag = value
+			}
+		}
+		if ref.repository != nil && ref.tag != nil && ref.tag.Value != "" {
+			refs = append(refs, ref)
+		}
+	}
+	for _, child := range n.Content {
+		refs = append(refs, helmImageRefs(child)...)
+	}
+	return refs
+}
@@ -0,0 +1,136 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+var _ = Describe("substituteHelmValues", func() {
+	var (
+		subs    []Substitution
+		toImage string
+	)
+
+	BeforeEach(func() {
+		opts := DefaultOptions()
+		opts.ProjectType = HelmProjectType
+		subs = BuildSubstitutions(opts)[authProxyPatchPath]
+		toImage = subs[0].ToTag
+	})
+
+	It("substitutes image fields and repository and tag fields", func() {
+		registry, repository, _ := strings.Cut(toImage, "/")
+		repository, tag, _ := strings.Cut(repository, ":")
+		out, matches, err := substituteHelmValues([]byte(helmValues), subs)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(out)).To(Equal(`# Proxy images.
+proxy:
+  image:
+    repository: ` + registry + "/" + repository + `
+    tag: "` + tag + `" # pinned
+    pullPolicy: IfNotPresent
+sidecar:
+  image: ` + toImage + `
+split:
+  image: {registry: ` + registry + `, repository: ` + repository + `, tag: ` + tag + `}
+nginx:
+  image:
+    repository: nginx
+    tag: 1.25.3
+default:
+  image:
+    repository: gcr.io/kubebuilder/kube-rbac-proxy
+    tag: ""
+`))
+		Expect(matches).To(HaveLen(len(subs)))
+		Expect(matches[0]).To(HaveLen(3))
+	})
+
+	It("leaves values without upstream images unchanged", func() {
+		const values = "image:\n  repository: nginx\n  tag: 1.25.3\nreplicaCount: 1\n"
+		out, _, err := substituteHelmValues([]byte(values), subs)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(out)).To(Equal(values))
+	})
+
+	It("fails if values cannot be parsed", func() {
+		_, _, err := substituteHelmValues([]byte("image: [\n"), subs)
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("helmValuesSubstitutions", func() {
+	var fs afero.Fs
+
+	BeforeEach(func() {
+		fs = afero.NewMemMapFs()
+		Expect(afero.WriteFile(fs, filepath.Join("helm-charts", "memcached", "values.yaml"), []byte(helmValues), 0644)).To(Succeed())
+		Expect(afero.WriteFile(fs, filepath.Join("helm-charts", "memcached", "templates", "values.yaml"), nil, 0644)).To(Succeed())
+	})
+
+	It("adds the values file of each chart of Helm projects", func() {
+		opts := defaultImageOptions()
+		opts.ProjectType = HelmProjectType
+		substs, err := helmValuesSubstitutions(fs, opts, imageSubstitutions(opts))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(substs).To(HaveKey(filepath.Join("helm-charts", "memcached", "values.yaml")))
+		Expect(substs).NotTo(HaveKey(filepath.Join("helm-charts", "memcached", "templates", "values.yaml")))
+	})
+
+	It("does not add values files of other projects", func() {
+		opts := defaultImageOptions()
+		opts.ProjectType = GoProjectType
+		substs, err := helmValuesSubstitutions(fs, opts, imageSubstitutions(opts))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(substs).NotTo(HaveKey(filepath.Join("helm-charts", "memcached", "values.yaml")))
+	})
+
+	It("substitutes images in values files", func() {
+		opts := defaultImageOptions()
+		opts.ProjectType = HelmProjectType
+		_, err := replaceImages(machinery.Filesystem{FS: fs}, opts)
+		Expect(err).NotTo(HaveOccurred())
+		b, err := afero.ReadFile(fs, filepath.Join("helm-charts", "memcached", "values.yaml"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(ContainSubstring("repository: registry.redhat.io/openshift4/ose-kube-rbac-proxy\n"))
+	})
+})
+
+const helmValues = `# Proxy images.
+proxy:
+  image:
+    repository: gcr.io/kubebuilder/kube-rbac-proxy
+    tag: "v0.13.1" # pinned
+    pullPolicy: IfNotPresent
+sidecar:
+  image: gcr.io/kubebuilder/kube-rbac-proxy:v0.13.1
+split:
+  image: {registry: gcr.io, repository: kubebuilder/kube-rbac-proxy, tag: v0.13.1}
+nginx:
+  image:
+    repository: nginx
+    tag: 1.25.3
+default:
+  image:
+    repository: gcr.io/kubebuilder/kube-rbac-proxy
+    tag: ""
+`
@@ -0,0 +1,1415 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"golang.org/x/mod/semver"
+	"golang.org/x/sync/errgroup"
+	apiutilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+const (
+	// The current OCP release version.
+	ocpProductVersion = "4.14"
+	// The currently used version of ubi8/ubi-minimal images.
+	ubiMinimalVersion = "8.8"
+	// The currently used version of ubi9/ubi-minimal images.
+	ubi9MinimalVersion = "9.2"
+
+	// Hosts of Red Hat registries that downstream images are pulled from.
+	redHatRegistry        = "registry.redhat.io"
+	redHatAccessRegistry  = "registry.access.redhat.com"
+	redHatConnectRegistry = "registry.connect.redhat.com"
+
+	// dockerHubRegistry is the host of images that do not name one.
+	dockerHubRegistry = "docker.io"
+)
+
+// redHatRegistries are the Red Hat registry hosts that OpenShift images may be pulled from.
+var redHatRegistries = []string{redHatRegistry, redHatConnectRegistry}
+
+// Substitution categories, which can be disabled by key.
+const (
+	KubeRBACProxyCategory    = "kube-rbac-proxy"
+	AnsibleOperatorCategory  = "ansible-operator"
+	HelmOperatorCategory     = "helm-operator"
+	UBIMinimalCategory       = "ubi-minimal"
+	UBIMicroCategory         = "ubi-micro"
+	GoBuilderCategory        = "go-builder"
+	ConsolePluginCategory    = "console-plugin"
+	OperatorRegistryCategory = "operator-registry"
+	CLICategory              = "cli"
+	ScorecardCategory        = "scorecard"
+	MonitoringCategory       = "monitoring"
+)
+
+// Categories are the keys of all built-in substitution categories.
+var Categories = []string{
+	KubeRBACProxyCategory,
+	AnsibleOperatorCategory,
+	HelmOperatorCategory,
+	UBIMinimalCategory,
+	UBIMicroCategory,
+	GoBuilderCategory,
+	ConsolePluginCategory,
+	OperatorRegistryCategory,
+	CLICategory,
+	ScorecardCategory,
+	MonitoringCategory,
+}
+
+// openShiftCategories are the keys of substitution categories of OpenShift (ose-*) images,
+// whose repository namespace and name may be overridden.
+var openShiftCategories = []string{
+	KubeRBACProxyCategory,
+	AnsibleOperatorCategory,
+	HelmOperatorCategory,
+	OperatorRegistryCategory,
+	CLICategory,
+	ScorecardCategory,
+}
+
+// defaultImageNamespace is the repository namespace of OpenShift images.
+const defaultImageNamespace = "openshift4"
+
+// supportedArches are the architectures downstream OpenShift images are published for.
+var supportedArches = []string{"amd64", "arm64", "ppc64le", "s390x"}
+
+// defaultGoBaseImage is the UBI image that replaces distroless runtime base images by default.
+const defaultGoBaseImage = "ubi-minimal"
+
+// goBaseImages are the UBI images that may replace distroless runtime base images.
+var goBaseImages = []string{defaultGoBaseImage, "ubi-micro", "ubi"}
+
+var (
+	// ocpVersionRE matches a valid OCP release version, ex. "4.14".
+	ocpVersionRE = regexp.MustCompile(`^\d+\.\d+$`)
+	// goVersionRE matches a valid Go release version, ex. "1.20" or "1.21.5", capturing its minor version.
+	goVersionRE = regexp.MustCompile(`^(\d+\.\d+)(\.\d+)?$`)
+	// ubiVersionRE matches a valid UBI release version, ex. "8.8", capturing its major version.
+	ubiVersionRE = regexp.MustCompile(`^(\d+)\.\d+$`)
+	// registryHostRE matches a valid registry host with an optional port, ex. "mirror.example.com:5000".
+	registryHostRE = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?)*(:\d+)?$`)
+	// repositoryPathRE matches a valid repository path without a registry host, ex. "openshift4".
+	repositoryPathRE = regexp.MustCompile(`^[a-z0-9]+([._-][a-z0-9]+)*(/[a-z0-9]+([._-][a-z0-9]+)*)*$`)
+)
+
+// Options configures the downstream images that upstream images are replaced with.
+type Options struct {
+	// OCPVersion is the OCP release version downstream OpenShift images are tagged with.
+	OCPVersion string
+	// UBIVersion is the version UBI base images are tagged with, independent of OCPVersion.
+	UBIVersion string
+	// UBIMajor is the major version of UBI base images, either 8 or 9.
+	UBIMajor int
+	// Arch, if set, is appended to downstream OpenShift image tags to select a single-architecture image.
+	// By default tags refer to multi-architecture manifest lists.
+	Arch string
+	// Registry, if set, replaces the Red Hat registry host of every downstream image.
+	Registry string
+	// RedHatRegistry, if set, is the Red Hat registry host, one of redHatRegistries, that OpenShift
+	// images are pulled from. Defaults to registry.redhat.io.
+	RedHatRegistry string
+	// ImageNamespace, if set, replaces the openshift4 repository namespace of OpenShift images,
+	// for mirrors that do not preserve Red Hat repository paths.
+	ImageNamespace string
+	// ImageNames map keys of openShiftCategories to names that replace their OpenShift image's name,
+	// ex. "kube-rbac-proxy" to replace ose-kube-rbac-proxy.
+	ImageNames map[string]string
+	// Images map substitution categories to images, ex. "mirror.example.com/ubi-minimal:8.8",
+	// that replace every image of the category instead of the computed downstream image.
+	Images map[string]string
+	// Disabled are the keys of built-in substitution categories that are not applied.
+	Disabled []string
+	// GoBuilderVersion, if set, tags golang builder images and raises the go.mod go directive
+	// to its minor version. Projects' Go versions are left alone by default.
+	GoBuilderVersion string
+	// NoGoModEdit leaves the go.mod go directive unchanged even if GoBuilderVersion is set.
+	NoGoModEdit bool
+	// FIPS replaces golang builder images with UBI go-toolset images, whose Go toolchain is FIPS capable,
+	// tagged with the minor version of GoBuilderVersion, or else of the replaced image.
+	FIPS bool
+	// MakefileImages replaces tool images assigned to Makefile variables, ex. "OPM_IMG ?= <image>",
+	// with their OpenShift equivalents.
+	MakefileImages bool
+	// RewriteDigests replaces images pinned by digest like other images. By default they are
+	// left unchanged, since a digest does not identify the same image in another repository.
+	RewriteDigests bool
+	// RBACProxyVersion, if set, is used instead of OCPVersion to tag kube-rbac-proxy images.
+	RBACProxyVersion string
+	// GoBaseImage, if set, is the UBI image, one of goBaseImages, that replaces the distroless
+	// runtime base image of Go operators. Defaults to ubi-minimal.
+	GoBaseImage string
+	// Channel is the OLM channel the operator's bundle is published to, recorded for bundle-related subcommands.
+	Channel string
+	// ProjectType, if set, limits substitutions of project type-specific images to projects of that type.
+	// By default substitutions for all project types are applied.
+	ProjectType string
+	// Files are additional paths, relative to the project root, that every built-in substitution
+	// is applied to, for project layouts that keep manifests in non-standard places.
+	Files []string
+	// KeepRegistries are registry hosts whose images are never replaced by any substitution.
+	KeepRegistries []string
+}
+
+// DefaultOptions returns Options set to the current OCP release and UBI 8 versions.
+func DefaultOptions() Options {
+	return Options{
+		OCPVersion: ocpProductVersion,
+		UBIVersion: ubiMinimalVersion,
+		UBIMajor:   8,
+	}
+}
+
+// imageOptions configures how upstream images are replaced in a project.
+type imageOptions struct {
+	Options
+
+	// dryRun prints planned substitutions to out instead of writing them.
+	dryRun bool
+	// listSubstitutions prints the substitution rules for each file to out, without processing any file.
+	listSubstitutions bool
+	// reverse restores upstream images instead of substituting downstream images.
+	reverse bool
+	// upstreamTag is the tag of operator-framework images restored if reverse is set.
+	upstreamTag string
+	// output is the format of the substitution report printed to out, either textOutput or jsonOutput.
+	// Dry-run substitutions are not printed in JSON output, so out only contains the report.
+	output string
+	// strict fails if a file to substitute images in does not exist, rather than skipping it.
+	strict bool
+	// failOnNoMatch fails if a substitution of a file that exists did not match anything,
+	// except in files expected to rarely match and additional files.
+	failOnNoMatch bool
+	// authProxyOptional skips the kube-rbac-proxy patch if it does not exist, even if strict is set.
+	authProxyOptional bool
+	// yamlAware only substitutes images in the values of image fields of YAML files, instead of
+	// anywhere in them. Other files are substituted as usual.
+	yamlAware bool
+	// rewriteComments substitutes images in comments too. By default comments are left unchanged.
+	rewriteComments bool
+	// annotate adds a comment recording the plugin and OCP version to each file images are substituted in.
+	annotate bool
+	// backup writes a copy of each file to "<path>.orig" before modifying it.
+	backup bool
+	// quiet prints no dry-run substitutions to out. The --quiet flag also logs only errors.
+	quiet bool
+	// passthrough substitutes no image, so the plugin has no effect on upstream builds.
+	passthrough bool
+	// outputDir, if set, is the directory files are written to, at their paths relative to the
+	// project root, instead of being modified in place.
+	outputDir string
+	// scanDir additionally substitutes images in files matching scanGlobs but not excludes.
+	scanDir bool
+	// scanGlobs are globs of files to scan for upstream images, relative to the project root.
+	scanGlobs []string
+	// excludes are globs of files not to scan.
+	excludes []string
+	// overlays are kustomize overlay directories whose YAML files images are substituted in.
+	// Defaults to the directories in overlaysDir.
+	overlays []string
+	// substitutionsFile is the path of a YAML file with additional substitutions.
+	substitutionsFile string
+	// extraSubstitutions are loaded from substitutionsFile and applied after built-in substitutions.
+	extraSubstitutions map[string][]Substitution
+	// fromPatterns and toImages are pairs of ad-hoc substitutions, compiled into adHocSubstitutions.
+	fromPatterns, toImages []string
+	// adHocSubstitutions are applied to every file after extraSubstitutions.
+	adHocSubstitutions []Substitution
+
+	// diffOutput is the path a unified diff of all changes is written to, or "-" for stdout.
+	diffOutput string
+	// diff, if set, receives a unified diff of each file changed by substitutions.
+	diff io.Writer
+	// statsFile is the path a JSON line summarizing the substitutions of each run is appended to.
+	statsFile string
+
+	// since, if set, skips files last modified before it.
+	since time.Time
+
+	// checkImages checks that each image that replaced an upstream image exists in its registry.
+	checkImages bool
+	// checkImagesTimeout bounds the time spent checking images. Defaults to defaultCheckImagesTimeout.
+	checkImagesTimeout time.Duration
+	// imageExists checks whether an image exists. Defaults to remoteImageExists.
+	imageExists imageExistsFunc
+
+	// out is where dry-run substitutions and diffs written to stdout are printed. Defaults to stdout.
+	out io.Writer
+	// logger logs each file processed at debug level, which is enabled by --verbose.
+	// Defaults to the standard logger.
+	logger *log.Entry
+	// paths, if set, limits substitutions to these files.
+	paths []string
+	// workers is the maximum number of files processed concurrently. Defaults to GOMAXPROCS.
+	workers int
+	// ctx cancels substitutions between files. Defaults to context.Background().
+	ctx context.Context
+}
+
+// getOut returns opts.out, or stdout if it is not set.
+func (opts imageOptions) getOut() io.Writer {
+	if opts.out == nil {
+		return os.Stdout
+	}
+	return opts.out
+}
+
+// getLogger returns opts.logger, or the standard logger if it is not set.
+func (opts imageOptions) getLogger() *log.Entry {
+	if opts.logger == nil {
+		return log.NewEntry(log.StandardLogger())
+	}
+	return opts.logger
+}
+
+// getContext returns opts.ctx, or the background context if it is not set.
+func (opts imageOptions) getContext() context.Context {
+	if opts.ctx == nil {
+		return context.Background()
+	}
+	return opts.ctx
+}
+
+// getWorkers returns opts.workers, or GOMAXPROCS if it is not set.
+func (opts imageOptions) getWorkers() int {
+	if opts.workers <= 0 {
+		return runtime.GOMAXPROCS(0)
+	}
+	return opts.workers
+}
+
+// defaultImageOptions returns imageOptions set to their defaults.
+func defaultImageOptions() imageOptions {
+	return imageOptions{Options: DefaultOptions()}
+}
+
+// validateOCPVersion returns an error if version is not of the form "<major>.<minor>".
+func validateOCPVersion(version string) error {
+	if !ocpVersionRE.MatchString(version) {
+		return fmt.Errorf("invalid --%s value %q: must be of the form <major>.<minor>, ex. %s",
+			ocpVersionFlag, version, ocpProductVersion)
+	}
+	return nil
+}
+
+// validateRBACProxyVersion returns an error if version is set and is not an OCP release version.
+func validateRBACProxyVersion(version string) error {
+	if version != "" && !ocpVersionRE.MatchString(version) {
+		return fmt.Errorf("invalid --%s value %q: must be of the form <major>.<minor>, ex. %s",
+			rbacProxyVersionFlag, version, ocpProductVersion)
+	}
+	return nil
+}
+
+// validateUBIVersion returns an error if major is not a supported UBI major version,
+// or if version is set and is not a release version of major.
+func validateUBIVersion(major int, version string) error {
+	if major != 8 && major != 9 {
+		return fmt.Errorf("invalid --%s value %d: must be 8 or 9", ubiMajorFlag, major)
+	}
+	if version == "" {
+		return nil
+	}
+	m := ubiVersionRE.FindStringSubmatch(version)
+	if m == nil {
+		return fmt.Errorf("invalid --%s value %q: must be of the form <major>.<minor>, ex. %s", ubiVersionFlag, version, ubiMinimalVersion)
+	}
+	if m[1] != strconv.Itoa(major) {
+		return fmt.Errorf("--%s value %q is not a UBI %d version, set by --%s", ubiVersionFlag, version, major, ubiMajorFlag)
+	}
+	return nil
+}
+
+// validateArch returns an error if arch is set and is not a supported architecture.
+func validateArch(arch string) error {
+	if arch == "" {
+		return nil
+	}
+	for _, supported := range supportedArches {
+		if arch == supported {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid --%s value %q: must be one of %s", archFlag, arch, strings.Join(supportedArches, ", "))
+}
+
+// validateRegistry returns an error if registry is set and is not a registry host.
+func validateRegistry(registry string) error {
+	if registry != "" && !registryHostRE.MatchString(registry) {
+		return fmt.Errorf("invalid --%s value %q: must be a registry host, ex. mirror.example.com:5000", registryFlag, registry)
+	}
+	return nil
+}
+
+// validateKeepRegistries returns an error if any of registries is not a registry host.
+func validateKeepRegistries(registries []string) error {
+	for _, registry := range registries {
+		if !registryHostRE.MatchString(registry) {
+			return fmt.Errorf("invalid --%s value %q: must be a registry host, ex. quay.io", keepRegistryFlag, registry)
+		}
+	}
+	return nil
+}
+
+// validateRedHatRegistry returns an error if host is set and is not a Red Hat registry host
+// OpenShift images may be pulled from, or if registry, which replaces it, is also set.
+func validateRedHatRegistry(host, registry string) error {
+	if host == "" {
+		return nil
+	}
+	if !contains(redHatRegistries, host) {
+		return fmt.Errorf("invalid --%s value %q: must be one of %s", redHatRegistryFlag, host, strings.Join(redHatRegistries, ", "))
+	}
+	if registry != "" {
+		return fmt.Errorf("--%s and --%s are mutually exclusive", redHatRegistryFlag, registryFlag)
+	}
+	return nil
+}
+
+// compileAdHocSubstitutions returns a substitution replacing each match of fromPatterns[i] with toImages[i].
+func compileAdHocSubstitutions(fromPatterns, toImages []string) ([]Substitution, error) {
+	if len(fromPatterns) != len(toImages) {
+		return nil, fmt.Errorf("--%s and --%s must be given the same number of times, got %d and %d",
+			fromPatternFlag, toImageFlag, len(fromPatterns), len(toImages))
+	}
+	substs := make([]Substitution, 0, len(fromPatterns))
+	for i, pattern := range fromPatterns {
+		fromTagRE, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --%s value %q: %v", fromPatternFlag, pattern, err)
+		}
+		if toImages[i] == "" {
+			return nil, fmt.Errorf("--%s for --%s %q must not be empty", toImageFlag, fromPatternFlag, pattern)
+		}
+		substs = append(substs, Substitution{FromTagRE: fromTagRE, ToTag: toImages[i]})
+	}
+	return substs, nil
+}
+
+// validateImageNamespace returns an error if namespace is set and is not a repository path.
+func validateImageNamespace(namespace string) error {
+	if namespace != "" && !repositoryPathRE.MatchString(namespace) {
+		return fmt.Errorf("invalid --%s value %q: must be a repository path, ex. %s", imageNamespaceFlag, namespace, defaultImageNamespace)
+	}
+	return nil
+}
+
+// validateImageNames returns an error if any key of names is not an OpenShift image category,
+// or if any name is not a repository name.
+func validateImageNames(names map[string]string) error {
+	for key, name := range names {
+		if !contains(openShiftCategories, key) {
+			return fmt.Errorf("invalid --%s key %q: must be one of %s", imageNameFlag, key, strings.Join(openShiftCategories, ", "))
+		}
+		if !repositoryPathRE.MatchString(name) || strings.Contains(name, "/") {
+			return fmt.Errorf("invalid --%s value %q for %s: must be a repository name", imageNameFlag, name, key)
+		}
+	}
+	return nil
+}
+
+// validateImages returns an error if any key of images is not a substitution category,
+// or if any image is not an image reference with a tag or digest.
+func validateImages(images map[string]string) error {
+	for key, image := range images {
+		if !contains(Categories, key) {
+			return fmt.Errorf("invalid image override category %q: must be one of %s", key, strings.Join(Categories, ", "))
+		}
+		if !isTaggedImage(image) {
+			return fmt.Errorf("invalid --%s%s value %q: must be an image with a tag or digest, ex. mirror.example.com/%s:latest",
+				imageFlagPrefix, key, image, key)
+		}
+	}
+	return nil
+}
+
+// validateGoBaseImage returns an error if image is set and is not a supported Go runtime base image.
+func validateGoBaseImage(image string) error {
+	if image != "" && !contains(goBaseImages, image) {
+		return fmt.Errorf("invalid --%s value %q: must be one of %s", goBaseImageFlag, image, strings.Join(goBaseImages, ", "))
+	}
+	return nil
+}
+
+// validateGoBuilderVersion returns an error if version is set and is not a Go release version.
+func validateGoBuilderVersion(version string) error {
+	if version != "" && !goVersionRE.MatchString(version) {
+		return fmt.Errorf("invalid --%s value %q: must be of the form <major>.<minor>[.<patch>], ex. %s",
+			goBuilderVersionFlag, version, defaultGoBuilderVersion)
+	}
+	return nil
+}
+
+// isTaggedImage reports whether image is a single image reference with a tag or digest.
+func isTaggedImage(image string) bool {
+	return !strings.ContainsAny(image, " \t\n\"'") && imageName(image) != image && !strings.HasSuffix(image, ":")
+}
+
+// validateCategories returns an error if any of keys is not a substitution category.
+func validateCategories(keys []string) error {
+	for _, key := range keys {
+		if !contains(Categories, key) {
+			return fmt.Errorf("invalid --%s value %q: must be one of %s", disableFlag, key, strings.Join(Categories, ", "))
+		}
+	}
+	return nil
+}
+
+// ubiVersions are the versions of UBI 8 and UBI 9 base images known to work with an OCP release.
+type ubiVersions struct {
+	ubi8, ubi9 string
+}
+
+// ocpUBIVersions maps OCP release versions to the UBI versions their operators should be based on.
+// Add an entry for each new OCP release.
+var ocpUBIVersions = map[string]ubiVersions{
+	"4.12": {ubi8: "8.6", ubi9: "9.1"},
+	"4.13": {ubi8: "8.8", ubi9: "9.2"},
+	"4.14": {ubi8: ubiMinimalVersion, ubi9: ubi9MinimalVersion},
+	"4.15": {ubi8: "8.9", ubi9: "9.3"},
+	"4.16": {ubi8: "8.10", ubi9: "9.4"},
+}
+
+// ubiVersionForOCP returns the version of UBI base images with the given major version that is
+// known to work with OCP release ocp. Unknown releases fall back to the versions for ocpProductVersion.
+func ubiVersionForOCP(ocp string, major int) string {
+	versions, ok := ocpUBIVersions[ocp]
+	if !ok {
+		versions = ubiVersions{ubi8: ubiMinimalVersion, ubi9: ubi9MinimalVersion}
+	}
+	if major == 9 {
+		return versions.ubi9
+	}
+	return versions.ubi8
+}
+
+// Substitution replaces each match of an upstream image pattern with a downstream image.
+type Substitution struct {
+	// Category is the key of the substitution's category. Substitutions from a substitutions file have none.
+	Category string
+	// FromTagRE matches the upstream images to replace. If it has a subexpression named "image", only
+	// the text that subexpression matches is the image replaced, ex. where FromTagRE also matches the
+	// character before the image to anchor it.
+	FromTagRE *regexp.Regexp
+	// ToTag is the downstream image that replaces each match of FromTagRE.
+	ToTag string
+	// Keep, if set, reports whether a match should be left unchanged.
+	Keep func(match []byte) bool
+	// PreserveDigests leaves matches pinned by digest unchanged.
+	PreserveDigests bool
+	// KeepRegistries are registry hosts whose matched images are left unchanged.
+	KeepRegistries []string
+	// Requires, if set, must match a file's content for the substitution to be applied to the file,
+	// ex. so a Go builder substitution is only applied to Dockerfiles that build Go operators.
+	Requires *regexp.Regexp
+}
+
+// registryHost returns the registry host of image, which is docker.io for images without one.
+// Matches that are not image references, such as go.mod directives, have no host.
+func registryHost(image []byte) string {
+	if bytes.ContainsAny(image, " \t") {
+		return ""
+	}
+	i := bytes.IndexByte(image, '/')
+	if i < 0 {
+		return dockerHubRegistry
+	}
+	host := string(image[:i])
+	if !strings.ContainsAny(host, ".:") && host != "localhost" {
+		return dockerHubRegistry
+	}
+	return host
+}
+
+// withKeepRegistries returns a copy of substs that leave images of registries unchanged.
+func withKeepRegistries(substs []Substitution, registries []string) []Substitution {
+	kept := make([]Substitution, len(substs))
+	for i, subst := range substs {
+		subst.KeepRegistries = registries
+		kept[i] = subst
+	}
+	return kept
+}
+
+// isDigestPinned reports whether image is pinned by digest, ex. "quay.io/example/foo@sha256:...".
+func isDigestPinned(image []byte) bool {
+	return bytes.Contains(image, []byte("@sha256:"))
+}
+
+// apply returns b with each match of subst.FromTagRE that is not kept replaced by subst.ToTag,
+// and the matches that were changed by their replacement. "$" references in ToTag are expanded as by regexp.ReplaceAll.
+func (subst Substitution) apply(b []byte) ([]byte, [][]byte) {
+	var (
+		out     []byte
+		matches [][]byte
+		last    int
+	)
+	imageIdx := subst.FromTagRE.SubexpIndex("image")
+	for _, idx := range subst.FromTagRE.FindAllSubmatchIndex(b, -1) {
+		// Only the image is replaced, text matched around it is kept.
+		start, end := idx[0], idx[1]
+		if imageIdx > 0 && idx[2*imageIdx] >= 0 {
+			start, end = idx[2*imageIdx], idx[2*imageIdx+1]
+		}
+		image := b[start:end]
+		out = append(out, b[last:start]...)
+		n := len(out)
+		out = subst.FromTagRE.Expand(out, []byte(subst.ToTag), b, idx)
+		// Matches that are kept or already equal to their replacement are not replacements.
+		if (subst.Keep != nil && subst.Keep(image)) || (subst.PreserveDigests && isDigestPinned(image)) ||
+			contains(subst.KeepRegistries, registryHost(image)) || bytes.Equal(out[n:], image) {
+			out = append(out[:n], image...)
+		} else {
+			matches = append(matches, image)
+		}
+		out = append(out, b[end:idx[1]]...)
+		last = idx[1]
+	}
+	return append(out, b[last:]...), matches
+}
+
+// substituteBytes applies subs to content in order, and returns the result
+// and the total number of replacements made.
+func substituteBytes(content []byte, subs []Substitution) ([]byte, int) {
+	out, substMatches := substitute(content, subs)
+	count := 0
+	for _, matches := range substMatches {
+		count += len(matches)
+	}
+	return out, count
+}
+
+// requiredSubstitutions returns the substitutions of subs whose Requires precondition, if any, matches content.
+func requiredSubstitutions(content []byte, subs []Substitution) []Substitution {
+	var required []Substitution
+	for _, subst := range subs {
+		if subst.Requires == nil || subst.Requires.Match(content) {
+			required = append(required, subst)
+		}
+	}
+	return required
+}
+
+// substitute applies subs to content in order, and returns the result
+// and the matches replaced by each substitution.
+func substitute(content []byte, subs []Substitution) ([]byte, [][][]byte) {
+	substMatches := make([][][]byte, len(subs))
+	for i, subst := range subs {
+		content, substMatches[i] = subst.apply(content)
+	}
+	return content, substMatches
+}
+
+// tagContext holds the values built-in toTag templates are rendered with.
+type tagContext struct {
+	// OCPVersion is the OCP version OpenShift images are tagged with.
+	OCPVersion string
+	// UBIVersion is the version UBI images are tagged with.
+	UBIVersion string
+	// UBIMajor is the major version of UBI images.
+	UBIMajor int
+	// Registry is the host of registry.redhat.io images.
+	Registry string
+	// AccessRegistry is the host of registry.access.redhat.com images.
+	AccessRegistry string
+	// ImageNamespace is the repository namespace of OpenShift images.
+	ImageNamespace string
+	// ImageNames map substitution categories to the names of their OpenShift images, if overridden.
+	ImageNames map[string]string
+	// Images map substitution categories to images that replace their computed images, if overridden.
+	Images map[string]string
+	// Arch is the architecture OpenShift image tags are suffixed with, if any.
+	Arch string
+	// GoBuilderVersion is the version golang builder images are tagged with.
+	GoBuilderVersion string
+	// GoVersion is the "<major>.<minor>" language version of GoBuilderVersion.
+	GoVersion string
+	// NoGoModEdit is set if the go.mod go directive must not be changed.
+	NoGoModEdit bool
+	// FIPS is set if golang builder images are replaced by FIPS capable go-toolset images.
+	FIPS bool
+	// MakefileImages is set if tool images assigned to Makefile variables are replaced.
+	MakefileImages bool
+	// RewriteDigests is set if images pinned by digest are replaced too.
+	RewriteDigests bool
+	// KeepRegistries are registry hosts whose images are left unchanged.
+	KeepRegistries []string
+	// RBACProxyVersion is the OCP version kube-rbac-proxy images are tagged with, if not OCPVersion.
+	RBACProxyVersion string
+	// GoBaseImage is the UBI image that replaces distroless runtime base images.
+	GoBaseImage string
+	// ProjectType is the type of project substituted, or "" for any type.
+	ProjectType string
+	// UpstreamTag is the tag of operator-framework images restored by reversing substitutions.
+	UpstreamTag string
+}
+
+// newTagContext returns the template context for opts.
+func newTagContext(opts Options) tagContext {
+	ctx := tagContext{
+		OCPVersion:       opts.OCPVersion,
+		UBIVersion:       opts.UBIVersion,
+		UBIMajor:         opts.UBIMajor,
+		Registry:         redHatRegistry,
+		AccessRegistry:   redHatAccessRegistry,
+		ImageNamespace:   opts.ImageNamespace,
+		ImageNames:       opts.ImageNames,
+		Images:           opts.Images,
+		Arch:             opts.Arch,
+		GoBuilderVersion: opts.GoBuilderVersion,
+		NoGoModEdit:      opts.NoGoModEdit,
+		FIPS:             opts.FIPS,
+		MakefileImages:   opts.MakefileImages,
+		RewriteDigests:   opts.RewriteDigests,
+		KeepRegistries:   opts.KeepRegistries,
+		RBACProxyVersion: opts.RBACProxyVersion,
+		GoBaseImage:      opts.GoBaseImage,
+		ProjectType:      opts.ProjectType,
+	}
+	if ctx.GoBaseImage == "" {
+		ctx.GoBaseImage = defaultGoBaseImage
+	}
+	if ctx.ImageNamespace == "" {
+		ctx.ImageNamespace = defaultImageNamespace
+	}
+	if opts.RedHatRegistry != "" {
+		ctx.Registry = opts.RedHatRegistry
+	}
+	if opts.Registry != "" {
+		ctx.Registry, ctx.AccessRegistry = opts.Registry, opts.Registry
+	}
+	if m := goVersionRE.FindStringSubmatch(opts.GoBuilderVersion); m != nil {
+		ctx.GoVersion = m[1]
+	}
+	return ctx
+}
+
+// ImageName returns the overridden name of category's OpenShift image, or name if it is not overridden.
+func (ctx tagContext) ImageName(category, name string) string {
+	if override, ok := ctx.ImageNames[category]; ok {
+		return override
+	}
+	return name
+}
+
+// substitutionTemplate is a built-in image substitution whose toTag is a text/template.
+type substitutionTemplate struct {
+	category  string
+	fromTagRE *regexp.Regexp
+	toTag     *template.Template
+	// enabled, if set, reports whether the substitution applies to ctx.
+	enabled func(ctx tagContext) bool
+	// keep, if set, reports whether match should be left unchanged instead of becoming toTag.
+	keep func(match []byte, toTag string) bool
+	// upstream, if set, is a template of the upstream image restored when reversing the substitution.
+	upstream *template.Template
+	// notImage is set if toTag is not an image, so it is never replaced by an image override.
+	notImage bool
+	// requires, if set, is the Requires precondition of the substitution.
+	requires *regexp.Regexp
+}
+
+// render returns tmpl's substitution with its toTag rendered against ctx.
+func (tmpl substitutionTemplate) render(ctx tagContext) Substitution {
+	subst := Substitution{
+		Category:        tmpl.category,
+		FromTagRE:       tmpl.fromTagRE,
+		ToTag:           tmpl.execute(tmpl.toTag, ctx),
+		PreserveDigests: !ctx.RewriteDigests,
+		KeepRegistries:  ctx.KeepRegistries,
+		Requires:        tmpl.requires,
+	}
+	if image, ok := ctx.Images[tmpl.category]; ok && !tmpl.notImage {
+		subst.ToTag = image
+	}
+	if tmpl.keep != nil {
+		toTag := subst.ToTag
+		subst.Keep = func(match []byte) bool { return tmpl.keep(match, toTag) }
+	}
+	return subst
+}
+
+// execute returns t rendered against ctx.
+func (tmpl substitutionTemplate) execute(t *template.Template, ctx tagContext) string {
+	var b strings.Builder
+	if err := t.Execute(&b, ctx); err != nil {
+		// Built-in templates only reference fields of tagContext.
+		panic(fmt.Sprintf("error rendering %s image tag: %v", tmpl.category, err))
+	}
+	return b.String()
+}
+
+func tagTemplate(text string) *template.Template {
+	return template.Must(template.New("").Parse(text))
+}
+
+// oseImage returns a template of the repository of category's OpenShift image named name, ending in ":".
+func oseImage(category, name string) string {
+	return `{{ .Registry }}/{{ .ImageNamespace }}/{{ .ImageName "` + category + `" "` + name + `" }}:`
+}
+
+// oseTag is the tag of OpenShift images.
+const oseTag = `v{{ .OCPVersion }}{{ with .Arch }}-{{ . }}{{ end }}`
+
+// rbacProxyTag is the tag of kube-rbac-proxy images, which may be pinned separately from other OpenShift images.
+const rbacProxyTag = `v{{ or .RBACProxyVersion .OCPVersion }}{{ with .Arch }}-{{ . }}{{ end }}`
+
+// bundleDockerfilePath is the path of the bundle image Dockerfile, which is generated by
+// "make bundle" after the project is scaffolded.
+const bundleDockerfilePath = "bundle.Dockerfile"
+
+var (
+	// scorecardBasicPath and scorecardOLMPath are the scorecard config patches of projects
+	// scaffolded with the scorecard plugin, which run the scorecard test image.
+	scorecardBasicPath = filepath.Join("config", "scorecard", "patches", "basic.config.yaml")
+	scorecardOLMPath   = filepath.Join("config", "scorecard", "patches", "olm.config.yaml")
+)
+
+// koConfigPath is the path of the ko build config of Go operators that build images with ko
+// instead of the Dockerfile.
+const koConfigPath = ".ko.yaml"
+
+var (
+	// moleculeDefaultPath and moleculeKindPath are the molecule scenarios of Ansible projects, whose
+	// platforms may be customized to run upstream images.
+	moleculeDefaultPath = filepath.Join("molecule", "default", "molecule.yml")
+	moleculeKindPath    = filepath.Join("molecule", "kind", "molecule.yml")
+)
+
+var (
+	// kubeRBACProxySubstitution replaces the kube-rbac-proxy sidecar image.
+	kubeRBACProxySubstitution = substitutionTemplate{
+		category:  KubeRBACProxyCategory,
+		fromTagRE: regexp.MustCompile(`gcr.io/kubebuilder/kube-rbac-proxy[:@][^ \n"']+`),
+		toTag:     tagTemplate(oseImage(KubeRBACProxyCategory, "ose-kube-rbac-proxy") + rbacProxyTag),
+		upstream:  tagTemplate(`gcr.io/kubebuilder/kube-rbac-proxy:` + upstreamRBACProxyTag),
+	}
+	// ansibleOperatorSubstitution replaces the base image of Ansible operators.
+	ansibleOperatorSubstitution = substitutionTemplate{
+		category:  AnsibleOperatorCategory,
+		fromTagRE: regexp.MustCompile(`quay.io/operator-framework/ansible-operator[:@][^ \n"']+`),
+		toTag:     tagTemplate(oseImage(AnsibleOperatorCategory, "ose-ansible-operator") + oseTag),
+		upstream:  tagTemplate(`quay.io/operator-framework/ansible-operator:{{ .UpstreamTag }}`),
+		enabled:   forProjectType(AnsibleProjectType),
+	}
+	// moleculeSubstitution replaces Ansible operator images run by molecule scenarios.
+	moleculeSubstitution = substitutionTemplate{
+		category:  ansibleOperatorSubstitution.category,
+		fromTagRE: ansibleOperatorSubstitution.fromTagRE,
+		toTag:     ansibleOperatorSubstitution.toTag,
+		upstream:  ansibleOperatorSubstitution.upstream,
+		enabled:   forProjectType(AnsibleProjectType),
+	}
+	// scorecardSubstitution replaces the image of scorecard's built-in tests.
+	scorecardSubstitution = substitutionTemplate{
+		category:  ScorecardCategory,
+		fromTagRE: regexp.MustCompile(`quay.io/operator-framework/scorecard-test[:@][^ \n"']+`),
+		toTag:     tagTemplate(oseImage(ScorecardCategory, "ose-scorecard-test") + oseTag),
+		upstream:  tagTemplate(`quay.io/operator-framework/scorecard-test:{{ .UpstreamTag }}`),
+	}
+	// ubiMinimalSubstitution replaces the distroless runtime base image of Go operators.
+	ubiMinimalSubstitution = substitutionTemplate{
+		category:  UBIMinimalCategory,
+		fromTagRE: regexp.MustCompile(`gcr.io/distroless/static[:@][^ \n"']+`),
+		toTag:     tagTemplate(`{{ .AccessRegistry }}/ubi{{ .UBIMajor }}/{{ .GoBaseImage }}:{{ .UBIVersion }}`),
+		upstream:  tagTemplate(`gcr.io/distroless/static:nonroot`),
+	}
+	// ubiMicroSubstitution retags the UBI runtime base image of hybrid Helm operators.
+	ubiMicroSubstitution = substitutionTemplate{
+		category:  UBIMicroCategory,
+		fromTagRE: regexp.MustCompile(`registry.access.redhat.com/ubi8/ubi-micro[:@][^ \n"']+`),
+		toTag:     tagTemplate(`{{ .AccessRegistry }}/ubi{{ .UBIMajor }}/ubi-micro:{{ .UBIVersion }}`),
+	}
+	// hybridUBIMicroSubstitution is ubiMicroSubstitution for Dockerfiles of hybrid Helm operators.
+	hybridUBIMicroSubstitution = substitutionTemplate{
+		category:  ubiMicroSubstitution.category,
+		fromTagRE: ubiMicroSubstitution.fromTagRE,
+		toTag:     ubiMicroSubstitution.toTag,
+		enabled:   forProjectType(HelmProjectType),
+		requires:  ubiMicroSubstitution.fromTagRE,
+	}
+)
+
+// builtinSubstitutions maps paths, relative to the project root, to built-in image substitutions.
+// Substitutions for a path are applied in order.
+var builtinSubstitutions = map[string][]substitutionTemplate{
+	authProxyPatchPath: {kubeRBACProxySubstitution},
+	"Dockerfile": {
+		// Ansible
+		ansibleOperatorSubstitution,
+		// Helm
+		{
+			category:  HelmOperatorCategory,
+			fromTagRE: regexp.MustCompile(`quay.io/operator-framework/helm-operator[:@][^ \n"']+`),
+			toTag:     tagTemplate(oseImage(HelmOperatorCategory, "ose-helm-operator") + oseTag),
+			upstream:  tagTemplate(`quay.io/operator-framework/helm-operator:{{ .UpstreamTag }}`),
+			enabled:   forProjectType(HelmProjectType),
+		},
+		// Go
+		onlyForProjectType(ubiMinimalSubstitution, GoProjectType),
+		// Hybrid Helm, which has no known project type. Other Helm operators build from the Helm operator
+		// image, so ubi-micro is only retagged in Dockerfiles that use it.
+		hybridUBIMicroSubstitution,
+		// Go builder
+		{
+			category:  GoBuilderCategory,
+			fromTagRE: regexp.MustCompile(`golang[:@][^ \n"']+`),
+			toTag:     tagTemplate(`golang:{{ .GoBuilderVersion }}`),
+			enabled:   func(ctx tagContext) bool { return hasGoBuilderVersion(ctx) && !ctx.FIPS },
+		},
+		// FIPS Go builder, which keeps the replaced image's Go version unless GoBuilderVersion is set.
+		// Images must start a reference, so that golang images of other registries are not matched.
+		{
+			category:  GoBuilderCategory,
+			fromTagRE: regexp.MustCompile(`(?m)(?:^|[\s"'=])(?P<image>(?:docker\.io/library/)?golang:(?P<version>\d+\.\d+)[^ \n"']*)`),
+			toTag:     tagTemplate(`{{ .AccessRegistry }}/ubi{{ .UBIMajor }}/go-toolset:{{ or .GoVersion "${version}" }}`),
+			enabled:   func(ctx tagContext) bool { return ctx.FIPS },
+		},
+	},
+	// Generated bundle Dockerfiles build from scratch, but may be changed to build from a base image.
+	bundleDockerfilePath: {
+		ubiMinimalSubstitution,
+		ubiMicroSubstitution,
+	},
+	// Scaffolded molecule scenarios run no images, but their platforms may be changed to.
+	moleculeDefaultPath: {moleculeSubstitution},
+	moleculeKindPath:    {moleculeSubstitution},
+	consolePluginPath: {
+		{
+			category:  ConsolePluginCategory,
+			fromTagRE: regexp.MustCompile(`registry.access.redhat.com/ubi\d+/nginx-122[:@][^ \n"']+`),
+			toTag:     tagTemplate(`{{ .AccessRegistry }}/ubi{{ .UBIMajor }}/nginx-122:latest`),
+		},
+	},
+	// Tool images assigned to Makefile variables, which are only replaced if MakefileImages is set.
+	makefilePath: {
+		{
+			category:  OperatorRegistryCategory,
+			fromTagRE: regexp.MustCompile(`quay.io/operator-framework/opm[:@][^ \n"']+`),
+			toTag:     tagTemplate(oseImage(OperatorRegistryCategory, "ose-operator-registry") + oseTag),
+			enabled:   hasMakefileImages,
+		},
+		{
+			category:  CLICategory,
+			fromTagRE: regexp.MustCompile(`(?:(?:docker.io/)?bitnami|registry.k8s.io)/kubectl[:@][^ \n"']+`),
+			toTag:     tagTemplate(oseImage(CLICategory, "ose-cli") + oseTag),
+			enabled:   hasMakefileImages,
+		},
+	},
+	// ko base images, ex. "defaultBaseImage: gcr.io/distroless/static:nonroot", which are static like
+	// Dockerfile runtime base images. ko's own default base image is cgr.dev/chainguard/static.
+	koConfigPath: {
+		{
+			category:  UBIMinimalCategory,
+			fromTagRE: regexp.MustCompile(`(?:gcr.io/distroless/(?:static|base)(?:-debian\d+)?|cgr.dev/chainguard/static)[:@][^ \n"']+`),
+			toTag:     ubiMinimalSubstitution.toTag,
+			upstream:  ubiMinimalSubstitution.upstream,
+			enabled:   forProjectType(GoProjectType),
+		},
+	},
+	scorecardBasicPath: {scorecardSubstitution},
+	scorecardOLMPath:   {scorecardSubstitution},
+	// Monitoring manifests, which may deploy exporters and other monitoring components.
+	prometheusGlob: monitoringSubstitutions(),
+	// The default of the Template's kube-rbac-proxy image parameter.
+	templatePath: {kubeRBACProxySubstitution},
+	"go.mod": {
+		// Keep the module's language version aligned with the builder's, but never lower it,
+		// since the module may rely on newer language features.
+		{
+			category:  GoBuilderCategory,
+			fromTagRE: regexp.MustCompile(`(?m)^go \d+\.\d+(\.\d+)?$`),
+			toTag:     tagTemplate(`go {{ .GoVersion }}`),
+			enabled:   mayEditGoMod,
+			notImage:  true,
+			keep: func(match []byte, toTag string) bool {
+				current := "v" + strings.TrimPrefix(string(match), "go ")
+				return semver.Compare(current, "v"+strings.TrimPrefix(toTag, "go ")) >= 0
+			},
+		},
+	},
+}
+
+func hasMakefileImages(ctx tagContext) bool {
+	return ctx.MakefileImages
+}
+
+func hasGoBuilderVersion(ctx tagContext) bool {
+	return ctx.GoBuilderVersion != ""
+}
+
+func mayEditGoMod(ctx tagContext) bool {
+	return hasGoBuilderVersion(ctx) && !ctx.NoGoModEdit
+}
+
+// forProjectType returns an enabled func for substitutions only applied to projects of type t.
+func forProjectType(t string) func(ctx tagContext) bool {
+	return func(ctx tagContext) bool {
+		return ctx.ProjectType == "" || ctx.ProjectType == t
+	}
+}
+
+// onlyForProjectType returns tmpl enabled only for projects of type t, for files such as the
+// Dockerfile where it is shared with substitutions of other project types.
+func onlyForProjectType(tmpl substitutionTemplate, t string) substitutionTemplate {
+	tmpl.enabled = forProjectType(t)
+	return tmpl
+}
+
+// BuildSubstitutions returns a map of paths, relative to the project root, to the built-in
+// image substitutions configured by opts. Substitutions for a path are applied in order.
+func BuildSubstitutions(opts Options) map[string][]Substitution {
+	ctx := newTagContext(opts)
+	substs := map[string][]Substitution{}
+	for filePath, tmpls := range builtinSubstitutions {
+		for _, tmpl := range tmpls {
+			if contains(opts.Disabled, tmpl.category) || (tmpl.enabled != nil && !tmpl.enabled(ctx)) {
+				continue
+			}
+			substs[filePath] = append(substs[filePath], tmpl.render(ctx))
+		}
+	}
+	return withFiles(substs, opts.Files)
+}
+
+// DownstreamImage returns the image that the built-in substitutions configured by opts replace
+// upstream with, and whether any substitution matched. Substitutions are tried in the order they
+// are applied to files, and only one that matches all of upstream replaces it.
+func DownstreamImage(upstream string, opts Options) (string, bool) {
+	for _, subst := range distinctSubstitutions(BuildSubstitutions(opts)) {
+		if idx := subst.FromTagRE.FindStringIndex(upstream); idx == nil || idx[0] != 0 || idx[1] != len(upstream) {
+			continue
+		}
+		if out, matches := subst.apply([]byte(upstream)); len(matches) > 0 {
+			return string(out), true
+		}
+	}
+	return upstream, false
+}
+
+// withFiles returns substs with each of files that has no substitutions given all distinct substitutions in substs.
+func withFiles(substs map[string][]Substitution, files []string) map[string][]Substitution {
+	if len(files) == 0 {
+		return substs
+	}
+	all := distinctSubstitutions(substs)
+	for _, filePath := range files {
+		filePath = filepath.Clean(filePath)
+		if _, ok := substs[filePath]; !ok {
+			substs[filePath] = all
+		}
+	}
+	return substs
+}
+
+// distinctSubstitutions returns each distinct substitution in substs, in path then substitution order.
+func distinctSubstitutions(substs map[string][]Substitution) []Substitution {
+	filePaths := make([]string, 0, len(substs))
+	for filePath := range substs {
+		filePaths = append(filePaths, filePath)
+	}
+	sort.Strings(filePaths)
+	var distinct []Substitution
+	seen := map[string]bool{}
+	for _, filePath := range filePaths {
+		for _, subst := range substs[filePath] {
+			if key := subst.FromTagRE.String(); !seen[key] {
+				seen[key] = true
+				distinct = append(distinct, subst)
+			}
+		}
+	}
+	return distinct
+}
+
+// validateFiles returns an error if any of files is not a path within the project root.
+func validateFiles(files []string) error {
+	for _, filePath := range files {
+		if !isProjectPath(filePath) {
+			return fmt.Errorf("invalid --%s value %q: must be a path relative to the project root", fileFlag, filePath)
+		}
+	}
+	return nil
+}
+
+// isProjectPath reports whether filePath is relative to and within the project root.
+func isProjectPath(filePath string) bool {
+	clean := filepath.Clean(filePath)
+	return !filepath.IsAbs(clean) && clean != ".." && !strings.HasPrefix(clean, "../")
+}
+
+// isOptional reports whether filePath may not exist even with opts.strict set, which is the case
+// for the kube-rbac-proxy patch of projects that may omit it, for files only scaffolded on request
+// or for some project types, and for the bundle Dockerfile, which is generated later.
+func isOptional(filePath string, opts imageOptions) bool {
+	return (opts.authProxyOptional && filePath == authProxyPatchPath) || filePath == consolePluginPath ||
+		filePath == templatePath || filePath == koConfigPath ||
+		filePath == scorecardBasicPath || filePath == scorecardOLMPath ||
+		rarelyMatches(filePath)
+}
+
+// rarelyMatches reports whether substitutions are not expected to match in filePath, since
+// generated bundle Dockerfiles build from scratch, scaffolded molecule scenarios run no images,
+// Helm charts mostly deploy images that have no downstream equivalent, and few Makefiles assign tool images.
+func rarelyMatches(filePath string) bool {
+	return filePath == bundleDockerfilePath || filePath == moleculeDefaultPath || filePath == moleculeKindPath ||
+		filePath == makefilePath || isHelmValuesFile(filePath) || isMonitoringFile(filePath)
+}
+
+// distinct returns the distinct values of matches in order of first appearance.
+func distinct(matches [][]byte) []string {
+	var values []string
+	for _, match := range matches {
+		if !contains(values, string(match)) {
+			values = append(values, string(match))
+		}
+	}
+	return values
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// imageSubstitutions returns a map of paths to image substitutions configured by opts.
+// Extra substitutions for a path are applied after that path's built-in substitutions,
+// which are reversed if opts.reverse is set, followed by ad-hoc substitutions. If opts.paths
+// is set, only substitutions for those paths are returned.
+func imageSubstitutions(opts imageOptions) map[string][]Substitution {
+	substs := BuildSubstitutions(opts.Options)
+	if opts.reverse {
+		substs = reverseSubstitutions(opts.Options, opts.upstreamTag)
+	}
+	for filePath, extra := range opts.extraSubstitutions {
+		substs[filePath] = append(substs[filePath], withKeepRegistries(extra, opts.KeepRegistries)...)
+	}
+	if len(opts.adHocSubstitutions) > 0 {
+		adHoc := withKeepRegistries(opts.adHocSubstitutions, opts.KeepRegistries)
+		for filePath := range substs {
+			substs[filePath] = append(substs[filePath], adHoc...)
+		}
+	}
+	if len(opts.paths) > 0 {
+		for filePath := range substs {
+			if !contains(opts.paths, filePath) {
+				delete(substs, filePath)
+			}
+		}
+	}
+	return substs
+}
+
+// fileSubstitutions returns the substitutions opts applies to each file in fs: those returned by
+// imageSubstitutions, with glob pattern keys expanded to the files they match, those of files found by scanning fs if opts.scanDir is set,
+// those of kustomize overlay files, those of manager manifests with containers that run upstream images,
+// and those of Helm chart values files.
+func fileSubstitutions(fs afero.Fs, opts imageOptions) (map[string][]Substitution, error) {
+	substs, err := expandGlobs(fs, imageSubstitutions(opts))
+	if err != nil {
+		return nil, err
+	}
+	if opts.scanDir {
+		if substs, err = scanSubstitutions(fs, opts, substs); err != nil {
+			return nil, err
+		}
+	}
+	if substs, err = overlaySubstitutions(fs, opts, substs); err != nil {
+		return nil, err
+	}
+	// Manager manifests are found by their upstream images, which reversed substitutions do not match.
+	if !opts.reverse {
+		if substs, err = managerSubstitutions(fs, opts, substs); err != nil {
+			return nil, err
+		}
+	}
+	return helmValuesSubstitutions(fs, opts, substs)
+}
+
+// SubstitutionResult records how many times an image substitution matched in a file.
+type SubstitutionResult struct {
+	// Path of the file the substitution was applied to.
+	Path string
+	// Pattern is the source of the regular expression matching upstream images.
+	Pattern string
+	// Category is the key of the substitution's category, if any.
+	Category string
+	// Image replaced each match of Pattern.
+	Image string
+	// Count is the number of replacements made.
+	Count int
+	// From are the distinct upstream images that were replaced, in order of appearance.
+	From []string
+}
+
+// ReplaceImagesReport replaces upstream images with their downstream (OpenShift) equivalents
+// tagged with the default OCP release and UBI versions, and reports the result of each substitution.
+func ReplaceImagesReport(fs machinery.Filesystem) ([]SubstitutionResult, error) {
+	return replaceImages(fs, defaultImageOptions())
+}
+
+// ReplaceImagesCount replaces upstream images with their downstream (OpenShift) equivalents
+// tagged with the default OCP release and UBI versions, and returns the total number of
+// replacements made. Since substitutions are idempotent, a second call returns 0.
+func ReplaceImagesCount(fs machinery.Filesystem) (int, error) {
+	results, err := replaceImages(fs, defaultImageOptions())
+	return totalCount(results), err
+}
+
+// ReplaceBundleImages replaces upstream images in the bundle Dockerfile with their downstream
+// (OpenShift) equivalents tagged with the default UBI version, and returns the number of
+// replacements made. It can be run after "make bundle" generates the bundle Dockerfile.
+func ReplaceBundleImages(fs machinery.Filesystem) (int, error) {
+	opts := defaultImageOptions()
+	opts.paths = []string{bundleDockerfilePath}
+	results, err := replaceImages(fs, opts)
+	return totalCount(results), err
+}
+
+// totalCount returns the total number of replacements made by results.
+func totalCount(results []SubstitutionResult) int {
+	total := 0
+	for _, result := range results {
+		total += result.Count
+	}
+	return total
+}
+
+// canceled returns an error wrapping ctx's error if ctx is done, or nil if it is not.
+func canceled(ctx context.Context, operation string) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("%s canceled: %w", operation, err)
+	}
+	return nil
+}
+
+// replaceImages replaces upstream images with their downstream (OpenShift) equivalents,
+// returning a result for each substitution in path order. Built-in substitutions only match
+// upstream images or produce output they would match identically, so running replaceImages
+// more than once on a project is safe.
+// Files that do not exist are skipped unless opts.strict is set. Files are written atomically,
+// and a write that fails with a transient error is retried once.
+// A file that cannot be processed does not stop other files from being processed and written;
+// errors for all such files are returned together, with results for the files that were written.
+// If opts.backup is set, each file is first copied to "<path>.orig", and files written are
+// restored if any file cannot be processed.
+// If opts.dryRun is set, each substitution that would be made is printed instead.
+// Up to opts.workers files are read and substituted concurrently; results, output, and
+// errors are still reported in path order.
+func replaceImages(fs machinery.Filesystem, opts imageOptions) ([]SubstitutionResult, error) {
+	out := opts.getOut()
+	logger := opts.getLogger()
+	ctx := opts.getContext()
+
+	if opts.passthrough {
+		logger.Info("Skipping image substitutions in passthrough mode")
+		return nil, nil
+	}
+	imageSubsts, err := fileSubstitutions(fs.FS, opts)
+	if err != nil {
+		return nil, err
+	}
+	filePaths := make([]string, 0, len(imageSubsts))
+	for filePath := range imageSubsts {
+		filePaths = append(filePaths, filePath)
+	}
+	sort.Strings(filePaths)
+
+	// Files are read and substituted concurrently, then reported and written in path order
+	// so that output and the error returned do not depend on scheduling.
+	processed := make([]processedFile, len(filePaths))
+	var g errgroup.Group
+	g.SetLimit(opts.getWorkers())
+	for i, filePath := range filePaths {
+		i, filePath := i, filePath
+		g.Go(func() error {
+			if ctx.Err() != nil {
+				return nil
+			}
+			processed[i] = processFile(fs.FS, filePath, imageSubsts[filePath], opts)
+			return nil
+		})
+	}
+	_ = g.Wait()
+	// No file is written if substitutions were canceled before every file was processed.
+	if err := canceled(ctx, "image substitution"); err != nil {
+		return nil, err
+	}
+
+	var (
+		results []SubstitutionResult
+		backups []fileBackup
+	)
+	var errs []error
+	for i, filePath := range filePaths {
+		file := processed[i]
+		if file.err != nil {
+			errs = append(errs, file.err)
+			continue
+		}
+		if file.missing {
+			logger.WithField("file", filePath).Debug("Skipping image substitutions, file does not exist")
+			continue
+		}
+		if file.unchanged {
+			logger.WithField("file", filePath).Debugf("Skipping image substitutions, file not modified since --%s", sinceFlag)
+			continue
+		}
+		var fileResults []SubstitutionResult
+		fileMatches := 0
+		for j, subst := range file.substs {
+			matches := file.matches[j]
+			fileMatches += len(matches)
+			if opts.dryRun && opts.output != jsonOutput && !opts.quiet {
+				for _, match := range matches {
+					fmt.Fprintf(out, "%s: %s -> %s\n", filePath, match, subst.ToTag)
+				}
+			}
+			fileResults = append(fileResults, SubstitutionResult{
+				Path:     filePath,
+				Pattern:  subst.FromTagRE.String(),
+				Category: subst.Category,
+				Image:    subst.ToTag,
+				Count:    len(matches),
+				From:     distinct(matches),
+			})
+		}
+		logger.WithFields(log.Fields{
+			"file":          filePath,
+			"substitutions": len(file.substs),
+			"matches":       fileMatches,
+			"dryRun":        opts.dryRun,
+		}).Debug("Processed image substitutions")
+		if opts.diff != nil && !bytes.Equal(file.orig, file.b) {
+			if err := writeUnifiedDiff(opts.diff, filePath, file.orig, file.b); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+		// Unchanged files are not written, so that they are not reported as modified.
+		if !opts.dryRun && !bytes.Equal(file.orig, file.b) {
+			if opts.backup {
+				backup := fileBackup{path: filePath, b: file.orig, mode: file.mode}
+				if err := writeBackup(fs.FS, backup); err != nil {
+					errs = append(errs, err)
+					continue
+				}
+				backups = append(backups, backup)
+			}
+			if err := writeFile(fs.FS, filePath, file.b, file.mode); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+		results = append(results, fileResults...)
+	}
+
+	if len(errs) > 0 && opts.backup {
+		if err := restoreBackups(fs.FS, backups); err != nil {
+			errs = append(errs, err)
+		}
+		return nil, apiutilerrors.NewAggregate(errs)
+	}
+	if len(errs) > 0 {
+		return results, apiutilerrors.NewAggregate(errs)
+	}
+	return results, nil
+}
+
+// processedFile is the result of substituting images in a file.
+type processedFile struct {
+	// orig and b are the file's contents before and after substitution.
+	orig, b []byte
+	mode    os.FileMode
+	// substs are the substitutions applied to the file, those whose preconditions matched it.
+	substs []Substitution
+	// matches are the matches of each of substs.
+	matches [][][]byte
+	// missing is set if the file does not exist and may be skipped.
+	missing bool
+	// unchanged is set if the file was not modified since opts.since, so it is skipped.
+	unchanged bool
+	err       error
+}
+
+// processFile reads filePath from fs and applies substs to its contents. It does not write
+// or log anything, so files can be processed concurrently.
+func processFile(fs afero.Fs, filePath string, substs []Substitution, opts imageOptions) processedFile {
+	info, err := fs.Stat(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		if !opts.strict || isOptional(filePath, opts) {
+			return processedFile{missing: true}
+		}
+		return processedFile{err: fmt.Errorf("error reading file for substitution: %v", err)}
+	} else if err != nil {
+		return processedFile{err: fmt.Errorf("error reading file info for substitution: %v", err)}
+	}
+	if !opts.since.IsZero() && info.ModTime().Before(opts.since) {
+		return processedFile{unchanged: true}
+	}
+	orig, err := afero.ReadFile(fs, filePath)
+	if err != nil {
+		return processedFile{err: fmt.Errorf("error reading file for substitution: %v", err)}
+	}
+	var (
+		b       []byte
+		matches [][][]byte
+	)
+	substs = requiredSubstitutions(orig, substs)
+	switch {
+	case opts.ProjectType == HelmProjectType && isHelmValuesFile(filePath):
+		if b, matches, err = substituteHelmValues(orig, substs); err != nil {
+			return processedFile{err: fmt.Errorf("error parsing Helm chart values %s for substitution: %v", filePath, err)}
+		}
+	case opts.MakefileImages && filePath == makefilePath:
+		b, matches = substituteMakefile(orig, substs, opts.rewriteComments)
+	// ko configs set base images in fields other than image fields.
+	case opts.yamlAware && isYAMLFile(filePath) && filePath != koConfigPath:
+		if b, matches, err = substituteYAML(orig, substs); err != nil {
+			return processedFile{err: fmt.Errorf("error parsing %s for YAML-aware substitution: %v", filePath, err)}
+		}
+	case opts.rewriteComments:
+		b, matches = substitute(orig, substs)
+	default:
+		b, matches = substituteCode(filePath, orig, substs)
+	}
+	if opts.annotate && !bytes.Equal(orig, b) {
+		b = annotate(filePath, b, opts.OCPVersion)
+	}
+	return processedFile{orig: orig, b: b, mode: info.Mode(), substs: substs, matches: matches}
+}
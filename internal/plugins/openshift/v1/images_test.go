@@ -0,0 +1,1669 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	log "github.com/sirupsen/logrus"
+	"sigs.k8s.io/kubebuilder/v3/pkg/config"
+	cfgv3 "sigs.k8s.io/kubebuilder/v3/pkg/config/v3"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+
+	"github.com/spf13/afero"
+)
+
+var _ = Describe("Images", func() {
+
+	Describe("validateOCPVersion", func() {
+		It("accepts <major>.<minor> versions", func() {
+			for _, v := range []string{"4.14", "4.15", "10.0"} {
+				Expect(validateOCPVersion(v)).To(Succeed(), v)
+			}
+		})
+		It("rejects malformed versions", func() {
+			for _, v := range []string{"", "v4.14", "4", "4.14.1", "4.x"} {
+				err := validateOCPVersion(v)
+				Expect(err).To(HaveOccurred(), v)
+				Expect(err.Error()).To(ContainSubstring("--" + ocpVersionFlag))
+			}
+		})
+	})
+
+	Describe("validateRBACProxyVersion", func() {
+		It("accepts no version and OCP release versions", func() {
+			for _, v := range []string{"", "4.13", "4.15"} {
+				Expect(validateRBACProxyVersion(v)).To(Succeed(), v)
+			}
+		})
+		It("rejects malformed versions", func() {
+			for _, v := range []string{"v4.14", "4", "4.14.1"} {
+				Expect(validateRBACProxyVersion(v)).To(MatchError(ContainSubstring("--"+rbacProxyVersionFlag)), v)
+			}
+		})
+	})
+
+	Describe("validateUBIVersion", func() {
+		It("accepts UBI 8 and 9 with matching versions", func() {
+			Expect(validateUBIVersion(8, "")).To(Succeed())
+			Expect(validateUBIVersion(8, "8.9")).To(Succeed())
+			Expect(validateUBIVersion(9, "9.2")).To(Succeed())
+		})
+		It("rejects unsupported major versions", func() {
+			Expect(validateUBIVersion(7, "")).To(MatchError(ContainSubstring("--" + ubiMajorFlag)))
+		})
+		It("rejects versions that do not match the major version", func() {
+			Expect(validateUBIVersion(9, "8.8")).To(MatchError(ContainSubstring("--" + ubiVersionFlag)))
+		})
+		It("rejects versions that are not release versions", func() {
+			for _, version := range []string{"8.8-1032", "8.", "8", "8.8.1", "v8.8"} {
+				Expect(validateUBIVersion(8, version)).To(MatchError(ContainSubstring("--"+ubiVersionFlag)), version)
+			}
+		})
+	})
+
+	Describe("ubiVersionForOCP", func() {
+		It("returns the UBI versions known to work with an OCP release", func() {
+			Expect(ubiVersionForOCP("4.12", 8)).To(Equal("8.6"))
+			Expect(ubiVersionForOCP("4.15", 8)).To(Equal("8.9"))
+			Expect(ubiVersionForOCP("4.15", 9)).To(Equal("9.3"))
+			Expect(ubiVersionForOCP("4.16", 8)).To(Equal("8.10"))
+		})
+		It("agrees with the defaults for the current OCP release", func() {
+			Expect(ubiVersionForOCP(ocpProductVersion, 8)).To(Equal(ubiMinimalVersion))
+			Expect(ubiVersionForOCP(ocpProductVersion, 9)).To(Equal(ubi9MinimalVersion))
+		})
+		It("falls back to the defaults for unknown OCP releases", func() {
+			Expect(ubiVersionForOCP("4.99", 8)).To(Equal(ubiMinimalVersion))
+			Expect(ubiVersionForOCP("4.99", 9)).To(Equal(ubi9MinimalVersion))
+		})
+	})
+
+	Describe("validateArch", func() {
+		It("accepts no architecture and supported architectures", func() {
+			for _, arch := range append([]string{""}, supportedArches...) {
+				Expect(validateArch(arch)).To(Succeed(), arch)
+			}
+		})
+		It("rejects unsupported architectures", func() {
+			Expect(validateArch("x86_64")).To(MatchError(ContainSubstring("--" + archFlag)))
+		})
+	})
+
+	Describe("validateGoBaseImage", func() {
+		It("accepts no image and supported images", func() {
+			Expect(validateGoBaseImage("")).To(Succeed())
+			for _, image := range goBaseImages {
+				Expect(validateGoBaseImage(image)).To(Succeed())
+			}
+		})
+		It("rejects unsupported images", func() {
+			Expect(validateGoBaseImage("ubi-init")).To(MatchError(ContainSubstring("--" + goBaseImageFlag)))
+		})
+	})
+
+	Describe("validateRegistry", func() {
+		It("accepts no registry and registry hosts", func() {
+			for _, registry := range []string{"", "mirror.example.com", "mirror.example.com:5000", "localhost"} {
+				Expect(validateRegistry(registry)).To(Succeed(), registry)
+			}
+		})
+		It("rejects other values", func() {
+			for _, registry := range []string{"https://mirror.example.com", "mirror.example.com/ns", "-mirror"} {
+				Expect(validateRegistry(registry)).To(MatchError(ContainSubstring("--"+registryFlag)), registry)
+			}
+		})
+	})
+
+	Describe("registryHost", func() {
+		It("returns the host of each image", func() {
+			for image, host := range map[string]string{
+				"gcr.io/distroless/static:nonroot":     "gcr.io",
+				"localhost:5000/example/operator:v0.1": "localhost:5000",
+				"localhost/example/operator:v0.1":      "localhost",
+				"golang:1.20":                          dockerHubRegistry,
+				"example/operator:v0.1":                dockerHubRegistry,
+				"go 1.20":                              "",
+			} {
+				Expect(registryHost([]byte(image))).To(Equal(host), image)
+			}
+		})
+	})
+
+	Describe("validateKeepRegistries", func() {
+		It("accepts registry hosts", func() {
+			Expect(validateKeepRegistries([]string{"quay.io", "localhost:5000"})).To(Succeed())
+		})
+		It("rejects other values", func() {
+			Expect(validateKeepRegistries([]string{"quay.io/example"})).To(MatchError(ContainSubstring("--" + keepRegistryFlag)))
+		})
+	})
+
+	Describe("validateRedHatRegistry", func() {
+		It("accepts no host and Red Hat registry hosts", func() {
+			for _, host := range append(redHatRegistries, "") {
+				Expect(validateRedHatRegistry(host, "")).To(Succeed(), host)
+			}
+		})
+		It("rejects other hosts", func() {
+			for _, host := range []string{redHatAccessRegistry, "quay.io"} {
+				Expect(validateRedHatRegistry(host, "")).To(MatchError(ContainSubstring("--"+redHatRegistryFlag)), host)
+			}
+		})
+		It("rejects a host with a registry override", func() {
+			Expect(validateRedHatRegistry(redHatConnectRegistry, "mirror.example.com")).To(
+				MatchError("--" + redHatRegistryFlag + " and --" + registryFlag + " are mutually exclusive"))
+		})
+	})
+
+	Describe("validateImageNamespace", func() {
+		It("accepts no namespace and repository paths", func() {
+			for _, namespace := range []string{"", "openshift4", "mirror/ocp", "my-org.mirror"} {
+				Expect(validateImageNamespace(namespace)).To(Succeed(), namespace)
+			}
+		})
+		It("rejects invalid repository paths", func() {
+			for _, namespace := range []string{"/openshift4", "openshift4/", "OpenShift", "mirror.example.com:5000/ocp"} {
+				Expect(validateImageNamespace(namespace)).To(MatchError(ContainSubstring("--"+imageNamespaceFlag)), namespace)
+			}
+		})
+	})
+
+	Describe("validateImageNames", func() {
+		It("accepts names of OpenShift image categories", func() {
+			Expect(validateImageNames(nil)).To(Succeed())
+			Expect(validateImageNames(map[string]string{KubeRBACProxyCategory: "kube-rbac-proxy"})).To(Succeed())
+		})
+		It("rejects other categories", func() {
+			Expect(validateImageNames(map[string]string{UBIMinimalCategory: "ubi"})).To(
+				MatchError(ContainSubstring(`--` + imageNameFlag + ` key "` + UBIMinimalCategory + `"`)))
+		})
+		It("rejects invalid names", func() {
+			for _, name := range []string{"", "ocp/kube-rbac-proxy", "kube-rbac-proxy:v1"} {
+				Expect(validateImageNames(map[string]string{KubeRBACProxyCategory: name})).To(
+					MatchError(ContainSubstring("--"+imageNameFlag)), name)
+			}
+		})
+	})
+
+	Describe("validateImages", func() {
+		It("accepts images of substitution categories", func() {
+			Expect(validateImages(nil)).To(Succeed())
+			Expect(validateImages(map[string]string{
+				UBIMinimalCategory:    "mirror.example.com/ubi-minimal:latest",
+				KubeRBACProxyCategory: "mirror.example.com/kube-rbac-proxy@sha256:abc",
+			})).To(Succeed())
+		})
+		It("rejects unknown categories", func() {
+			Expect(validateImages(map[string]string{"go-runtime": "mirror/foo:bar"})).To(
+				MatchError(ContainSubstring(`invalid image override category "go-runtime"`)))
+		})
+		It("rejects images without a tag or digest", func() {
+			for _, image := range []string{"", "mirror/foo", "mirror:5000/foo", "mirror/foo:", "mirror/foo:bar baz"} {
+				Expect(validateImages(map[string]string{UBIMinimalCategory: image})).To(
+					MatchError(ContainSubstring("--"+imageFlagPrefix+UBIMinimalCategory)), image)
+			}
+		})
+	})
+
+	Describe("validateGoBuilderVersion", func() {
+		It("accepts no version and Go release versions", func() {
+			for _, v := range []string{"", "1.20", "1.21.5"} {
+				Expect(validateGoBuilderVersion(v)).To(Succeed(), v)
+			}
+		})
+		It("rejects malformed versions", func() {
+			for _, v := range []string{"1", "go1.21", "1.21rc1", "1.x"} {
+				Expect(validateGoBuilderVersion(v)).To(MatchError(ContainSubstring("--"+goBuilderVersionFlag)), v)
+			}
+		})
+	})
+
+	Describe("validateCategories", func() {
+		It("accepts substitution categories", func() {
+			Expect(validateCategories(nil)).To(Succeed())
+			Expect(validateCategories(Categories)).To(Succeed())
+		})
+		It("rejects unknown categories", func() {
+			Expect(validateCategories([]string{KubeRBACProxyCategory, "golang"})).To(MatchError(ContainSubstring("--" + disableFlag)))
+		})
+	})
+
+	Describe("compileAdHocSubstitutions", func() {
+		It("pairs each pattern with an image", func() {
+			substs, err := compileAdHocSubstitutions([]string{`quay.io/example/a:[^ \n]+`, "b"}, []string{"mirror/a:v1", "c"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(substs).To(HaveLen(2))
+			Expect(substs[0].FromTagRE.String()).To(Equal(`quay.io/example/a:[^ \n]+`))
+			Expect(substs[0].ToTag).To(Equal("mirror/a:v1"))
+			Expect(substs[1].ToTag).To(Equal("c"))
+		})
+		It("rejects unpaired flags", func() {
+			_, err := compileAdHocSubstitutions([]string{"a", "b"}, []string{"c"})
+			Expect(err).To(MatchError(ContainSubstring("--" + fromPatternFlag + " and --" + toImageFlag)))
+		})
+		It("rejects invalid patterns and empty images", func() {
+			_, err := compileAdHocSubstitutions([]string{"a["}, []string{"c"})
+			Expect(err).To(MatchError(ContainSubstring("invalid --" + fromPatternFlag)))
+			_, err = compileAdHocSubstitutions([]string{"a"}, []string{""})
+			Expect(err).To(MatchError(ContainSubstring("--" + toImageFlag)))
+		})
+	})
+
+	Describe("validateFiles", func() {
+		It("accepts paths relative to the project root", func() {
+			Expect(validateFiles(nil)).To(Succeed())
+			Expect(validateFiles([]string{"deploy/operator.yaml", "./a/../b.yaml", "..foo"})).To(Succeed())
+		})
+		It("rejects absolute paths and paths outside the project root", func() {
+			Expect(validateFiles([]string{"/deploy/operator.yaml"})).To(MatchError(ContainSubstring("--" + fileFlag)))
+			Expect(validateFiles([]string{"../operator.yaml"})).To(MatchError(ContainSubstring("--" + fileFlag)))
+			Expect(validateFiles([]string{"a/../.."})).To(MatchError(ContainSubstring("--" + fileFlag)))
+		})
+	})
+
+	Describe("BuildSubstitutions", func() {
+		It("returns the built-in substitutions without extra substitutions", func() {
+			opts := defaultImageOptions()
+			opts.extraSubstitutions = map[string][]Substitution{
+				"Dockerfile":            {{FromTagRE: regexp.MustCompile(`foo`), ToTag: "bar"}},
+				"config/manager/a.yaml": {{FromTagRE: regexp.MustCompile(`foo`), ToTag: "bar"}},
+			}
+			substs := BuildSubstitutions(opts.Options)
+			Expect(substs).To(HaveLen(11))
+			Expect(substs["Dockerfile"]).To(HaveLen(4))
+			Expect(substs["Dockerfile"][0].ToTag).To(Equal("registry.redhat.io/openshift4/ose-ansible-operator:v" + ocpProductVersion))
+			Expect(imageSubstitutions(opts)["Dockerfile"]).To(HaveLen(5))
+		})
+		It("renders each built-in toTag template", func() {
+			ctx := tagContext{
+				OCPVersion:       "4.13",
+				UBIVersion:       "9.2",
+				UBIMajor:         9,
+				Registry:         "mirror.example.com",
+				AccessRegistry:   "access.example.com",
+				ImageNamespace:   defaultImageNamespace,
+				Arch:             "arm64",
+				GoBuilderVersion: "1.20.5",
+				GoVersion:        "1.20",
+				RBACProxyVersion: "4.12",
+				GoBaseImage:      "ubi",
+			}
+			expected := map[string][]string{
+				authProxyPatchPath: {
+					"mirror.example.com/openshift4/ose-kube-rbac-proxy:v4.12-arm64",
+				},
+				bundleDockerfilePath: {
+					"access.example.com/ubi9/ubi:9.2",
+					"access.example.com/ubi9/ubi-micro:9.2",
+				},
+				"Dockerfile": {
+					"mirror.example.com/openshift4/ose-ansible-operator:v4.13-arm64",
+					"mirror.example.com/openshift4/ose-helm-operator:v4.13-arm64",
+					"access.example.com/ubi9/ubi:9.2",
+					"access.example.com/ubi9/ubi-micro:9.2",
+					"golang:1.20.5",
+					"access.example.com/ubi9/go-toolset:1.20",
+				},
+				consolePluginPath: {
+					"access.example.com/ubi9/nginx-122:latest",
+				},
+				templatePath: {
+					"mirror.example.com/openshift4/ose-kube-rbac-proxy:v4.12-arm64",
+				},
+				koConfigPath: {
+					"access.example.com/ubi9/ubi:9.2",
+				},
+				scorecardBasicPath: {
+					"mirror.example.com/openshift4/ose-scorecard-test:v4.13-arm64",
+				},
+				scorecardOLMPath: {
+					"mirror.example.com/openshift4/ose-scorecard-test:v4.13-arm64",
+				},
+				prometheusGlob: {
+					"mirror.example.com/openshift4/ose-prometheus:v4.13-arm64",
+					"mirror.example.com/openshift4/ose-prometheus-alertmanager:v4.13-arm64",
+					"mirror.example.com/openshift4/ose-prometheus-node-exporter:v4.13-arm64",
+					"mirror.example.com/openshift4/ose-prometheus-operator:v4.13-arm64",
+					"mirror.example.com/openshift4/ose-prometheus-config-reloader:v4.13-arm64",
+					"mirror.example.com/openshift4/ose-kube-state-metrics:v4.13-arm64",
+					"mirror.example.com/openshift4/ose-kube-rbac-proxy:v4.12-arm64",
+				},
+				makefilePath: {
+					"mirror.example.com/openshift4/ose-operator-registry:v4.13-arm64",
+					"mirror.example.com/openshift4/ose-cli:v4.13-arm64",
+				},
+				moleculeDefaultPath: {
+					"mirror.example.com/openshift4/ose-ansible-operator:v4.13-arm64",
+				},
+				moleculeKindPath: {
+					"mirror.example.com/openshift4/ose-ansible-operator:v4.13-arm64",
+				},
+				"go.mod": {
+					"go 1.20",
+				},
+			}
+			Expect(builtinSubstitutions).To(HaveLen(len(expected)))
+			for filePath, tmpls := range builtinSubstitutions {
+				Expect(tmpls).To(HaveLen(len(expected[filePath])), filePath)
+				for i, tmpl := range tmpls {
+					Expect(tmpl.render(ctx).ToTag).To(Equal(expected[filePath][i]), tmpl.category)
+				}
+			}
+		})
+		It("tags kube-rbac-proxy images with the OCP version by default", func() {
+			opts := DefaultOptions()
+			Expect(BuildSubstitutions(opts)[authProxyPatchPath][0].ToTag).To(
+				Equal("registry.redhat.io/openshift4/ose-kube-rbac-proxy:v" + ocpProductVersion))
+			opts.RBACProxyVersion = "4.13"
+			substs := BuildSubstitutions(opts)
+			Expect(substs[authProxyPatchPath][0].ToTag).To(Equal("registry.redhat.io/openshift4/ose-kube-rbac-proxy:v4.13"))
+			Expect(substs["Dockerfile"][0].ToTag).To(Equal("registry.redhat.io/openshift4/ose-ansible-operator:v" + ocpProductVersion))
+		})
+		It("pulls OpenShift images from the chosen Red Hat registry", func() {
+			opts := DefaultOptions()
+			opts.RedHatRegistry = redHatConnectRegistry
+			substs := BuildSubstitutions(opts)
+			Expect(substs[authProxyPatchPath][0].ToTag).To(Equal("registry.connect.redhat.com/openshift4/ose-kube-rbac-proxy:v" + ocpProductVersion))
+			Expect(substs["Dockerfile"][2].ToTag).To(Equal("registry.access.redhat.com/ubi8/ubi-minimal:" + ubiMinimalVersion))
+		})
+		It("overrides the namespace and names of OpenShift images", func() {
+			opts := DefaultOptions()
+			opts.Registry, opts.ImageNamespace = "mirror.example.com", "mirror/ocp"
+			opts.ImageNames = map[string]string{KubeRBACProxyCategory: "kube-rbac-proxy"}
+			substs := BuildSubstitutions(opts)
+			Expect(substs[authProxyPatchPath][0].ToTag).To(Equal("mirror.example.com/mirror/ocp/kube-rbac-proxy:v" + ocpProductVersion))
+			Expect(substs["Dockerfile"][0].ToTag).To(Equal("mirror.example.com/mirror/ocp/ose-ansible-operator:v" + ocpProductVersion))
+			Expect(substs["Dockerfile"][2].ToTag).To(Equal("mirror.example.com/ubi8/ubi-minimal:" + ubiMinimalVersion))
+		})
+		It("replaces the images of categories with image overrides", func() {
+			opts := DefaultOptions()
+			opts.Registry, opts.GoBuilderVersion = "mirror.example.com", "1.21"
+			opts.Images = map[string]string{UBIMinimalCategory: "internal.example.com/base:1", GoBuilderCategory: "internal.example.com/golang:1.21"}
+			substs := BuildSubstitutions(opts)
+			Expect(substs["Dockerfile"][0].ToTag).To(Equal("mirror.example.com/openshift4/ose-ansible-operator:v" + ocpProductVersion))
+			Expect(substs["Dockerfile"][2].ToTag).To(Equal("internal.example.com/base:1"))
+			Expect(substs[koConfigPath][0].ToTag).To(Equal("internal.example.com/base:1"))
+			Expect(substs["Dockerfile"][4].ToTag).To(Equal("internal.example.com/golang:1.21"))
+			// The go.mod go directive is not an image.
+			Expect(substs["go.mod"][0].ToTag).To(Equal("go 1.21"))
+		})
+		It("applies every distinct built-in substitution to additional files", func() {
+			opts := DefaultOptions()
+			builtIns := BuildSubstitutions(opts)
+			opts.Files = []string{"deploy/operator.yaml", "./Dockerfile"}
+			substs := BuildSubstitutions(opts)
+			Expect(substs).To(HaveLen(len(builtIns) + 1))
+			Expect(substs["Dockerfile"]).To(Equal(builtIns["Dockerfile"]))
+			Expect(substs["deploy/operator.yaml"]).To(Equal(distinctSubstitutions(builtIns)))
+			Expect(substs["deploy/operator.yaml"]).To(HaveLen(14))
+		})
+		It("replaces distroless base images with ubi-minimal by default", func() {
+			opts := DefaultOptions()
+			Expect(BuildSubstitutions(opts)["Dockerfile"][2].ToTag).To(
+				Equal("registry.access.redhat.com/ubi8/ubi-minimal:" + ubiMinimalVersion))
+			opts.GoBaseImage = "ubi-micro"
+			Expect(BuildSubstitutions(opts)["Dockerfile"][2].ToTag).To(
+				Equal("registry.access.redhat.com/ubi8/ubi-micro:" + ubiMinimalVersion))
+		})
+		It("omits the architecture suffix if no architecture is set", func() {
+			subst := builtinSubstitutions[authProxyPatchPath][0].render(tagContext{
+				Registry: redHatRegistry, ImageNamespace: defaultImageNamespace, OCPVersion: "4.13",
+			})
+			Expect(subst.ToTag).To(Equal("registry.redhat.io/openshift4/ose-kube-rbac-proxy:v4.13"))
+		})
+	})
+
+	Describe("DownstreamImage", func() {
+		It("returns the downstream image of an upstream image", func() {
+			opts := DefaultOptions()
+			opts.KeepRegistries = []string{"quay.io"}
+			cases := []struct {
+				upstream   string
+				downstream string
+				matched    bool
+			}{
+				{"gcr.io/kubebuilder/kube-rbac-proxy:v0.13.1", "registry.redhat.io/openshift4/ose-kube-rbac-proxy:v" + ocpProductVersion, true},
+				{"gcr.io/distroless/static:nonroot", "registry.access.redhat.com/ubi8/ubi-minimal:" + ubiMinimalVersion, true},
+				{"registry.access.redhat.com/ubi8/ubi-minimal:" + ubiMinimalVersion, "registry.access.redhat.com/ubi8/ubi-minimal:" + ubiMinimalVersion, false},
+				{"quay.io/operator-framework/ansible-operator:v1.31.0", "quay.io/operator-framework/ansible-operator:v1.31.0", false},
+				{"example.com/gcr.io/distroless/static:nonroot", "example.com/gcr.io/distroless/static:nonroot", false},
+				{"go 1.19", "go 1.19", false},
+			}
+			for _, c := range cases {
+				downstream, matched := DownstreamImage(c.upstream, opts)
+				Expect(downstream).To(Equal(c.downstream), c.upstream)
+				Expect(matched).To(Equal(c.matched), c.upstream)
+			}
+		})
+	})
+
+	Describe("substituteBytes", func() {
+		opts := DefaultOptions()
+		opts.GoBuilderVersion = "1.20.5"
+		substs := BuildSubstitutions(opts)
+
+		It("substitutes each built-in pattern in representative snippets", func() {
+			ubiMinimal := "registry.access.redhat.com/ubi8/ubi-minimal:" + ubiMinimalVersion
+			cases := []struct {
+				path, content, expected string
+				count                   int
+			}{
+				{
+					path:     authProxyPatchPath,
+					content:  "      - name: kube-rbac-proxy\n        image: gcr.io/kubebuilder/kube-rbac-proxy:v0.13.1\n",
+					expected: "      - name: kube-rbac-proxy\n        image: registry.redhat.io/openshift4/ose-kube-rbac-proxy:v" + ocpProductVersion + "\n",
+					count:    1,
+				},
+				{
+					path:     "Dockerfile",
+					content:  "FROM quay.io/operator-framework/ansible-operator:v1.31.0\nCOPY watches.yaml ${HOME}/watches.yaml\n",
+					expected: "FROM registry.redhat.io/openshift4/ose-ansible-operator:v" + ocpProductVersion + "\nCOPY watches.yaml ${HOME}/watches.yaml\n",
+					count:    1,
+				},
+				{
+					path:     "Dockerfile",
+					content:  "FROM quay.io/operator-framework/helm-operator:v1.31.0\nENV HOME=/opt/helm\n",
+					expected: "FROM registry.redhat.io/openshift4/ose-helm-operator:v" + ocpProductVersion + "\nENV HOME=/opt/helm\n",
+					count:    1,
+				},
+				{
+					path:     "Dockerfile",
+					content:  "FROM golang:1.19 as builder\n\nFROM gcr.io/distroless/static:nonroot\nUSER 65532:65532\n",
+					expected: "FROM golang:1.20.5 as builder\n\nFROM " + ubiMinimal + "\nUSER 65532:65532\n",
+					count:    2,
+				},
+				{
+					path:     "Dockerfile",
+					content:  "FROM registry.access.redhat.com/ubi8/ubi-micro:8.6\n",
+					expected: "FROM registry.access.redhat.com/ubi8/ubi-micro:" + ubiMinimalVersion + "\n",
+					count:    1,
+				},
+				{
+					path:     "go.mod",
+					content:  "module example.com/memcached-operator\n\ngo 1.19\n",
+					expected: "module example.com/memcached-operator\n\ngo 1.20\n",
+					count:    1,
+				},
+				{
+					path:     "go.mod",
+					content:  "module example.com/memcached-operator\n\ngo 1.21.0\n",
+					expected: "module example.com/memcached-operator\n\ngo 1.21.0\n",
+					count:    0,
+				},
+				{
+					path:     consolePluginPath,
+					content:  "        image: registry.access.redhat.com/ubi9/nginx-122:1\n",
+					expected: "        image: registry.access.redhat.com/ubi8/nginx-122:latest\n",
+					count:    1,
+				},
+				{
+					path:     "Dockerfile",
+					content:  "FROM foo/helm-operator:latest\nFROM distroless/static:latest\n",
+					expected: "FROM foo/helm-operator:latest\nFROM distroless/static:latest\n",
+					count:    0,
+				},
+			}
+			for _, c := range cases {
+				out, count := substituteBytes([]byte(c.content), substs[c.path])
+				Expect(string(out)).To(Equal(c.expected), c.content)
+				Expect(count).To(Equal(c.count), c.content)
+			}
+		})
+
+		It("leaves tag and digest pins of each upstream image unchanged by default", func() {
+			const digest = "@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+			for _, pinned := range digestPinnedImages {
+				for _, content := range []string{pinned.image + digest + "\n", pinned.image + ":v1" + digest + "\n"} {
+					out, count := substituteBytes([]byte(content), substs[pinned.path])
+					Expect(string(out)).To(Equal(content))
+					Expect(count).To(Equal(0), content)
+				}
+			}
+		})
+
+		It("replaces tag and digest pins of each upstream image with --preserve-digests=false", func() {
+			const digest = "@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+			rewriteOpts := opts
+			rewriteOpts.RewriteDigests = true
+			rewriteSubsts := BuildSubstitutions(rewriteOpts)
+			for _, pinned := range digestPinnedImages {
+				tagged, _ := substituteBytes([]byte(pinned.image+":v1\n"), rewriteSubsts[pinned.path])
+				for _, content := range []string{pinned.image + digest + "\n", pinned.image + ":v1" + digest + "\n"} {
+					out, count := substituteBytes([]byte(content), rewriteSubsts[pinned.path])
+					Expect(string(out)).To(Equal(string(tagged)), content)
+					Expect(count).To(Equal(1), content)
+				}
+			}
+		})
+
+		It("leaves images of kept registries unchanged", func() {
+			keepOpts := opts
+			keepOpts.KeepRegistries = []string{"gcr.io", dockerHubRegistry}
+			keepSubsts := BuildSubstitutions(keepOpts)
+			content := "FROM golang:1.19 as builder\nFROM gcr.io/distroless/static:nonroot\n"
+			out, count := substituteBytes([]byte(content), keepSubsts["Dockerfile"])
+			Expect(string(out)).To(Equal(content))
+			Expect(count).To(Equal(0))
+			out, count = substituteBytes([]byte("go 1.19\n"), keepSubsts["go.mod"])
+			Expect(string(out)).To(Equal("go 1.20\n"))
+			Expect(count).To(Equal(1))
+		})
+
+		It("applies substitutions in order", func() {
+			out, count := substituteBytes([]byte("a"), []Substitution{
+				{FromTagRE: regexp.MustCompile(`a`), ToTag: "b"},
+				{FromTagRE: regexp.MustCompile(`b`), ToTag: "c"},
+			})
+			Expect(string(out)).To(Equal("c"))
+			Expect(count).To(Equal(2))
+		})
+	})
+
+	Describe("requiredSubstitutions", func() {
+		It("returns the substitutions whose preconditions match the content", func() {
+			unconditional := Substitution{FromTagRE: regexp.MustCompile(`golang:[^ \n]+`), ToTag: "golang:1.21"}
+			goBuild := Substitution{FromTagRE: regexp.MustCompile(`golang:[^ \n]+`), ToTag: "golang:1.22", Requires: regexp.MustCompile(`(?m)^RUN .*go build`)}
+			ansible := Substitution{FromTagRE: regexp.MustCompile(`golang:[^ \n]+`), ToTag: "golang:1.23", Requires: regexp.MustCompile(`ansible-operator`)}
+			subs := []Substitution{unconditional, goBuild, ansible}
+			cases := []struct {
+				content  string
+				expected []Substitution
+			}{
+				{"FROM golang:1.20\nRUN go build -o manager main.go\n", []Substitution{unconditional, goBuild}},
+				{"FROM quay.io/operator-framework/ansible-operator:v1.31.0\n", []Substitution{unconditional, ansible}},
+				{"FROM golang:1.20\n", []Substitution{unconditional}},
+			}
+			for _, c := range cases {
+				Expect(requiredSubstitutions([]byte(c.content), subs)).To(Equal(c.expected), c.content)
+			}
+		})
+	})
+
+	Describe("replaceImages", func() {
+		var (
+			fs machinery.Filesystem
+
+			dockerfilePath = "Dockerfile"
+			proxyPatchPath = "config/default/manager_auth_proxy_patch.yaml"
+		)
+
+		BeforeEach(func() {
+			fs = machinery.Filesystem{FS: afero.NewMemMapFs()}
+		})
+
+		It("substitutes all images correctly", func() {
+			Expect(afero.WriteFile(fs.FS, dockerfilePath, []byte(dockerfileAll), 0644)).To(Succeed())
+			Expect(afero.WriteFile(fs.FS, proxyPatchPath, []byte(proxyPatch), 0644)).To(Succeed())
+			_, err := replaceImages(fs, defaultImageOptions())
+			Expect(err).NotTo(HaveOccurred())
+			dockerfileOut, err := afero.ReadFile(fs.FS, dockerfilePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(dockerfileOut)).To(ContainSubstring(dockerfileAllExp), "Dockerfile match")
+			proxyPatchOut, err := afero.ReadFile(fs.FS, proxyPatchPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(proxyPatchOut)).To(ContainSubstring(proxyPatchExp), "manager_auth_proxy_patch.yaml match")
+		})
+
+		It("reports the number of replacements made by each substitution", func() {
+			const dockerfileGo = "FROM gcr.io/distroless/static:nonroot\n"
+			Expect(afero.WriteFile(fs.FS, dockerfilePath, []byte(dockerfileGo), 0644)).To(Succeed())
+			Expect(afero.WriteFile(fs.FS, proxyPatchPath, []byte(proxyPatch), 0644)).To(Succeed())
+			results, err := ReplaceImagesReport(fs)
+			Expect(err).NotTo(HaveOccurred())
+			// ubi-micro is only retagged in Dockerfiles that use it, so it has no result.
+			Expect(results).To(Equal([]SubstitutionResult{
+				{
+					Path:     dockerfilePath,
+					Pattern:  `quay.io/operator-framework/ansible-operator[:@][^ \n"']+`,
+					Category: AnsibleOperatorCategory,
+					Image:    "registry.redhat.io/openshift4/ose-ansible-operator:v" + ocpProductVersion,
+					Count:    0,
+				},
+				{
+					Path:     dockerfilePath,
+					Pattern:  `quay.io/operator-framework/helm-operator[:@][^ \n"']+`,
+					Category: HelmOperatorCategory,
+					Image:    "registry.redhat.io/openshift4/ose-helm-operator:v" + ocpProductVersion,
+					Count:    0,
+				},
+				{
+					Path:     dockerfilePath,
+					Pattern:  `gcr.io/distroless/static[:@][^ \n"']+`,
+					Category: UBIMinimalCategory,
+					Image:    "registry.access.redhat.com/ubi8/ubi-minimal:" + ubiMinimalVersion,
+					Count:    1,
+					From:     []string{"gcr.io/distroless/static:nonroot"},
+				},
+				{
+					Path:     proxyPatchPath,
+					Pattern:  `gcr.io/kubebuilder/kube-rbac-proxy[:@][^ \n"']+`,
+					Category: KubeRBACProxyCategory,
+					Image:    "registry.redhat.io/openshift4/ose-kube-rbac-proxy:v" + ocpProductVersion,
+					Count:    2,
+					From:     []string{"gcr.io/kubebuilder/kube-rbac-proxy:v0.5.0", "gcr.io/kubebuilder/kube-rbac-proxy:latest"},
+				},
+			}))
+		})
+
+		It("counts replacements, which a second run does not make", func() {
+			Expect(afero.WriteFile(fs.FS, dockerfilePath, []byte(dockerfileAll), 0644)).To(Succeed())
+			Expect(afero.WriteFile(fs.FS, proxyPatchPath, []byte(proxyPatch), 0644)).To(Succeed())
+			count, err := ReplaceImagesCount(fs)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(count).To(Equal(11))
+			count, err = ReplaceImagesCount(fs)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(count).To(BeZero())
+		})
+
+		It("tags downstream images with the given OCP version", func() {
+			Expect(afero.WriteFile(fs.FS, dockerfilePath, []byte(dockerfileAll), 0644)).To(Succeed())
+			Expect(afero.WriteFile(fs.FS, proxyPatchPath, []byte(proxyPatch), 0644)).To(Succeed())
+			opts := defaultImageOptions()
+			opts.OCPVersion = "4.15"
+			_, err := replaceImages(fs, opts)
+			Expect(err).NotTo(HaveOccurred())
+			dockerfileOut, err := afero.ReadFile(fs.FS, dockerfilePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(dockerfileOut)).To(ContainSubstring("registry.redhat.io/openshift4/ose-ansible-operator:v4.15"))
+			Expect(string(dockerfileOut)).To(ContainSubstring("registry.redhat.io/openshift4/ose-helm-operator:v4.15"))
+			Expect(string(dockerfileOut)).NotTo(ContainSubstring(":v" + ocpProductVersion))
+			proxyPatchOut, err := afero.ReadFile(fs.FS, proxyPatchPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(proxyPatchOut)).To(ContainSubstring("registry.redhat.io/openshift4/ose-kube-rbac-proxy:v4.15"))
+		})
+
+		It("tags UBI images with the given UBI version", func() {
+			Expect(afero.WriteFile(fs.FS, dockerfilePath, []byte(dockerfileAll), 0644)).To(Succeed())
+			Expect(afero.WriteFile(fs.FS, proxyPatchPath, []byte(proxyPatch), 0644)).To(Succeed())
+			opts := defaultImageOptions()
+			opts.UBIVersion = "8.9"
+			_, err := replaceImages(fs, opts)
+			Expect(err).NotTo(HaveOccurred())
+			dockerfileOut, err := afero.ReadFile(fs.FS, dockerfilePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(dockerfileOut)).To(ContainSubstring("FROM registry.access.redhat.com/ubi8/ubi-minimal:8.9\n"))
+			Expect(string(dockerfileOut)).To(ContainSubstring("FROM registry.access.redhat.com/ubi8/ubi-micro:8.9\n"))
+			Expect(string(dockerfileOut)).To(ContainSubstring("FROM registry.redhat.io/openshift4/ose-helm-operator:v" + ocpProductVersion + "\n"))
+		})
+
+		It("replaces distroless base images with the given UBI image", func() {
+			Expect(afero.WriteFile(fs.FS, dockerfilePath, []byte(dockerfileAll), 0644)).To(Succeed())
+			opts := defaultImageOptions()
+			opts.GoBaseImage = "ubi"
+			_, err := replaceImages(fs, opts)
+			Expect(err).NotTo(HaveOccurred())
+			dockerfileOut, err := afero.ReadFile(fs.FS, dockerfilePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(dockerfileOut)).To(ContainSubstring("FROM registry.access.redhat.com/ubi8/ubi:" + ubiMinimalVersion + "\n"))
+			Expect(string(dockerfileOut)).NotTo(ContainSubstring("ubi-minimal"))
+			Expect(string(dockerfileOut)).To(ContainSubstring("FROM registry.access.redhat.com/ubi8/ubi-micro:" + ubiMinimalVersion + "\n"))
+		})
+
+		It("switches UBI base images to UBI 9", func() {
+			Expect(afero.WriteFile(fs.FS, dockerfilePath, []byte(dockerfileAll), 0644)).To(Succeed())
+			Expect(afero.WriteFile(fs.FS, proxyPatchPath, []byte(proxyPatch), 0644)).To(Succeed())
+			opts := defaultImageOptions()
+			opts.UBIMajor, opts.UBIVersion = 9, ubi9MinimalVersion
+			_, err := replaceImages(fs, opts)
+			Expect(err).NotTo(HaveOccurred())
+			dockerfileOut, err := afero.ReadFile(fs.FS, dockerfilePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(dockerfileOut)).To(ContainSubstring("FROM registry.access.redhat.com/ubi9/ubi-minimal:" + ubi9MinimalVersion + "\n"))
+			Expect(string(dockerfileOut)).To(ContainSubstring("FROM registry.access.redhat.com/ubi9/ubi-micro:" + ubi9MinimalVersion + "\n"))
+			Expect(string(dockerfileOut)).NotTo(ContainSubstring("ubi8"))
+			Expect(string(dockerfileOut)).To(ContainSubstring("FROM registry.redhat.io/openshift4/ose-ansible-operator:v" + ocpProductVersion + "\n"))
+			Expect(string(dockerfileOut)).To(ContainSubstring("FROM registry.redhat.io/openshift4/ose-helm-operator:v" + ocpProductVersion + "\n"))
+		})
+
+		It("appends the given architecture to downstream OpenShift image tags", func() {
+			Expect(afero.WriteFile(fs.FS, dockerfilePath, []byte(dockerfileAll), 0644)).To(Succeed())
+			Expect(afero.WriteFile(fs.FS, proxyPatchPath, []byte(proxyPatch), 0644)).To(Succeed())
+			opts := defaultImageOptions()
+			opts.Arch = "arm64"
+			_, err := replaceImages(fs, opts)
+			Expect(err).NotTo(HaveOccurred())
+			dockerfileOut, err := afero.ReadFile(fs.FS, dockerfilePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(dockerfileOut)).To(ContainSubstring("FROM registry.redhat.io/openshift4/ose-ansible-operator:v" + ocpProductVersion + "-arm64\n"))
+			Expect(string(dockerfileOut)).To(ContainSubstring("FROM registry.redhat.io/openshift4/ose-helm-operator:v" + ocpProductVersion + "-arm64\n"))
+			Expect(string(dockerfileOut)).To(ContainSubstring("FROM registry.access.redhat.com/ubi8/ubi-minimal:" + ubiMinimalVersion + "\n"))
+			proxyPatchOut, err := afero.ReadFile(fs.FS, proxyPatchPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(proxyPatchOut)).To(ContainSubstring("image: registry.redhat.io/openshift4/ose-kube-rbac-proxy:v" + ocpProductVersion + "-arm64\n"))
+		})
+
+		It("substitutes base images parameterized by ARG, quoted or not", func() {
+			Expect(afero.WriteFile(fs.FS, dockerfilePath, []byte(dockerfileARG), 0644)).To(Succeed())
+			opts := defaultImageOptions()
+			opts.GoBuilderVersion = "1.21"
+			_, err := replaceImages(fs, opts)
+			Expect(err).NotTo(HaveOccurred())
+			dockerfileOut, err := afero.ReadFile(fs.FS, dockerfilePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(dockerfileOut)).To(Equal(dockerfileARGExp))
+		})
+
+		It("substitutes every occurrence of a base image in multi-stage builds with aliases", func() {
+			Expect(afero.WriteFile(fs.FS, dockerfilePath, []byte(dockerfileAlias), 0644)).To(Succeed())
+			opts := defaultImageOptions()
+			opts.GoBuilderVersion = "1.21"
+			results, err := replaceImages(fs, opts)
+			Expect(err).NotTo(HaveOccurred())
+			dockerfileOut, err := afero.ReadFile(fs.FS, dockerfilePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(dockerfileOut)).To(Equal(dockerfileAliasExp))
+			counts := map[string]int{}
+			for _, result := range results {
+				counts[result.Pattern] += result.Count
+			}
+			Expect(counts[ansibleOperatorSubstitution.fromTagRE.String()]).To(Equal(2))
+			Expect(counts[`golang[:@][^ \n"']+`]).To(Equal(1))
+		})
+
+		It("leaves Go versions unchanged by default", func() {
+			Expect(afero.WriteFile(fs.FS, dockerfilePath, []byte(dockerfileGoBuilder), 0644)).To(Succeed())
+			Expect(afero.WriteFile(fs.FS, "go.mod", []byte(goMod), 0644)).To(Succeed())
+			_, err := replaceImages(fs, defaultImageOptions())
+			Expect(err).NotTo(HaveOccurred())
+			dockerfileOut, err := afero.ReadFile(fs.FS, dockerfilePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(dockerfileOut)).To(ContainSubstring("FROM golang:1.19 as builder\n"))
+			goModOut, err := afero.ReadFile(fs.FS, "go.mod")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(goModOut)).To(Equal(goMod))
+		})
+
+		It("tags golang builder images and aligns the go directive with the given Go version", func() {
+			Expect(afero.WriteFile(fs.FS, dockerfilePath, []byte(dockerfileGoBuilder), 0644)).To(Succeed())
+			Expect(afero.WriteFile(fs.FS, "go.mod", []byte(goMod), 0644)).To(Succeed())
+			opts := defaultImageOptions()
+			opts.GoBuilderVersion = "1.21.5"
+			_, err := replaceImages(fs, opts)
+			Expect(err).NotTo(HaveOccurred())
+			dockerfileOut, err := afero.ReadFile(fs.FS, dockerfilePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(dockerfileOut)).To(Equal("FROM golang:1.21.5 as builder\n" +
+				"FROM docker.io/library/golang:1.21.5\n" +
+				"FROM registry.access.redhat.com/ubi8/ubi-minimal:" + ubiMinimalVersion + "\n"))
+			goModOut, err := afero.ReadFile(fs.FS, "go.mod")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(goModOut)).To(Equal("module example.com/memcached-operator\n\ngo 1.21\n\nrequire golang.org/x/net v0.17.0\n"))
+
+			// The go-builder category can still be disabled.
+			Expect(afero.WriteFile(fs.FS, dockerfilePath, []byte(dockerfileGoBuilder), 0644)).To(Succeed())
+			opts.Disabled = []string{GoBuilderCategory}
+			_, err = replaceImages(fs, opts)
+			Expect(err).NotTo(HaveOccurred())
+			dockerfileOut, err = afero.ReadFile(fs.FS, dockerfilePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(dockerfileOut)).To(ContainSubstring("FROM golang:1.19 as builder\n"))
+		})
+
+		It("never lowers the go directive", func() {
+			opts := defaultImageOptions()
+			opts.GoBuilderVersion = "1.21"
+			for _, c := range []struct{ current, exp string }{
+				{"go 1.19", "go 1.21"},
+				{"go 1.21", "go 1.21"},
+				{"go 1.21.5", "go 1.21.5"},
+				{"go 1.22", "go 1.22"},
+			} {
+				Expect(afero.WriteFile(fs.FS, "go.mod", []byte("module example.com/op\n\n"+c.current+"\n"), 0644)).To(Succeed())
+				results, err := replaceImages(fs, opts)
+				Expect(err).NotTo(HaveOccurred())
+				goModOut, err := afero.ReadFile(fs.FS, "go.mod")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(goModOut)).To(Equal("module example.com/op\n\n"+c.exp+"\n"), c.current)
+				for _, result := range results {
+					if result.Path == "go.mod" && c.current != "go 1.19" {
+						Expect(result.Count).To(BeZero(), c.current)
+					}
+				}
+			}
+		})
+
+		It("leaves go.mod unchanged if go.mod edits are disabled", func() {
+			const goMod = "module example.com/op\n\ngo 1.19\n"
+			Expect(afero.WriteFile(fs.FS, "go.mod", []byte(goMod), 0644)).To(Succeed())
+			Expect(afero.WriteFile(fs.FS, dockerfilePath, []byte("FROM golang:1.19 as builder\n"), 0644)).To(Succeed())
+			opts := defaultImageOptions()
+			opts.GoBuilderVersion, opts.NoGoModEdit = "1.21", true
+			results, err := replaceImages(fs, opts)
+			Expect(err).NotTo(HaveOccurred())
+			for _, result := range results {
+				Expect(result.Path).NotTo(Equal("go.mod"))
+			}
+			goModOut, err := afero.ReadFile(fs.FS, "go.mod")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(goModOut)).To(Equal(goMod))
+			dockerfileOut, err := afero.ReadFile(fs.FS, dockerfilePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(dockerfileOut)).To(Equal("FROM golang:1.21 as builder\n"))
+		})
+
+		It("does not apply disabled substitution categories", func() {
+			Expect(afero.WriteFile(fs.FS, dockerfilePath, []byte(dockerfileAll), 0644)).To(Succeed())
+			Expect(afero.WriteFile(fs.FS, proxyPatchPath, []byte(proxyPatch), 0644)).To(Succeed())
+			opts := defaultImageOptions()
+			opts.Disabled = []string{HelmOperatorCategory, KubeRBACProxyCategory}
+			results, err := replaceImages(fs, opts)
+			Expect(err).NotTo(HaveOccurred())
+			for _, result := range results {
+				Expect(result.Path).To(Equal(dockerfilePath))
+			}
+			dockerfileOut, err := afero.ReadFile(fs.FS, dockerfilePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(dockerfileOut)).To(ContainSubstring("FROM quay.io/operator-framework/helm-operator:v1.2.3\n"))
+			Expect(string(dockerfileOut)).To(ContainSubstring("FROM registry.redhat.io/openshift4/ose-ansible-operator:v" + ocpProductVersion + "\n"))
+			proxyPatchOut, err := afero.ReadFile(fs.FS, proxyPatchPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(proxyPatchOut)).To(Equal(proxyPatch))
+		})
+
+		It("replaces Red Hat registry hosts with the given registry", func() {
+			Expect(afero.WriteFile(fs.FS, dockerfilePath, []byte(dockerfileAll), 0644)).To(Succeed())
+			Expect(afero.WriteFile(fs.FS, proxyPatchPath, []byte(proxyPatch), 0644)).To(Succeed())
+			opts := defaultImageOptions()
+			opts.Registry = "mirror.example.com:5000"
+			_, err := replaceImages(fs, opts)
+			Expect(err).NotTo(HaveOccurred())
+			dockerfileOut, err := afero.ReadFile(fs.FS, dockerfilePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(dockerfileOut)).To(ContainSubstring("FROM mirror.example.com:5000/openshift4/ose-ansible-operator:v" + ocpProductVersion + "\n"))
+			Expect(string(dockerfileOut)).To(ContainSubstring("FROM mirror.example.com:5000/openshift4/ose-helm-operator:v" + ocpProductVersion + "\n"))
+			Expect(string(dockerfileOut)).To(ContainSubstring("FROM mirror.example.com:5000/ubi8/ubi-minimal:" + ubiMinimalVersion + "\n"))
+			Expect(string(dockerfileOut)).To(ContainSubstring("FROM mirror.example.com:5000/ubi8/ubi-micro:" + ubiMinimalVersion + "\n"))
+			Expect(string(dockerfileOut)).NotTo(ContainSubstring(redHatRegistry))
+			Expect(string(dockerfileOut)).NotTo(ContainSubstring(redHatAccessRegistry))
+			proxyPatchOut, err := afero.ReadFile(fs.FS, proxyPatchPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(proxyPatchOut)).To(ContainSubstring("image: mirror.example.com:5000/openshift4/ose-kube-rbac-proxy:v" + ocpProductVersion + "\n"))
+		})
+
+		It("substitutes images run by molecule scenarios of Ansible projects", func() {
+			const otherScenarioPath = "molecule/openshift/molecule.yml"
+			for _, path := range []string{moleculeDefaultPath, otherScenarioPath} {
+				Expect(afero.WriteFile(fs.FS, path, []byte(moleculeDocker), 0644)).To(Succeed())
+			}
+			opts := defaultImageOptions()
+			opts.ProjectType, opts.scanDir = AnsibleProjectType, true
+			_, err := replaceImages(fs, opts)
+			Expect(err).NotTo(HaveOccurred())
+			for _, path := range []string{moleculeDefaultPath, otherScenarioPath} {
+				b, err := afero.ReadFile(fs.FS, path)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(b)).To(ContainSubstring("image: registry.redhat.io/openshift4/ose-ansible-operator:v"+ocpProductVersion+"\n"), path)
+			}
+		})
+
+		It("does not substitute molecule scenarios of other projects", func() {
+			Expect(afero.WriteFile(fs.FS, moleculeDefaultPath, []byte(moleculeDocker), 0644)).To(Succeed())
+			opts := defaultImageOptions()
+			opts.ProjectType = HelmProjectType
+			_, err := replaceImages(fs, opts)
+			Expect(err).NotTo(HaveOccurred())
+			b, err := afero.ReadFile(fs.FS, moleculeDefaultPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(Equal(moleculeDocker))
+		})
+
+		It("substitutes ko base images of Go projects, also in YAML-aware mode", func() {
+			for _, yamlAware := range []bool{false, true} {
+				Expect(afero.WriteFile(fs.FS, koConfigPath, []byte(koConfig), 0644)).To(Succeed())
+				opts := defaultImageOptions()
+				opts.ProjectType, opts.yamlAware = GoProjectType, yamlAware
+				_, err := replaceImages(fs, opts)
+				Expect(err).NotTo(HaveOccurred())
+				b, err := afero.ReadFile(fs.FS, koConfigPath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(b)).To(Equal(koConfigExp))
+			}
+		})
+
+		It("skips projects without a ko config", func() {
+			opts := defaultImageOptions()
+			opts.ProjectType, opts.strict, opts.authProxyOptional = GoProjectType, true, true
+			Expect(afero.WriteFile(fs.FS, dockerfilePath, []byte(dockerfileAll), 0644)).To(Succeed())
+			_, err := replaceImages(fs, opts)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(afero.Exists(fs.FS, koConfigPath)).To(BeFalse())
+		})
+
+		It("substitutes the scorecard test image in scorecard config patches", func() {
+			Expect(afero.WriteFile(fs.FS, scorecardBasicPath, []byte(scorecardBasicPatch), 0644)).To(Succeed())
+			Expect(afero.WriteFile(fs.FS, scorecardOLMPath, []byte(scorecardOLMPatch), 0644)).To(Succeed())
+			_, err := replaceImages(fs, defaultImageOptions())
+			Expect(err).NotTo(HaveOccurred())
+			for _, path := range []string{scorecardBasicPath, scorecardOLMPath} {
+				b, err := afero.ReadFile(fs.FS, path)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(b)).NotTo(ContainSubstring("quay.io/operator-framework/scorecard-test:"), path)
+				Expect(string(b)).To(ContainSubstring("image: registry.redhat.io/openshift4/ose-scorecard-test:v"+ocpProductVersion+"\n"), path)
+			}
+			// Images pinned by digest are kept by default.
+			b, err := afero.ReadFile(fs.FS, scorecardOLMPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(ContainSubstring("image: quay.io/operator-framework/scorecard-test@sha256:"))
+		})
+
+		It("skips projects without a scorecard config in strict mode", func() {
+			Expect(afero.WriteFile(fs.FS, dockerfilePath, []byte(dockerfileAll), 0644)).To(Succeed())
+			opts := defaultImageOptions()
+			opts.strict, opts.authProxyOptional = true, true
+			_, err := replaceImages(fs, opts)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("logs the match count of each file processed at debug level", func() {
+			const dockerfileGo = "FROM gcr.io/distroless/static:nonroot\n"
+			Expect(afero.WriteFile(fs.FS, dockerfilePath, []byte(dockerfileGo), 0644)).To(Succeed())
+			Expect(afero.WriteFile(fs.FS, proxyPatchPath, []byte(proxyPatch), 0644)).To(Succeed())
+			logOut := &bytes.Buffer{}
+			logger := log.New()
+			logger.SetOutput(logOut)
+			logger.SetFormatter(&log.JSONFormatter{})
+			opts := defaultImageOptions()
+			opts.logger = log.NewEntry(logger)
+
+			logger.SetLevel(log.DebugLevel)
+			_, err := replaceImages(fs, opts)
+			Expect(err).NotTo(HaveOccurred())
+			lines := strings.Split(strings.TrimSpace(logOut.String()), "\n")
+			Expect(lines).To(HaveLen(10))
+			Expect(lines[0]).To(ContainSubstring(`"file":".ko.yaml"`))
+			Expect(lines[0]).To(ContainSubstring("does not exist"))
+			Expect(lines[1]).To(ContainSubstring(`"file":"Dockerfile"`))
+			Expect(lines[1]).To(ContainSubstring(`"matches":1`))
+			Expect(lines[2]).To(ContainSubstring(`"file":"bundle.Dockerfile"`))
+			Expect(lines[2]).To(ContainSubstring("does not exist"))
+			Expect(lines[3]).To(ContainSubstring(`"file":"config/default/manager_auth_proxy_patch.yaml"`))
+			Expect(lines[3]).To(ContainSubstring(`"matches":2`))
+			Expect(lines[4]).To(ContainSubstring(`"file":"config/openshift/consoleplugin.yaml"`))
+			Expect(lines[4]).To(ContainSubstring("does not exist"))
+			Expect(lines[5]).To(ContainSubstring(`"file":"config/openshift/template.yaml"`))
+			Expect(lines[6]).To(ContainSubstring(`"file":"config/scorecard/patches/basic.config.yaml"`))
+			Expect(lines[7]).To(ContainSubstring(`"file":"config/scorecard/patches/olm.config.yaml"`))
+			Expect(lines[8]).To(ContainSubstring(`"file":"molecule/default/molecule.yml"`))
+			Expect(lines[9]).To(ContainSubstring(`"file":"molecule/kind/molecule.yml"`))
+
+			logOut.Reset()
+			logger.SetLevel(log.InfoLevel)
+			_, err = replaceImages(fs, opts)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(logOut.String()).To(BeEmpty())
+		})
+
+		It("is idempotent", func() {
+			withRegistry := defaultImageOptions()
+			withRegistry.Registry = "mirror.example.com:5000"
+			withUBI9 := defaultImageOptions()
+			withUBI9.UBIMajor, withUBI9.UBIVersion = 9, ubi9MinimalVersion
+			withVersions := defaultImageOptions()
+			withVersions.OCPVersion, withVersions.UBIVersion = "4.15", "8.9"
+			withArch := defaultImageOptions()
+			withArch.Arch = "s390x"
+
+			for _, opts := range []imageOptions{defaultImageOptions(), withRegistry, withUBI9, withVersions, withArch} {
+				Expect(afero.WriteFile(fs.FS, dockerfilePath, []byte(dockerfileAll), 0644)).To(Succeed())
+				Expect(afero.WriteFile(fs.FS, proxyPatchPath, []byte(proxyPatch), 0644)).To(Succeed())
+
+				_, err := replaceImages(fs, opts)
+				Expect(err).NotTo(HaveOccurred())
+				dockerfileOnce, err := afero.ReadFile(fs.FS, dockerfilePath)
+				Expect(err).NotTo(HaveOccurred())
+				proxyPatchOnce, err := afero.ReadFile(fs.FS, proxyPatchPath)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = replaceImages(fs, opts)
+				Expect(err).NotTo(HaveOccurred())
+				dockerfileTwice, err := afero.ReadFile(fs.FS, dockerfilePath)
+				Expect(err).NotTo(HaveOccurred())
+				proxyPatchTwice, err := afero.ReadFile(fs.FS, proxyPatchPath)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(dockerfileTwice).To(Equal(dockerfileOnce), "Dockerfile with %+v", opts)
+				Expect(proxyPatchTwice).To(Equal(proxyPatchOnce), "manager_auth_proxy_patch.yaml with %+v", opts)
+			}
+		})
+
+		It("writes files without leaving temporary files behind", func() {
+			Expect(afero.WriteFile(fs.FS, dockerfilePath, []byte(dockerfileAll), 0600)).To(Succeed())
+			Expect(afero.WriteFile(fs.FS, proxyPatchPath, []byte(proxyPatch), 0644)).To(Succeed())
+			_, err := replaceImages(fs, defaultImageOptions())
+			Expect(err).NotTo(HaveOccurred())
+			infos, err := afero.ReadDir(fs.FS, ".")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(infos).To(HaveLen(2)) // Dockerfile and config/
+			info, err := fs.FS.Stat(dockerfilePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Mode().Perm()).To(BeEquivalentTo(0600))
+		})
+
+		It("retries a write that fails with a transient error", func() {
+			Expect(afero.WriteFile(fs.FS, dockerfilePath, []byte(dockerfileAll), 0644)).To(Succeed())
+			delay := writeRetryDelay
+			writeRetryDelay = 0
+			defer func() { writeRetryDelay = delay }()
+
+			flakyFS := &flakyRenameFs{Fs: fs.FS, failures: 1}
+			_, err := replaceImages(machinery.Filesystem{FS: flakyFS}, defaultImageOptions())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(flakyFS.failures).To(BeZero())
+			dockerfileOut, err := afero.ReadFile(fs.FS, dockerfilePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(dockerfileOut)).To(ContainSubstring(dockerfileAllExp))
+		})
+
+		It("reports the path of a file that cannot be written", func() {
+			Expect(afero.WriteFile(fs.FS, dockerfilePath, []byte(dockerfileAll), 0644)).To(Succeed())
+			_, err := replaceImages(machinery.Filesystem{FS: afero.NewReadOnlyFs(fs.FS)}, defaultImageOptions())
+			Expect(err).To(MatchError(ContainSubstring("error writing " + dockerfilePath + ":")))
+		})
+
+		It("reports a missing parent directory", func() {
+			err := writeFile(fs.FS, "config/missing/a.yaml", nil, 0644)
+			Expect(err).To(MatchError("error writing config/missing/a.yaml: directory config/missing does not exist"))
+		})
+
+		It("backs up files before modifying them", func() {
+			Expect(afero.WriteFile(fs.FS, dockerfilePath, []byte(dockerfileAll), 0600)).To(Succeed())
+			Expect(afero.WriteFile(fs.FS, proxyPatchPath, []byte(proxyPatch), 0644)).To(Succeed())
+			opts := defaultImageOptions()
+			opts.backup = true
+			_, err := replaceImages(fs, opts)
+			Expect(err).NotTo(HaveOccurred())
+			dockerfileBackup, err := afero.ReadFile(fs.FS, dockerfilePath+backupSuffix)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(dockerfileBackup)).To(Equal(dockerfileAll))
+			info, err := fs.FS.Stat(dockerfilePath + backupSuffix)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Mode().Perm()).To(BeEquivalentTo(0600))
+			proxyPatchBackup, err := afero.ReadFile(fs.FS, proxyPatchPath+backupSuffix)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(proxyPatchBackup)).To(Equal(proxyPatch))
+		})
+
+		It("processes every file and reports the errors of all files that failed", func() {
+			Expect(afero.WriteFile(fs.FS, proxyPatchPath, []byte(proxyPatch), 0644)).To(Succeed())
+			opts := defaultImageOptions()
+			opts.strict, opts.GoBuilderVersion = true, "1.21"
+			results, err := replaceImages(fs, opts)
+			Expect(err).To(MatchError(ContainSubstring(dockerfilePath)))
+			Expect(err).To(MatchError(ContainSubstring("go.mod")))
+			Expect(results).NotTo(BeEmpty())
+			for _, result := range results {
+				Expect(result.Path).To(Equal(proxyPatchPath))
+			}
+			proxyPatchOut, err := afero.ReadFile(fs.FS, proxyPatchPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(proxyPatchOut)).To(ContainSubstring(proxyPatchExp))
+		})
+
+		It("restores backed up files if a later file fails", func() {
+			Expect(afero.WriteFile(fs.FS, dockerfilePath, []byte(dockerfileAll), 0644)).To(Succeed())
+			opts := defaultImageOptions()
+			opts.backup, opts.strict = true, true
+			_, err := replaceImages(fs, opts)
+			Expect(err).To(HaveOccurred())
+			dockerfileOut, err := afero.ReadFile(fs.FS, dockerfilePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(dockerfileOut)).To(Equal(dockerfileAll))
+		})
+
+		It("prints planned substitutions without writing files in dry-run mode", func() {
+			Expect(afero.WriteFile(fs.FS, dockerfilePath, []byte(dockerfileAll), 0644)).To(Succeed())
+			Expect(afero.WriteFile(fs.FS, proxyPatchPath, []byte(proxyPatch), 0644)).To(Succeed())
+			out := &bytes.Buffer{}
+			opts := defaultImageOptions()
+			opts.dryRun, opts.out = true, out
+			_, err := replaceImages(fs, opts)
+			Expect(err).NotTo(HaveOccurred())
+			dockerfileOut, err := afero.ReadFile(fs.FS, dockerfilePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(dockerfileOut)).To(Equal(dockerfileAll))
+			proxyPatchOut, err := afero.ReadFile(fs.FS, proxyPatchPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(proxyPatchOut)).To(Equal(proxyPatch))
+			Expect(out.String()).To(ContainSubstring("Dockerfile: gcr.io/distroless/static:nonroot -> " +
+				"registry.access.redhat.com/ubi8/ubi-minimal:" + ubiMinimalVersion + "\n"))
+			Expect(out.String()).To(ContainSubstring(proxyPatchPath + ": gcr.io/kubebuilder/kube-rbac-proxy:v0.5.0 -> " +
+				"registry.redhat.io/openshift4/ose-kube-rbac-proxy:v" + ocpProductVersion + "\n"))
+		})
+
+		It("prints dry-run substitutions in path order when processing files concurrently", func() {
+			opts := defaultImageOptions()
+			for i := 0; i < 50; i++ {
+				filePath := fmt.Sprintf("config/manager/manager_%02d.yaml", i)
+				Expect(afero.WriteFile(fs.FS, filePath, []byte(proxyPatch), 0644)).To(Succeed())
+				opts.Files = append(opts.Files, filePath)
+			}
+			out := &bytes.Buffer{}
+			opts.dryRun, opts.out, opts.workers = true, out, 8
+			_, err := replaceImages(fs, opts)
+			Expect(err).NotTo(HaveOccurred())
+			var paths []string
+			for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+				paths = append(paths, strings.SplitN(line, ":", 2)[0])
+			}
+			Expect(len(paths)).To(BeNumerically(">=", 50))
+			Expect(sort.StringsAreSorted(paths)).To(BeTrue())
+		})
+
+		It("reports the error of the first file in path order", func() {
+			opts := defaultImageOptions()
+			opts.strict, opts.workers = true, 8
+			_, err := replaceImages(fs, opts)
+			Expect(err).To(MatchError(ContainSubstring(dockerfilePath)))
+		})
+
+		It("skips missing files", func() {
+			Expect(afero.WriteFile(fs.FS, dockerfilePath, []byte(dockerfileAll), 0644)).To(Succeed())
+			results, err := replaceImages(fs, defaultImageOptions())
+			Expect(err).NotTo(HaveOccurred())
+			for _, result := range results {
+				Expect(result.Path).To(Equal(dockerfilePath))
+			}
+			dockerfileOut, err := afero.ReadFile(fs.FS, dockerfilePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(dockerfileOut)).To(ContainSubstring(dockerfileAllExp))
+		})
+
+		It("fails if a file is missing in strict mode", func() {
+			Expect(afero.WriteFile(fs.FS, dockerfilePath, []byte(dockerfileAll), 0644)).To(Succeed())
+			opts := defaultImageOptions()
+			opts.strict = true
+			_, err := replaceImages(fs, opts)
+			Expect(err).To(MatchError(ContainSubstring("error reading file for substitution")))
+		})
+
+		It("does not require a console plugin or bundle Dockerfile in strict mode", func() {
+			Expect(afero.WriteFile(fs.FS, dockerfilePath, []byte(dockerfileAll), 0644)).To(Succeed())
+			Expect(afero.WriteFile(fs.FS, proxyPatchPath, []byte(proxyPatch), 0644)).To(Succeed())
+			opts := defaultImageOptions()
+			opts.strict = true
+			_, err := replaceImages(fs, opts)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("skips files last modified before --since", func() {
+			since := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+			Expect(afero.WriteFile(fs.FS, dockerfilePath, []byte(dockerfileAll), 0644)).To(Succeed())
+			Expect(afero.WriteFile(fs.FS, proxyPatchPath, []byte(proxyPatch), 0644)).To(Succeed())
+			Expect(fs.FS.Chtimes(dockerfilePath, since.Add(-time.Hour), since.Add(-time.Hour))).To(Succeed())
+			Expect(fs.FS.Chtimes(proxyPatchPath, since.Add(time.Hour), since.Add(time.Hour))).To(Succeed())
+			opts := defaultImageOptions()
+			opts.since = since
+			results, err := replaceImages(fs, opts)
+			Expect(err).NotTo(HaveOccurred())
+			for _, result := range results {
+				Expect(result.Path).To(Equal(proxyPatchPath))
+			}
+			dockerfileOut, err := afero.ReadFile(fs.FS, dockerfilePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(dockerfileOut)).To(Equal(dockerfileAll))
+			proxyPatchOut, err := afero.ReadFile(fs.FS, proxyPatchPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(proxyPatchOut)).To(Equal(proxyPatchExp))
+		})
+
+		It("writes no files if canceled", func() {
+			Expect(afero.WriteFile(fs.FS, dockerfilePath, []byte(dockerfileAll), 0644)).To(Succeed())
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			for _, scanDir := range []bool{false, true} {
+				opts := defaultImageOptions()
+				opts.ctx, opts.scanDir = ctx, scanDir
+				_, err := replaceImages(fs, opts)
+				Expect(err).To(MatchError(context.Canceled))
+				Expect(err).To(MatchError(ContainSubstring("canceled")))
+				b, err := afero.ReadFile(fs.FS, dockerfilePath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(b)).To(Equal(dockerfileAll))
+			}
+		})
+
+		It("fails in dry-run mode if a file is missing in strict mode", func() {
+			Expect(afero.WriteFile(fs.FS, dockerfilePath, []byte(dockerfileAll), 0644)).To(Succeed())
+			opts := defaultImageOptions()
+			opts.dryRun, opts.out, opts.strict = true, &bytes.Buffer{}, true
+			_, err := replaceImages(fs, opts)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
+
+var _ = Describe("ReplaceBundleImages", func() {
+	var fs machinery.Filesystem
+
+	BeforeEach(func() {
+		fs = machinery.Filesystem{FS: afero.NewMemMapFs()}
+		Expect(afero.WriteFile(fs.FS, "Dockerfile", []byte(dockerfileAll), 0644)).To(Succeed())
+	})
+
+	It("leaves a generated bundle Dockerfile unchanged", func() {
+		Expect(afero.WriteFile(fs.FS, bundleDockerfilePath, []byte(bundleDockerfile), 0644)).To(Succeed())
+		count, err := ReplaceBundleImages(fs)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(count).To(BeZero())
+		bundleOut, err := afero.ReadFile(fs.FS, bundleDockerfilePath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(bundleOut)).To(Equal(bundleDockerfile))
+	})
+
+	It("substitutes base images of the bundle Dockerfile only", func() {
+		content := strings.Replace(bundleDockerfile, "FROM scratch", "FROM gcr.io/distroless/static:nonroot", 1)
+		Expect(afero.WriteFile(fs.FS, bundleDockerfilePath, []byte(content), 0644)).To(Succeed())
+		count, err := ReplaceBundleImages(fs)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(count).To(Equal(1))
+		bundleOut, err := afero.ReadFile(fs.FS, bundleDockerfilePath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(bundleOut)).To(HavePrefix("FROM registry.access.redhat.com/ubi8/ubi-minimal:" + ubiMinimalVersion + "\n"))
+		dockerfileOut, err := afero.ReadFile(fs.FS, "Dockerfile")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(dockerfileOut)).To(Equal(dockerfileAll))
+	})
+
+	It("skips a missing bundle Dockerfile", func() {
+		count, err := ReplaceBundleImages(fs)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(count).To(BeZero())
+	})
+})
+
+var _ = Describe("Helm scaffold", func() {
+	// helmScaffoldDir contains a project scaffolded by the Helm plugin.
+	const helmScaffoldDir = "../../../../testdata/helm/memcached-operator"
+
+	var (
+		fs machinery.Filesystem
+		c  config.Config
+	)
+
+	BeforeEach(func() {
+		// Writes go to memory, leaving the scaffold unchanged.
+		fs = machinery.Filesystem{FS: afero.NewCopyOnWriteFs(afero.NewReadOnlyFs(afero.NewBasePathFs(afero.NewOsFs(), helmScaffoldDir)), afero.NewMemMapFs())}
+		b, err := afero.ReadFile(fs.FS, "PROJECT")
+		Expect(err).NotTo(HaveOccurred())
+		c = cfgv3.New()
+		Expect(c.UnmarshalYAML(b)).To(Succeed())
+	})
+
+	It("is detected as a Helm project", func() {
+		Expect(projectType(c)).To(Equal(HelmProjectType))
+	})
+
+	It("substitutes every upstream image of the scaffold", func() {
+		s := &initSubcommand{}
+		Expect(s.InjectConfig(c)).To(Succeed())
+		Expect(s.PreScaffold(fs)).To(Succeed())
+		Expect(s.Scaffold(fs)).To(Succeed())
+
+		dockerfileOut, err := afero.ReadFile(fs.FS, "Dockerfile")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(dockerfileOut)).To(Equal(helmDockerfileExp))
+		images, err := VerifyImages(context.Background(), fs)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(images).To(BeEmpty())
+	})
+
+	It("does not substitute Helm images in other project types", func() {
+		opts := DefaultOptions()
+		opts.ProjectType = GoProjectType
+		for _, subst := range BuildSubstitutions(opts)["Dockerfile"] {
+			Expect(subst.Category).NotTo(Equal(HelmOperatorCategory))
+		}
+		opts.ProjectType = HelmProjectType
+		Expect(BuildSubstitutions(opts)["Dockerfile"]).To(ContainElement(HaveField("Category", HelmOperatorCategory)))
+	})
+
+	It("succeeds with --fail-on-nomatch", func() {
+		s := &initSubcommand{options: imageOptions{failOnNoMatch: true}}
+		Expect(s.InjectConfig(c)).To(Succeed())
+		Expect(s.PreScaffold(fs)).To(Succeed())
+		Expect(s.Scaffold(fs)).To(Succeed())
+	})
+})
+
+var _ = Describe("Go scaffold", func() {
+	// goScaffoldDir contains a project scaffolded by the Go plugin.
+	const goScaffoldDir = "../../../../testdata/go/v3/memcached-operator"
+
+	var (
+		fs machinery.Filesystem
+		c  config.Config
+	)
+
+	BeforeEach(func() {
+		// Writes go to memory, leaving the scaffold unchanged.
+		fs = machinery.Filesystem{FS: afero.NewCopyOnWriteFs(afero.NewReadOnlyFs(afero.NewBasePathFs(afero.NewOsFs(), goScaffoldDir)), afero.NewMemMapFs())}
+		b, err := afero.ReadFile(fs.FS, "PROJECT")
+		Expect(err).NotTo(HaveOccurred())
+		c = cfgv3.New()
+		Expect(c.UnmarshalYAML(b)).To(Succeed())
+	})
+
+	It("succeeds with --fail-on-nomatch, since only Go substitutions apply to its Dockerfile", func() {
+		s := &initSubcommand{options: imageOptions{failOnNoMatch: true}}
+		Expect(s.InjectConfig(c)).To(Succeed())
+		Expect(s.PreScaffold(fs)).To(Succeed())
+		Expect(s.Scaffold(fs)).To(Succeed())
+
+		dockerfileOut, err := afero.ReadFile(fs.FS, "Dockerfile")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(dockerfileOut)).To(ContainSubstring("FROM registry.access.redhat.com/ubi8/ubi-minimal:" + ubiMinimalVersion + "\n"))
+		images, err := VerifyImages(context.Background(), fs)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(images).To(BeEmpty())
+	})
+
+	It("does not substitute Ansible or hybrid Helm images in its Dockerfile", func() {
+		opts := DefaultOptions()
+		opts.ProjectType = GoProjectType
+		for _, subst := range BuildSubstitutions(opts)["Dockerfile"] {
+			Expect(subst.Category).NotTo(BeElementOf(AnsibleOperatorCategory, UBIMicroCategory))
+		}
+	})
+})
+
+const helmDockerfileExp = `# Build the manager binary
+FROM registry.redhat.io/openshift4/ose-helm-operator:v` + ocpProductVersion + `
+
+ENV HOME=/opt/helm
+COPY watches.yaml ${HOME}/watches.yaml
+COPY helm-charts  ${HOME}/helm-charts
+WORKDIR ${HOME}
+`
+
+const dockerfileAll = `FROM foo:bar
+
+FROM quay.io/operator-framework/ansible-operator:v1.2.3
+FROM quay.io/operator-framework/ansible-operator:v1.2
+FROM quay.io/operator-framework/ansible-operator:latest
+FROM quay.io/operator-framework/ansible:latest
+FROM foo/ansible-operator:latest
+
+FROM quay.io/operator-framework/helm-operator:v1.2.3
+FROM quay.io/operator-framework/helm-operator:v1.2
+FROM quay.io/operator-framework/helm-operator:latest
+FROM quay.io/operator-framework/helm:latest
+FROM foo/helm-operator:latest
+
+FROM gcr.io/distroless/static:nonroot
+FROM gcr.io/distroless/static:latest
+FROM distroless/static:latest
+
+FROM registry.access.redhat.com/ubi8/ubi-micro:8.1
+`
+
+const dockerfileAllExp = `FROM foo:bar
+
+FROM registry.redhat.io/openshift4/ose-ansible-operator:v` + ocpProductVersion + `
+FROM registry.redhat.io/openshift4/ose-ansible-operator:v` + ocpProductVersion + `
+FROM registry.redhat.io/openshift4/ose-ansible-operator:v` + ocpProductVersion + `
+FROM quay.io/operator-framework/ansible:latest
+FROM foo/ansible-operator:latest
+
+FROM registry.redhat.io/openshift4/ose-helm-operator:v` + ocpProductVersion + `
+FROM registry.redhat.io/openshift4/ose-helm-operator:v` + ocpProductVersion + `
+FROM registry.redhat.io/openshift4/ose-helm-operator:v` + ocpProductVersion + `
+FROM quay.io/operator-framework/helm:latest
+FROM foo/helm-operator:latest
+
+FROM registry.access.redhat.com/ubi8/ubi-minimal:` + ubiMinimalVersion + `
+FROM registry.access.redhat.com/ubi8/ubi-minimal:` + ubiMinimalVersion + `
+FROM distroless/static:latest
+
+FROM registry.access.redhat.com/ubi8/ubi-micro:` + ubiMinimalVersion + `
+`
+
+// digestPinnedImages are the upstream images substituted in each path, without a tag or digest.
+var digestPinnedImages = []struct{ path, image string }{
+	{authProxyPatchPath, "gcr.io/kubebuilder/kube-rbac-proxy"},
+	{"Dockerfile", "quay.io/operator-framework/ansible-operator"},
+	{"Dockerfile", "quay.io/operator-framework/helm-operator"},
+	{"Dockerfile", "gcr.io/distroless/static"},
+	{"Dockerfile", "registry.access.redhat.com/ubi8/ubi-micro"},
+	{"Dockerfile", "golang"},
+	{consolePluginPath, "registry.access.redhat.com/ubi9/nginx-122"},
+}
+
+// moleculeDocker is an Ansible project's molecule scenario customized to test with the docker driver.
+const moleculeDocker = `---
+dependency:
+  name: galaxy
+driver:
+  name: docker
+platforms:
+  - name: operator
+    image: quay.io/operator-framework/ansible-operator:v1.31.0
+    groups:
+      - k8s
+provisioner:
+  name: ansible
+verifier:
+  name: ansible
+`
+
+const koConfig = `# Build images with ko instead of the Dockerfile.
+defaultBaseImage: cgr.dev/chainguard/static:latest
+baseImageOverrides:
+  example.com/memcached-operator/cmd/debug: gcr.io/distroless/base-debian12:debug
+`
+
+const koConfigExp = `# Build images with ko instead of the Dockerfile.
+defaultBaseImage: registry.access.redhat.com/ubi8/ubi-minimal:` + ubiMinimalVersion + `
+baseImageOverrides:
+  example.com/memcached-operator/cmd/debug: registry.access.redhat.com/ubi8/ubi-minimal:` + ubiMinimalVersion + `
+`
+
+const scorecardBasicPatch = `- op: add
+  path: /stages/0/tests/-
+  value:
+    entrypoint:
+    - scorecard-test
+    - basic-check-spec
+    image: quay.io/operator-framework/scorecard-test:v1.31.0
+    labels:
+      suite: basic
+      test: basic-check-spec-test
+`
+
+const scorecardOLMPatch = `- op: add
+  path: /stages/0/tests/-
+  value:
+    entrypoint:
+    - scorecard-test
+    - olm-bundle-validation
+    image: quay.io/operator-framework/scorecard-test:v1.31.0
+    labels:
+      suite: olm
+      test: olm-bundle-validation-test
+- op: add
+  path: /stages/0/tests/-
+  value:
+    entrypoint:
+    - scorecard-test
+    - olm-crds-have-validation
+    image: quay.io/operator-framework/scorecard-test@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef
+    labels:
+      suite: olm
+      test: olm-crds-have-validation-test
+`
+
+const proxyPatch = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: controller-manager
+  namespace: system
+spec:
+  template:
+    spec:
+      containers:
+      - name: kube-rbac-proxy
+        image: gcr.io/kubebuilder/kube-rbac-proxy:v0.5.0
+      - name: kube-rbac-proxy-latest
+        image: gcr.io/kubebuilder/kube-rbac-proxy:latest
+      - name: upstream
+        image: quay.io/brancz/kube-rbac-proxy:v0.5.0
+`
+
+const proxyPatchExp = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: controller-manager
+  namespace: system
+spec:
+  template:
+    spec:
+      containers:
+      - name: kube-rbac-proxy
+        image: registry.redhat.io/openshift4/ose-kube-rbac-proxy:v` + ocpProductVersion + `
+      - name: kube-rbac-proxy-latest
+        image: registry.redhat.io/openshift4/ose-kube-rbac-proxy:v` + ocpProductVersion + `
+      - name: upstream
+        image: quay.io/brancz/kube-rbac-proxy:v0.5.0
+`
+
+const dockerfileGoBuilder = `FROM golang:1.19 as builder
+FROM docker.io/library/golang:1.19
+FROM gcr.io/distroless/static:nonroot
+`
+
+// dockerfileARG parameterizes base images with ARG defaults, as hybrid and Ansible operators may.
+const dockerfileARG = `ARG BUILDER=golang:1.19
+ARG BASE_IMAGE="quay.io/operator-framework/ansible-operator:v1.31.0"
+ARG RUNTIME_IMAGE='gcr.io/distroless/static:nonroot'
+FROM ${BUILDER} AS builder
+FROM ${BASE_IMAGE}
+FROM ${RUNTIME_IMAGE}
+`
+
+const dockerfileARGExp = `ARG BUILDER=golang:1.21
+ARG BASE_IMAGE="registry.redhat.io/openshift4/ose-ansible-operator:v` + ocpProductVersion + `"
+ARG RUNTIME_IMAGE='registry.access.redhat.com/ubi8/ubi-minimal:` + ubiMinimalVersion + `'
+FROM ${BUILDER} AS builder
+FROM ${BASE_IMAGE}
+FROM ${RUNTIME_IMAGE}
+`
+
+// dockerfileAlias references builder stages by alias, and its base image more than once.
+const dockerfileAlias = `FROM golang:1.19 AS builder
+RUN go build -o manager main.go
+
+FROM quay.io/operator-framework/ansible-operator:v1.31.0 AS base
+COPY requirements.yml ${HOME}/requirements.yml
+
+FROM quay.io/operator-framework/ansible-operator:v1.31.0
+COPY --from=builder /workspace/manager /manager
+COPY --from=base ${HOME}/.ansible ${HOME}/.ansible
+`
+
+const dockerfileAliasExp = `FROM golang:1.21 AS builder
+RUN go build -o manager main.go
+
+FROM registry.redhat.io/openshift4/ose-ansible-operator:v` + ocpProductVersion + ` AS base
+COPY requirements.yml ${HOME}/requirements.yml
+
+FROM registry.redhat.io/openshift4/ose-ansible-operator:v` + ocpProductVersion + `
+COPY --from=builder /workspace/manager /manager
+COPY --from=base ${HOME}/.ansible ${HOME}/.ansible
+`
+
+const goMod = `module example.com/memcached-operator
+
+go 1.19
+
+require golang.org/x/net v0.17.0
+`
+
+// flakyRenameFs fails the first failures renames.
+type flakyRenameFs struct {
+	afero.Fs
+	failures int
+}
+
+func (fs *flakyRenameFs) Rename(oldname, newname string) error {
+	if fs.failures > 0 {
+		fs.failures--
+		return errors.New("device busy")
+	}
+	return fs.Fs.Rename(oldname, newname)
+}
+
+// bundleDockerfile is a bundle Dockerfile generated by "make bundle".
+const bundleDockerfile = `FROM scratch
+
+# Core bundle labels.
+LABEL operators.operatorframework.io.bundle.mediatype.v1=registry+v1
+LABEL operators.operatorframework.io.bundle.manifests.v1=manifests/
+LABEL operators.operatorframework.io.bundle.metadata.v1=metadata/
+LABEL operators.operatorframework.io.bundle.package.v1=memcached-operator
+LABEL operators.operatorframework.io.bundle.channels.v1=alpha
+LABEL operators.operatorframework.io.metrics.builder=operator-sdk-v1.31.0
+LABEL operators.operatorframework.io.metrics.mediatype.v1=metrics+v1
+LABEL operators.operatorframework.io.metrics.project_layout=go.kubebuilder.io/v4
+
+# Labels for testing.
+LABEL operators.operatorframework.io.test.mediatype.v1=scorecard+v1
+LABEL operators.operatorframework.io.test.config.v1=tests/scorecard/
+
+# Copy files to locations specified by labels.
+COPY bundle/manifests /manifests/
+COPY bundle/metadata /metadata/
+COPY bundle/tests/scorecard /tests/scorecard/
+`
+
+// BenchmarkReplaceImages compares processing hundreds of files serially and concurrently.
+func BenchmarkReplaceImages(b *testing.B) {
+	const numFiles = 500
+	opts := defaultImageOptions()
+	for i := 0; i < numFiles; i++ {
+		opts.Files = append(opts.Files, fmt.Sprintf("config/samples/sample_%03d.yaml", i))
+	}
+	content := []byte(strings.Repeat(proxyPatch, 20))
+
+	for name, workers := range map[string]int{"serial": 1, "concurrent": 0} {
+		opts := opts
+		opts.workers = workers
+		b.Run(name, func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				b.StopTimer()
+				fs := machinery.Filesystem{FS: afero.NewMemMapFs()}
+				for _, filePath := range opts.Files {
+					if err := afero.WriteFile(fs.FS, filePath, content, 0644); err != nil {
+						b.Fatal(err)
+					}
+				}
+				b.StartTimer()
+				if _, err := replaceImages(fs, opts); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -15,25 +15,328 @@
 package v1
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
-	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/spf13/afero"
+	"github.com/spf13/pflag"
 	"sigs.k8s.io/kubebuilder/v3/pkg/config"
+	"sigs.k8s.io/kubebuilder/v3/pkg/config/store"
 	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+	"sigs.k8s.io/kubebuilder/v3/pkg/plugin"
+
+	"github.com/operator-framework/operator-sdk/internal/flags"
 )
 
 const (
-	// The current OCP release version.
-	ocpProductVersion = "4.14"
-	// The currently used version of ubi8/ubi-minimal images.
-	ubiMinimalVersion = "8.8"
+	ocpVersionFlag    = "ocp-version"
+	ubiVersionFlag    = "ubi-version"
+	ubiMajorFlag      = "ubi-major"
+	archFlag          = "arch"
+	dryRunFlag        = "dry-run"
+	registryFlag      = "registry"
+	backupFlag        = "backup"
+	strictFlag        = "strict"
+	failOnNoMatchFlag = "fail-on-nomatch"
+	withSCCFlag       = "with-scc"
+
+	withConsolePluginFlag    = "with-console-plugin"
+	withOpenShiftOverlayFlag = "with-openshift-overlay"
+	withTemplateFlag         = "with-template"
+	withCSVAnnotationsFlag   = "with-csv-annotations"
+	withMirrorPolicyFlag     = "with-mirror-policy"
+	withVerifyTargetFlag     = "with-verify-target"
+	maxOCPVersionFlag        = "max-ocp-version"
+	channelFlag              = "channel"
+	noVersionWarningsFlag    = "no-version-warnings"
+	disableFlag              = "disable"
+
+	goBuilderVersionFlag = "go-builder-version"
+	noGoModEditFlag      = "no-gomod-edit"
+	fipsFlag             = "fips"
+	autoGoVersionFlag    = "auto-go-version"
+	preserveDigestsFlag  = "preserve-digests"
+	// defaultGoBuilderVersion is an example --go-builder-version value.
+	defaultGoBuilderVersion = "1.20"
+
+	rbacProxyVersionFlag = "rbac-proxy-version"
+	goBaseImageFlag      = "go-base-image"
+	imageNamespaceFlag   = "image-namespace"
+	imageNameFlag        = "image-name"
+	// imageFlagPrefix prefixes the category of each --image-<category> flag.
+	imageFlagPrefix    = "image-"
+	redHatRegistryFlag = "redhat-registry"
+	keepRegistryFlag   = "keep-registry"
+	makefileImagesFlag = "makefile-images"
+	tidyFlag           = "tidy"
+
+	substitutionsFileFlag  = "substitutions-file"
+	listSubstitutionsFlag  = "list-substitutions"
+	diffOutputFlag         = "diff-output"
+	statsFileFlag          = "stats-file"
+	outputDirFlag          = "output-dir"
+	scanDirFlag            = "scan-dir"
+	scanGlobFlag           = "scan-glob"
+	sinceFlag              = "since"
+	excludeFlag            = "exclude"
+	overlayFlag            = "overlay"
+	checkImagesFlag        = "check-images"
+	checkImagesTimeoutFlag = "check-images-timeout"
+	outputFlag             = "output"
+	reverseFlag            = "reverse"
+	upstreamTagFlag        = "upstream-tag"
+	fileFlag               = "file"
+	fromPatternFlag        = "from-pattern"
+	toImageFlag            = "to-image"
+	yamlAwareFlag          = "yaml-aware"
+	rewriteCommentsFlag    = "rewrite-comments"
+	annotateFlag           = "annotate"
+	passthroughFlag        = "passthrough"
+	projectRootFlag        = "project-root"
+	quietFlag              = "quiet"
+
+	// ocpVersionEnv and ubiVersionEnv set the --ocp-version and --ubi-version values if those flags are not given.
+	ocpVersionEnv = "OSDK_OCP_VERSION"
+	ubiVersionEnv = "OSDK_UBI_VERSION"
+	// passthroughEnv, if true, runs every subcommand in passthrough mode, as --passthrough does for init.
+	passthroughEnv = "OSDK_PASSTHROUGH"
 )
 
+var _ plugin.InitSubcommand = &initSubcommand{}
+var _ plugin.HasPostScaffold = &initSubcommand{}
+
 type initSubcommand struct {
 	config config.Config
+	// flags are the flags bound by BindFlags, used to tell which flags were given.
+	flags *pflag.FlagSet
+
+	options imageOptions
+
+	// withSCC scaffolds a SecurityContextConstraints for the controller manager.
+	withSCC bool
+	// withConsolePlugin scaffolds an OpenShift console dynamic plugin.
+	withConsolePlugin bool
+	// withOpenShiftOverlay scaffolds config/openshift as a kustomize overlay of config/default.
+	withOpenShiftOverlay bool
+	// withTemplate scaffolds an OpenShift Template that deploys the controller manager without OLM.
+	withTemplate bool
+	// withCSVAnnotations scaffolds Red Hat certified catalog annotations for the base ClusterServiceVersion.
+	withCSVAnnotations bool
+	// withMirrorPolicy scaffolds an ImageContentSourcePolicy for the mirror registry.
+	withMirrorPolicy bool
+	// withVerifyTarget adds a verify-images target to the Makefile.
+	withVerifyTarget bool
+	// preserveDigests leaves images pinned by digest unchanged. It is only applied if its flag is given.
+	preserveDigests bool
+	// maxOCPVersion is the latest OCP release the CSV annotations declare support for.
+	maxOCPVersion string
+	// ocpVersions are the OCP releases the CSV annotations declare support for, parsed from --ocp-version.
+	ocpVersions ocpVersionRange
+	// noVersionWarnings silences warnings about end of life and untested OCP releases.
+	noVersionWarnings bool
+	// autoGoVersion tags golang builder images with the go.mod go directive's version.
+	autoGoVersion bool
+	// since is the RFC 3339 time files must have been modified after to be processed.
+	since string
+	// tidy runs go mod tidy after go.mod is changed.
+	tidy bool
+	// goModTidy runs go mod tidy with --tidy. Defaults to execGoModTidy.
+	goModTidy goModTidyFunc
+	// projectRoot is the directory of the project, which paths of files and flag values are relative to.
+	// PreScaffold resolves it to the working directory if it is not given.
+	projectRoot string
+	// projectConfig is the config of the project at projectRoot if it is not the working directory,
+	// which replaces config and is saved once the plugin config is recorded in it.
+	projectConfig store.Store
+
+	// report lists the files created and modified by the last Scaffold call.
+	report ScaffoldReport
+	// scaffolded is the filesystem the last Scaffold call wrote to, which PostScaffold substitutes images in again.
+	scaffolded *recordingFs
+}
+
+func (s *initSubcommand) BindFlags(fs *pflag.FlagSet) {
+	s.flags = fs
+	fs.SortFlags = false
+	fs.StringVar(&s.options.OCPVersion, ocpVersionFlag, ocpProductVersion,
+		"OCP release version used to tag downstream (registry.redhat.io/openshift4/ose-*) images, ex. 4.14; "+
+			"with --"+withCSVAnnotationsFlag+", may be a range of supported releases, ex. 4.12-4.16, or 4.12+ "+
+			"for 4.12 and later, whose earliest release tags images; "+
+			"if not given, ocpVersion in "+localConfigPath+", then $"+ocpVersionEnv+", is used if set")
+	fs.BoolVar(&s.noVersionWarnings, noVersionWarningsFlag, false,
+		"do not warn if --"+ocpVersionFlag+" is an end of life release, older than "+minSupportedOCPVersion+
+			", or newer than the latest release tested, "+maxTestedOCPVersion)
+	fs.StringVar(&s.options.UBIVersion, ubiVersionFlag, "",
+		"version used to tag UBI (ubi-minimal, ubi-micro) base images, ex. 8.8; "+
+			"overrides the UBI version known to work with --"+ocpVersionFlag+
+			" (ex. "+ubiMinimalVersion+" for UBI 8 and "+ubi9MinimalVersion+" for UBI 9 with OCP "+ocpProductVersion+"); "+
+			"if not given, ubiVersion in "+localConfigPath+", then $"+ubiVersionEnv+", is used if set")
+	fs.IntVar(&s.options.UBIMajor, ubiMajorFlag, 8,
+		"major version of UBI base images, either 8 or 9; OpenShift operator images are not affected")
+	fs.StringVar(&s.options.GoBuilderVersion, goBuilderVersionFlag, "",
+		"Go version, ex. "+defaultGoBuilderVersion+", used to tag golang builder images in the Dockerfile; "+
+			"the go.mod go directive is raised to its minor version, but never lowered (default leave Go versions unchanged)")
+	fs.BoolVar(&s.options.NoGoModEdit, noGoModEditFlag, false,
+		"leave the go.mod go directive unchanged with --"+goBuilderVersionFlag+", for projects that manage it themselves")
+	fs.BoolVar(&s.autoGoVersion, autoGoVersionFlag, false,
+		"tag golang builder images in the Dockerfile with the Go version of the go.mod go directive, so the builder "+
+			"matches the module's language version; --"+goBuilderVersionFlag+" is used instead if go.mod cannot be read")
+	fs.BoolVar(&s.options.FIPS, fipsFlag, false,
+		"replace golang builder images with FIPS capable UBI go-toolset images, tagged with the minor version of --"+
+			goBuilderVersionFlag+" if given, otherwise of the replaced image, and enable cgo in the Dockerfile's "+
+			"Go build; the runtime base image stays "+defaultGoBaseImage+", which provides the OpenSSL libraries FIPS mode uses")
+	fs.BoolVar(&s.preserveDigests, preserveDigestsFlag, true,
+		"leave upstream images pinned by digest (@sha256:...) unchanged, since digests differ between registries; "+
+			"if false they are replaced by tagged downstream images like other images")
+	fs.StringVar(&s.options.RBACProxyVersion, rbacProxyVersionFlag, "",
+		"OCP release version used to tag the downstream kube-rbac-proxy image, ex. 4.13, "+
+			"for proxy images released separately (default the --"+ocpVersionFlag+" value)")
+	fs.StringVar(&s.options.GoBaseImage, goBaseImageFlag, "",
+		"UBI image that replaces the gcr.io/distroless/static runtime base image of Go operators, one of "+
+			strings.Join(goBaseImages, ", ")+"; builder images are not affected (default "+defaultGoBaseImage+")")
+	fs.StringVar(&s.options.Arch, archFlag, "",
+		"architecture appended to downstream OpenShift (ose-*) image tags, one of "+strings.Join(supportedArches, ", ")+
+			"; by default tags refer to multi-architecture manifest lists")
+	fs.StringVar(&s.options.Registry, registryFlag, "",
+		"registry host, ex. mirror.example.com:5000, that replaces "+redHatRegistry+" and "+redHatAccessRegistry+
+			" in downstream images; useful for disconnected environments; if not given, registry in "+localConfigPath+" is used if set")
+	fs.StringVar(&s.options.RedHatRegistry, redHatRegistryFlag, "",
+		"Red Hat registry host that downstream OpenShift (ose-*) images are pulled from, one of "+
+			strings.Join(redHatRegistries, ", ")+", ex. "+redHatConnectRegistry+" for partner-certified operators "+
+			"(default "+redHatRegistry+")")
+	fs.StringVar(&s.options.ImageNamespace, imageNamespaceFlag, "",
+		"repository namespace, ex. mirror/ocp, that replaces "+defaultImageNamespace+" in downstream OpenShift (ose-*) images, "+
+			"for mirrors that flatten Red Hat repository paths (default "+defaultImageNamespace+")")
+	fs.StringToStringVar(&s.options.ImageNames, imageNameFlag, nil,
+		"comma-separated <category>=<name> pairs, ex. "+KubeRBACProxyCategory+"=kube-rbac-proxy, of names that replace "+
+			"the names of downstream OpenShift images, for categories "+strings.Join(openShiftCategories, ", "))
+	for _, category := range Categories {
+		fs.Var(&imageOverride{images: &s.options.Images, category: category}, imageFlagPrefix+category,
+			"image, ex. mirror.example.com/"+category+":latest, that replaces every image of the "+category+
+				" substitution category instead of the computed downstream image; overrides all other image options")
+	}
+	fs.StringArrayVar(&s.options.KeepRegistries, keepRegistryFlag, nil,
+		"registry host, ex. quay.io, whose images are never replaced by any substitution, "+
+			"for upstream images referenced deliberately; images without a host are on "+dockerHubRegistry+"; may be repeated")
+	fs.BoolVar(&s.options.MakefileImages, makefileImagesFlag, false,
+		"replace tool images assigned to Makefile variables, ex. OPM_IMG ?= quay.io/operator-framework/opm:latest, "+
+			"with their OpenShift equivalents; only variable values are changed, never recipes")
+	fs.BoolVar(&s.tidy, tidyFlag, false,
+		"run \"go mod tidy\" in the project after its go.mod go directive is changed, ex. by --"+goBuilderVersionFlag+
+			", so that its requirements are reconciled with the new Go version; requires the go command")
+	fs.StringSliceVar(&s.options.Disabled, disableFlag, nil,
+		"comma-separated keys of image substitution categories to not apply, any of "+strings.Join(Categories, ", ")+
+			"; if not given, disable in "+localConfigPath+" is used if set")
+	fs.BoolVar(&s.options.dryRun, dryRunFlag, false,
+		"print the image substitutions that would be made to each file without writing them, "+
+			"exiting with "+strconv.Itoa(ExitCodeSubstitutionsNeeded)+" if any would be made and 0 if the project needs none")
+	fs.BoolVar(&s.options.quiet, quietFlag, false,
+		"print only errors: no warnings, progress, or dry-run substitutions are printed, but exit codes are unchanged; "+
+			"reports requested by --"+outputFlag+"="+jsonOutput+", --"+diffOutputFlag+", or --"+listSubstitutionsFlag+" are still printed; "+
+			"cannot be set with --"+flags.VerboseOpt)
+	fs.BoolVar(&s.options.passthrough, passthroughFlag, false,
+		"run without substituting any image, reporting no changes, so that the plugin can stay in the plugin chain of "+
+			"upstream builds; OpenShift resources requested by other flags are still scaffolded and the plugin config "+
+			"is still recorded; if not given, $"+passthroughEnv+" is used if set")
+	fs.BoolVar(&s.options.listSubstitutions, listSubstitutionsFlag, false,
+		"print the pattern and resolved replacement of each image substitution for each file, "+
+			"whether or not the file exists, then exit without changing any file")
+	fs.BoolVar(&s.options.strict, strictFlag, false,
+		"fail if a file that images are substituted in does not exist, instead of skipping it")
+	fs.BoolVar(&s.options.failOnNoMatch, failOnNoMatchFlag, false,
+		"fail, listing each image substitution of an existing file that did not match anything, "+
+			"which usually means the upstream scaffold changed; images already substituted do not match again")
+	fs.BoolVar(&s.options.yamlAware, yamlAwareFlag, false,
+		"only substitute images in the values of image fields of YAML files, leaving comments and other fields unchanged; "+
+			"other files are substituted anywhere")
+	fs.BoolVar(&s.options.rewriteComments, rewriteCommentsFlag, false,
+		"also substitute images in comments of Dockerfiles, YAML files, go.mod, and the Makefile, "+
+			"ex. images pinned by digest for provenance, except YAML comments with --"+yamlAwareFlag+"; by default comments are left unchanged")
+	fs.BoolVar(&s.options.annotate, annotateFlag, false,
+		"add a comment recording the plugin and --"+ocpVersionFlag+" to the top of each Dockerfile, YAML, and go.mod file "+
+			"images are substituted in; re-running replaces the comment")
+	fs.BoolVar(&s.options.backup, backupFlag, false,
+		"write a copy of each file to <file>"+backupSuffix+" before substituting images in it, "+
+			"which \"operator-sdk openshift rollback\" restores")
+	fs.StringVar(&s.projectRoot, projectRootFlag, "",
+		"path of the project's root directory, containing its PROJECT file, to substitute images in when not "+
+			"run from it; the paths of files and of other flag values are relative to it, and the plugin config is "+
+			"recorded in its PROJECT file (default the working directory)")
+	fs.StringVar(&s.options.outputDir, outputDirFlag, "",
+		"directory to write substituted and scaffolded files to, at their paths relative to the project root, "+
+			"instead of modifying the project in place")
+	fs.StringVar(&s.options.output, outputFlag, textOutput,
+		"format of the substitution report, one of "+strings.Join(outputFormats, ", ")+
+			"; "+jsonOutput+" prints a JSON array of {file, pattern, from, to, count} objects to stdout")
+	fs.StringVar(&s.options.diffOutput, diffOutputFlag, "",
+		"path to write a unified diff of all image substitutions to, or "+stdoutPath+" for stdout")
+	fs.StringVar(&s.options.statsFile, statsFileFlag, "",
+		"path of a file to append a line of JSON to, summarizing the files processed, total replacements, "+
+			"and replacements per category of this run, for aggregating the stats of many runs; nothing is sent over the network")
+	fs.BoolVar(&s.options.checkImages, checkImagesFlag, false,
+		"check that each downstream image that replaced an upstream image exists in its registry, "+
+			"using credentials from the Docker config file; requires network access")
+	fs.DurationVar(&s.options.checkImagesTimeout, checkImagesTimeoutFlag, defaultCheckImagesTimeout,
+		"maximum time to spend checking images with --"+checkImagesFlag)
+	fs.BoolVar(&s.withSCC, withSCCFlag, false,
+		"scaffold a SecurityContextConstraints derived from restricted-v2 for the controller manager in config/openshift")
+	fs.BoolVar(&s.withConsolePlugin, withConsolePluginFlag, false,
+		"scaffold a ConsolePlugin stub served by an nginx Deployment in config/openshift, "+
+			"for operators that ship an OpenShift console dynamic plugin")
+	fs.BoolVar(&s.withOpenShiftOverlay, withOpenShiftOverlayFlag, false,
+		"scaffold config/openshift as a kustomize overlay of config/default with the resources of --"+withSCCFlag+
+			" and --"+withConsolePluginFlag+" and service CA patches for webhooks, instead of adding it to config/default; "+
+			"deploy with \"kustomize build config/openshift\"")
+	fs.BoolVar(&s.withTemplate, withTemplateFlag, false,
+		"scaffold an OpenShift Template in config/openshift that deploys the controller manager without OLM, "+
+			"with image and namespace parameters")
+	fs.BoolVar(&s.withCSVAnnotations, withCSVAnnotationsFlag, false,
+		"scaffold a patch in config/manifests/bases adding the ClusterServiceVersion annotations required by "+
+			"the Red Hat certified catalog, with supported OCP versions from --"+ocpVersionFlag)
+	fs.StringVar(&s.maxOCPVersion, maxOCPVersionFlag, "",
+		"latest OCP release version, ex. 4.16, supported by the operator with --"+withCSVAnnotationsFlag+
+			" (default only the --"+ocpVersionFlag+" release)")
+	fs.StringVar(&s.options.Channel, channelFlag, defaultChannel,
+		"OLM channel the operator's bundle is published to, recorded in the project config and "+
+			"noted as the channel to build bundles with in the CSV patch of --"+withCSVAnnotationsFlag)
+	fs.BoolVar(&s.withMirrorPolicy, withMirrorPolicyFlag, false,
+		"scaffold an ImageContentSourcePolicy in config/openshift that mirrors the Red Hat repositories of "+
+			"substituted images to the --"+registryFlag+" host, for disconnected clusters")
+	fs.BoolVar(&s.withVerifyTarget, withVerifyTargetFlag, false,
+		"add a verify-images target to the Makefile that runs \"operator-sdk openshift verify\" "+
+			"to check that no upstream images are referenced")
+	fs.BoolVar(&s.options.scanDir, scanDirFlag, false,
+		"also substitute upstream images in files matching --"+scanGlobFlag+", such as kustomize components")
+	fs.StringSliceVar(&s.options.scanGlobs, scanGlobFlag, defaultScanGlobs,
+		"globs of files to scan for upstream images with --"+scanDirFlag+", where ** matches any number of directories")
+	fs.StringSliceVar(&s.options.excludes, excludeFlag, nil,
+		"globs of files to not scan with --"+scanDirFlag)
+	fs.StringSliceVar(&s.options.overlays, overlayFlag, nil,
+		"kustomize overlay directories, ex. config/overlays/prod, whose YAML files images are substituted in like the base config "+
+			"(default every directory in "+overlaysDir+")")
+	fs.StringVar(&s.since, sinceFlag, "",
+		"RFC 3339 time, ex. 2024-01-02T15:04:05Z, to only substitute images in files modified after, "+
+			"for incremental runs over large scanned trees; git checkouts set the modification time of the files they write "+
+			"to the time of the checkout, so every file of a fresh clone is processed")
+	fs.StringSliceVar(&s.options.Files, fileFlag, nil,
+		"comma-separated paths of additional files, relative to the project root, to substitute any upstream image in; "+
+			"recorded so that later subcommands substitute them too")
+	fs.StringVar(&s.options.substitutionsFile, substitutionsFileFlag, "",
+		"path to a YAML file containing a list of additional {path, from, to, requires} image substitutions, "+
+			"where path is a file or a glob pattern, ex. config/default/*_patch.yaml, from is a regular expression, "+
+			"and the optional requires is a regular expression that must match a file's content for the substitution to apply to it")
+	fs.StringArrayVar(&s.options.fromPatterns, fromPatternFlag, nil,
+		"regular expression matching an upstream image to replace in every file with the next --"+toImageFlag+
+			" value, for one-off substitutions; may be repeated")
+	fs.StringArrayVar(&s.options.toImages, toImageFlag, nil,
+		"image that replaces matches of the corresponding --"+fromPatternFlag+"; may be repeated")
 }
 
 func (s *initSubcommand) InjectConfig(c config.Config) error {
@@ -41,76 +344,465 @@ func (s *initSubcommand) InjectConfig(c config.Config) error {
 	return nil
 }
 
-// Scaffold updates a newly initialized project with OpenShift-specific configuration.
-func (s *initSubcommand) Scaffold(fs machinery.Filesystem) error {
-	if err := replaceImages(fs); err != nil {
+// PreScaffold validates flag values before any files are scaffolded.
+func (s *initSubcommand) PreScaffold(fs machinery.Filesystem) error {
+	if s.options.quiet {
+		logOnlyErrors(s.options.logger)
+	}
+	root, err := resolveProjectRoot(fs.FS, s.projectRoot)
+	if err != nil {
 		return err
 	}
+	if root != "." {
+		if s.projectConfig, err = loadProjectRootConfig(fs.FS, root); err != nil {
+			return err
+		}
+		s.config = s.projectConfig.Config()
+	}
+	s.projectRoot, fs = root, machinery.Filesystem{FS: newProjectRootFs(fs.FS, root)}
 
-	// Update the plugin config section with this plugin's configuration.
-	if err := s.config.EncodePluginConfig(pluginKey, Config{}); err != nil && !errors.As(err, &config.UnsupportedFieldError{}) {
-		return fmt.Errorf("error writing plugin config for %s: %v", pluginKey, err)
+	versions, resolved, err := resolveVersions(s.options.getContext())
+	if err != nil {
+		return err
+	}
+	if resolved && versions.OCPVersion != "" && !s.flagChanged(ocpVersionFlag) {
+		s.options.OCPVersion = versions.OCPVersion
+	}
+	if err := s.applyDefaults(fs.FS); err != nil {
+		return err
+	}
+	if !s.flagChanged(passthroughFlag) && passthroughFromEnv() {
+		s.options.passthrough = true
+	}
+	if s.flagChanged(preserveDigestsFlag) {
+		s.options.RewriteDigests = !s.preserveDigests
+	}
+	if s.options.OCPVersion == "" {
+		s.options.OCPVersion = ocpProductVersion
+	}
+	if s.options.UBIMajor == 0 {
+		s.options.UBIMajor = 8
+	}
+	// Images are tagged with the earliest release of a range.
+	ocpVersions, err := parseOCPVersionRange(s.options.OCPVersion)
+	if err != nil {
+		return err
+	}
+	s.options.OCPVersion, s.ocpVersions = ocpVersions.min, ocpVersions
+	if ocpVersions.isRange() && !s.withCSVAnnotations {
+		return fmt.Errorf("--%s range %q requires --%s", ocpVersionFlag, ocpVersions.annotation(), withCSVAnnotationsFlag)
+	}
+	if err := validateRBACProxyVersion(s.options.RBACProxyVersion); err != nil {
+		return err
+	}
+	if s.withMirrorPolicy && s.options.Registry == "" {
+		return fmt.Errorf("--%s requires --%s", withMirrorPolicyFlag, registryFlag)
+	}
+	if s.maxOCPVersion != "" && !s.withCSVAnnotations {
+		return fmt.Errorf("--%s requires --%s", maxOCPVersionFlag, withCSVAnnotationsFlag)
+	}
+	if s.options.Channel == "" {
+		s.options.Channel = defaultChannel
+	}
+	if err := validateChannel(s.options.Channel); err != nil {
+		return err
+	}
+	if err := validateMaxOCPVersion(s.options.OCPVersion, s.maxOCPVersion); err != nil {
+		return err
+	}
+
+	if err := validateUBIVersion(s.options.UBIMajor, s.options.UBIVersion); err != nil {
+		return err
+	}
+	if err := validateArch(s.options.Arch); err != nil {
+		return err
+	}
+	if s.autoGoVersion {
+		s.options.GoBuilderVersion = autoGoBuilderVersion(fs.FS, s.options.GoBuilderVersion, s.options.getLogger())
+	}
+	if err := validateGoBuilderVersion(s.options.GoBuilderVersion); err != nil {
+		return err
+	}
+	if err := validateGoBaseImage(s.options.GoBaseImage); err != nil {
+		return err
+	}
+	if err := validateRegistry(s.options.Registry); err != nil {
+		return err
+	}
+	if err := validateKeepRegistries(s.options.KeepRegistries); err != nil {
+		return err
+	}
+	if err := validateRedHatRegistry(s.options.RedHatRegistry, s.options.Registry); err != nil {
+		return err
+	}
+	if err := validateImageNamespace(s.options.ImageNamespace); err != nil {
+		return err
+	}
+	if err := validateImageNames(s.options.ImageNames); err != nil {
+		return err
+	}
+	if err := validateImages(s.options.Images); err != nil {
+		return err
+	}
+	if err := validateCategories(s.options.Disabled); err != nil {
+		return err
+	}
+	if err := checkFlagConflicts(s.flagConflicts()); err != nil {
+		return err
+	}
+	if s.maxOCPVersion != "" {
+		s.ocpVersions.max = s.maxOCPVersion
+	}
+	if !s.noVersionWarnings {
+		for _, version := range []string{s.ocpVersions.min, s.ocpVersions.max} {
+			if version == "" {
+				continue
+			}
+			if warning := ocpVersionWarning(version); warning != "" {
+				s.options.getLogger().Warn(warning)
+			}
+		}
+	}
+	if err := validateFiles(s.options.Files); err != nil {
+		return err
+	}
+	for i, filePath := range s.options.Files {
+		s.options.Files[i] = filepath.Clean(filePath)
+	}
+	if s.options.output == "" {
+		s.options.output = textOutput
+	}
+	if err := validateOutput(s.options.output); err != nil {
+		return err
+	}
+	if err := validateOutputDir(s.options.outputDir); err != nil {
+		return err
+	}
+	if s.options.output == jsonOutput && s.options.diffOutput == stdoutPath {
+		return fmt.Errorf("--%s=%s cannot be written to stdout with --%s=%s", diffOutputFlag, stdoutPath, outputFlag, jsonOutput)
+	}
+	if _, err := compileGlobs(scanGlobFlag, s.options.scanGlobs); err != nil {
+		return err
+	}
+	if _, err := compileGlobs(excludeFlag, s.options.excludes); err != nil {
+		return err
+	}
+	if s.since != "" {
+		since, err := time.Parse(time.RFC3339, s.since)
+		if err != nil {
+			return fmt.Errorf("invalid --%s value %q: must be an RFC 3339 time, ex. 2024-01-02T15:04:05Z", sinceFlag, s.since)
+		}
+		s.options.since = since
+	}
+	if s.options.UBIVersion == "" {
+		s.options.UBIVersion = versions.ubiVersionFor(s.options.OCPVersion, s.options.UBIMajor)
+	}
+
+	s.options.authProxyOptional = mayOmitAuthProxy(s.config)
+	s.options.ProjectType = projectType(s.config)
+
+	adHoc, err := compileAdHocSubstitutions(s.options.fromPatterns, s.options.toImages)
+	if err != nil {
+		return err
+	}
+	s.options.adHocSubstitutions = adHoc
+
+	if s.options.substitutionsFile != "" {
+		substs, err := loadSubstitutionsFile(fs.FS, s.options.substitutionsFile)
+		if err != nil {
+			return err
+		}
+		s.options.extraSubstitutions = substs
 	}
 
+	// Reject a config that Scaffold could not record before any file is written.
+	return newConfig(s.options).Validate()
+}
+
+// applyDefaults sets the values of flags that were not given from the local config file in fs,
+// or, for the OCP and UBI versions, the environment. Values are resolved in order of precedence
+// from flags, then the local config file, then the environment, then defaults.
+func (s *initSubcommand) applyDefaults(fs afero.Fs) error {
+	if v, ok := os.LookupEnv(ocpVersionEnv); ok && !s.flagChanged(ocpVersionFlag) {
+		s.options.OCPVersion = v
+	}
+	if v, ok := os.LookupEnv(ubiVersionEnv); ok && !s.flagChanged(ubiVersionFlag) {
+		s.options.UBIVersion = v
+	}
+
+	local, err := loadLocalConfig(fs)
+	if err != nil {
+		return err
+	}
+	if local.OCPVersion != "" && !s.flagChanged(ocpVersionFlag) {
+		s.options.OCPVersion = local.OCPVersion
+	}
+	if local.UBIVersion != "" && !s.flagChanged(ubiVersionFlag) {
+		s.options.UBIVersion = local.UBIVersion
+	}
+	if local.Registry != "" && !s.flagChanged(registryFlag) {
+		s.options.Registry = local.Registry
+	}
+	if local.Disable != nil && !s.flagChanged(disableFlag) {
+		s.options.Disabled = local.Disable
+	}
 	return nil
 }
 
-type substitution struct {
-	fromTagRE *regexp.Regexp
-	toTag     string
+// flagChanged reports whether the flag name was given.
+func (s *initSubcommand) flagChanged(name string) bool {
+	return s.flags != nil && s.flags.Changed(name)
+}
+
+// imageOverride is the value of an --image-<category> flag, which sets category's image in images.
+type imageOverride struct {
+	images   *map[string]string
+	category string
 }
 
-// imageSubstitutions is a map of paths to image substitutions.
-var imageSubstitutions = map[string][]substitution{
-	filepath.Join("config", "default", "manager_auth_proxy_patch.yaml"): {
-		{
-			regexp.MustCompile(`gcr.io/kubebuilder/kube-rbac-proxy:[^ \n]+`),
-			"registry.redhat.io/openshift4/ose-kube-rbac-proxy:v" + ocpProductVersion,
-		},
-	},
-	filepath.Join("Dockerfile"): {
-		// Ansible
-		{
-			regexp.MustCompile(`quay.io/operator-framework/ansible-operator:[^ \n]+`),
-			"registry.redhat.io/openshift4/ose-ansible-operator:v" + ocpProductVersion,
-		},
-		// Helm
-		{
-			regexp.MustCompile(`quay.io/operator-framework/helm-operator:[^ \n]+`),
-			"registry.redhat.io/openshift4/ose-helm-operator:v" + ocpProductVersion,
-		},
-		// Go
-		{
-			regexp.MustCompile(`gcr.io/distroless/static:[^ \n]+`),
-			"registry.access.redhat.com/ubi8/ubi-minimal:" + ubiMinimalVersion,
-		},
-		// Hybrid Helm
-		{
-			regexp.MustCompile(`registry.access.redhat.com/ubi8/ubi-micro:[^ \n]+`),
-			"registry.access.redhat.com/ubi8/ubi-micro:" + ubiMinimalVersion,
-		},
-	},
+func (v *imageOverride) String() string {
+	if v.images == nil {
+		return ""
+	}
+	return (*v.images)[v.category]
 }
 
-// replaceImages replaces upstream images with their downstream (OpenShift) equivalents.
-func replaceImages(fs machinery.Filesystem) error {
+func (v *imageOverride) Set(image string) error {
+	if *v.images == nil {
+		*v.images = map[string]string{}
+	}
+	(*v.images)[v.category] = image
+	return nil
+}
 
-	for filePath, substitutions := range imageSubstitutions {
-		b, err := afero.ReadFile(fs.FS, filePath)
-		if err != nil {
-			return fmt.Errorf("error reading file for substitution: %v", err)
+func (v *imageOverride) Type() string {
+	return "string"
+}
+
+// Report returns the files created and modified by the last Scaffold call and the PostScaffold
+// call after it, including those written before an error was returned. Dry runs write no files.
+func (s *initSubcommand) Report() ScaffoldReport {
+	return s.report
+}
+
+// Scaffold updates a newly initialized project with OpenShift-specific configuration.
+func (s *initSubcommand) Scaffold(fs machinery.Filesystem) error {
+	fs = machinery.Filesystem{FS: newProjectRootFs(fs.FS, s.projectRoot)}
+	if s.options.listSubstitutions {
+		return listSubstitutions(s.options.getOut(), fs.FS, s.options)
+	}
+	// The stats file is not part of the project, so it is not written to --output-dir.
+	projectFs := fs.FS
+	if s.options.outputDir != "" {
+		fs = machinery.Filesystem{FS: newOutputDirFs(fs.FS, s.options.outputDir)}
+	}
+	rfs := newRecordingFs(fs.FS)
+	fs = machinery.Filesystem{FS: rfs}
+	s.scaffolded = rfs
+	defer func() { s.report = rfs.report() }()
+
+	// OpenShift config is scaffolded first so that images it contains are substituted.
+	if s.withSCC || s.withConsolePlugin || s.withOpenShiftOverlay {
+		if s.options.dryRun {
+			s.options.getLogger().Infof("Skipping OpenShift config scaffolding in dry-run mode")
+		} else if err := scaffoldOpenShiftConfig(fs, s.config, s.withSCC, s.withConsolePlugin, s.withOpenShiftOverlay); err != nil {
+			return err
 		}
-		info, err := fs.FS.Stat(filePath)
-		if err != nil {
-			return fmt.Errorf("error reading file info for substitution: %v", err)
+	}
+	if s.withTemplate {
+		if s.options.dryRun {
+			s.options.getLogger().Infof("Skipping OpenShift Template scaffolding in dry-run mode")
+		} else if err := scaffoldTemplate(fs, s.config); err != nil {
+			return err
+		}
+	}
+	if s.withCSVAnnotations {
+		if s.options.dryRun {
+			s.options.getLogger().Infof("Skipping CSV annotations scaffolding in dry-run mode")
+		} else if err := scaffoldCSVAnnotations(fs, s.config, s.ocpVersions, s.options.Channel); err != nil {
+			return err
+		}
+	}
+
+	opts := s.options
+	var diff bytes.Buffer
+	if opts.diffOutput != "" {
+		opts.diff = &diff
+	}
+	results, err := replaceImages(fs, opts)
+	if err != nil {
+		return err
+	}
+	if opts.dryRun {
+		s.options.getLogger().Infof("Skipping transformers in dry-run mode")
+	} else if opts.passthrough {
+		s.options.getLogger().Infof("Skipping transformers in passthrough mode")
+	} else if err := runTransformers(fs); err != nil {
+		return err
+	}
+	if opts.diffOutput != "" {
+		if err := writeDiffOutput(fs.FS, opts.diffOutput, diff.Bytes(), opts.getOut()); err != nil {
+			return err
 		}
-		for _, subst := range substitutions {
-			b = subst.fromTagRE.ReplaceAll(b, []byte(subst.toTag))
+	}
+	if opts.FIPS {
+		if opts.dryRun {
+			s.options.getLogger().Infof("Skipping FIPS build settings in dry-run mode")
+		} else if opts.passthrough {
+			s.options.getLogger().Infof("Skipping FIPS build settings in passthrough mode")
+		} else if err := addFIPSBuildSettings(fs.FS); err != nil {
+			return err
 		}
-		if err = afero.WriteFile(fs.FS, filePath, b, info.Mode()); err != nil {
+	}
+	// Record a bumped builder version so that later substitutions do not lower it again.
+	if bumped, err := checkGoBuilderVersion(fs.FS, opts); err != nil {
+		return err
+	} else if bumped != "" {
+		s.options.GoBuilderVersion = bumped
+	}
+	if s.withMirrorPolicy {
+		if opts.dryRun {
+			s.options.getLogger().Infof("Skipping ImageContentSourcePolicy scaffolding in dry-run mode")
+		} else if opts.passthrough {
+			s.options.getLogger().Infof("Skipping ImageContentSourcePolicy scaffolding in passthrough mode")
+		} else if err := scaffoldMirrorPolicy(fs, s.config, opts.Options, results); err != nil {
 			return err
 		}
 	}
+	if s.withVerifyTarget {
+		if opts.dryRun {
+			s.options.getLogger().Infof("Skipping verify-images target in dry-run mode")
+		} else if opts.passthrough {
+			s.options.getLogger().Infof("Skipping verify-images target in passthrough mode")
+		} else if err := addVerifyImagesTarget(fs.FS); err != nil {
+			return err
+		}
+	}
+	if opts.checkImages {
+		if err := checkImages(opts.getContext(), results, opts.imageExists, opts.checkImagesTimeout); err != nil {
+			return err
+		}
+	}
+	if opts.output == jsonOutput {
+		if err := writeJSONReport(opts.getOut(), results); err != nil {
+			return err
+		}
+	}
+	if opts.statsFile != "" {
+		if err := appendStats(projectFs, opts.statsFile, newStatsRecord(s.config.GetProjectName(), opts.dryRun, results)); err != nil {
+			return err
+		}
+	}
+	// Every substitution is applied to additional files, so only warn if none matched.
+	// Ad-hoc substitutions are applied to every file, and substitutions of path patterns to every
+	// file they match, so only warn if they matched nothing.
+	type globSubst struct{ glob, pattern string }
+	fileCounts, adHocCounts, globCounts := map[string]int{}, map[string]int{}, map[globSubst]int{}
+	for _, subst := range opts.adHocSubstitutions {
+		adHocCounts[subst.FromTagRE.String()] = 0
+	}
+	for filePath, substs := range opts.extraSubstitutions {
+		for _, subst := range substs {
+			if isGlob(filePath) {
+				globCounts[globSubst{filePath, subst.FromTagRE.String()}] = 0
+			}
+		}
+	}
+	for _, result := range results {
+		fileCounts[result.Path] += result.Count
+		if _, ok := adHocCounts[result.Pattern]; ok {
+			adHocCounts[result.Pattern] += result.Count
+		} else if glob, ok := globPattern(opts.extraSubstitutions, result.Path, result.Pattern); ok {
+			globCounts[globSubst{glob, result.Pattern}] += result.Count
+		}
+	}
+	var unmatched []string
+	for _, result := range results {
+		if contains(opts.Files, result.Path) || rarelyMatches(result.Path) {
+			continue
+		}
+		if _, ok := adHocCounts[result.Pattern]; ok {
+			continue
+		}
+		if _, ok := globPattern(opts.extraSubstitutions, result.Path, result.Pattern); ok {
+			continue
+		}
+		if result.Count == 0 {
+			s.options.getLogger().Warnf("Image substitution %q did not match anything in %s", result.Pattern, result.Path)
+			unmatched = append(unmatched, fmt.Sprintf("%s: %s", result.Path, result.Pattern))
+		}
+	}
+	for _, filePath := range opts.Files {
+		if count, ok := fileCounts[filePath]; ok && count == 0 {
+			s.options.getLogger().Warnf("No image substitution matched anything in %s", filePath)
+		}
+	}
+	for _, subst := range opts.adHocSubstitutions {
+		if pattern := subst.FromTagRE.String(); adHocCounts[pattern] == 0 {
+			s.options.getLogger().Warnf("--%s %q did not match anything", fromPatternFlag, pattern)
+		}
+	}
+	globSubsts := make([]globSubst, 0, len(globCounts))
+	for subst := range globCounts {
+		globSubsts = append(globSubsts, subst)
+	}
+	sort.Slice(globSubsts, func(i, j int) bool {
+		if globSubsts[i].glob != globSubsts[j].glob {
+			return globSubsts[i].glob < globSubsts[j].glob
+		}
+		return globSubsts[i].pattern < globSubsts[j].pattern
+	})
+	for _, subst := range globSubsts {
+		if globCounts[subst] == 0 {
+			s.options.getLogger().Warnf("Image substitution %q did not match anything in files matching %s", subst.pattern, subst.glob)
+			unmatched = append(unmatched, fmt.Sprintf("%s: %s", subst.glob, subst.pattern))
+		}
+	}
+	if opts.failOnNoMatch && len(unmatched) != 0 {
+		return fmt.Errorf("error substituting images, substitutions did not match anything:\n%s", strings.Join(unmatched, "\n"))
+	}
+	if n := totalCount(results); opts.dryRun && n > 0 {
+		return &ExitError{Code: ExitCodeSubstitutionsNeeded, Err: fmt.Errorf("dry run found %d image substitutions to make", n)}
+	}
+
+	// Update the plugin config section with this plugin's configuration.
+	if err := s.config.EncodePluginConfig(pluginKey, newConfig(s.options)); err != nil && !errors.As(err, &config.UnsupportedFieldError{}) {
+		return fmt.Errorf("error writing plugin config for %s: %v", pluginKey, err)
+	}
 
+	return saveProjectRootConfig(s.projectConfig)
+}
+
+// PostScaffold substitutes images again in files that plugins after this one in the plugin chain
+// rewrote after Scaffold, since their Scaffold runs later, so that substitutions are the final pass.
+// Substitutions do not match images already substituted, so files left unchanged are not written.
+func (s *initSubcommand) PostScaffold() error {
+	if s.scaffolded == nil || s.options.dryRun {
+		return nil
+	}
+	defer func() { s.report = s.scaffolded.report() }()
+
+	// Passthrough runs change no file, so files rewritten by later plugins are left as they are too.
+	if later := pluginsAfter(s.config); len(later) != 0 && !s.options.passthrough {
+		// Backups, diffs, and reports describe the changes Scaffold made, so they are not repeated.
+		opts := s.options
+		opts.backup, opts.checkImages, opts.output = false, false, textOutput
+		results, err := replaceImages(machinery.Filesystem{FS: s.scaffolded}, opts)
+		if err != nil {
+			return err
+		}
+		if opts.FIPS {
+			if err := addFIPSBuildSettings(s.scaffolded); err != nil {
+				return err
+			}
+		}
+		if n := totalCount(results); n > 0 {
+			s.options.getLogger().Infof("Substituted %d images again in files rewritten by %s", n, strings.Join(later, ", "))
+		}
+	}
+	// go.mod is tidied last, once no later substitution changes it again.
+	if s.tidy {
+		return s.tidyGoMod()
+	}
 	return nil
 }
@@ -15,113 +15,649 @@
 package v1
 
 import (
+	"bytes"
+	"os"
+	"time"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	log "github.com/sirupsen/logrus"
+	cfgv3 "sigs.k8s.io/kubebuilder/v3/pkg/config/v3"
 	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
 
 	"github.com/spf13/afero"
+	"github.com/spf13/pflag"
 )
 
 var _ = Describe("RunInit", func() {
 
-	Describe("replaceImages", func() {
+	Describe("PreScaffold", func() {
 		var (
-			fs machinery.Filesystem
-
-			dockerfilePath = "Dockerfile"
-			proxyPatchPath = "config/default/manager_auth_proxy_patch.yaml"
+			fs    machinery.Filesystem
+			s     *initSubcommand
+			flags *pflag.FlagSet
 		)
 
 		BeforeEach(func() {
 			fs = machinery.Filesystem{FS: afero.NewMemMapFs()}
+			s = &initSubcommand{}
+			flags = pflag.NewFlagSet("init", pflag.ContinueOnError)
+			s.BindFlags(flags)
+			Expect(os.Setenv(ocpVersionEnv, "4.13")).To(Succeed())
+			Expect(os.Setenv(ubiVersionEnv, "8.7")).To(Succeed())
+		})
+
+		AfterEach(func() {
+			Expect(os.Unsetenv(ocpVersionEnv)).To(Succeed())
+			Expect(os.Unsetenv(ubiVersionEnv)).To(Succeed())
+		})
+
+		It("resolves versions from the environment if their flags are not given", func() {
+			Expect(flags.Parse(nil)).To(Succeed())
+			Expect(s.PreScaffold(fs)).To(Succeed())
+			Expect(s.options.OCPVersion).To(Equal("4.13"))
+			Expect(s.options.UBIVersion).To(Equal("8.7"))
+		})
+
+		It("prefers flags over the environment", func() {
+			Expect(flags.Parse([]string{"--" + ocpVersionFlag, "4.15", "--" + ubiVersionFlag, "8.9"})).To(Succeed())
+			Expect(s.PreScaffold(fs)).To(Succeed())
+			Expect(s.options.OCPVersion).To(Equal("4.15"))
+			Expect(s.options.UBIVersion).To(Equal("8.9"))
+		})
+
+		It("falls back to the defaults if neither flags nor the environment are set", func() {
+			Expect(os.Unsetenv(ocpVersionEnv)).To(Succeed())
+			Expect(os.Unsetenv(ubiVersionEnv)).To(Succeed())
+			Expect(flags.Parse(nil)).To(Succeed())
+			Expect(s.PreScaffold(fs)).To(Succeed())
+			Expect(s.options.OCPVersion).To(Equal(ocpProductVersion))
+			Expect(s.options.UBIVersion).To(Equal(ubiMinimalVersion))
+		})
+
+		It("replaces images pinned by digest only if --preserve-digests=false is given", func() {
+			Expect(flags.Parse(nil)).To(Succeed())
+			Expect(s.PreScaffold(fs)).To(Succeed())
+			Expect(s.options.RewriteDigests).To(BeFalse())
+			Expect(flags.Parse([]string{"--" + preserveDigestsFlag + "=false"})).To(Succeed())
+			Expect(s.PreScaffold(fs)).To(Succeed())
+			Expect(s.options.RewriteDigests).To(BeTrue())
+		})
+
+		It("warns about end of life and untested OCP releases unless --no-version-warnings is given", func() {
+			cases := []struct {
+				args []string
+				warn bool
+			}{
+				{[]string{"--" + ocpVersionFlag, "4.10"}, true},
+				{[]string{"--" + ocpVersionFlag, "4.99"}, true},
+				{[]string{"--" + ocpVersionFlag, "4.14"}, false},
+				{[]string{"--" + ocpVersionFlag, "4.10", "--" + noVersionWarningsFlag}, false},
+			}
+			for _, c := range cases {
+				logOut := &bytes.Buffer{}
+				logger := log.New()
+				logger.SetOutput(logOut)
+				s := &initSubcommand{options: imageOptions{logger: log.NewEntry(logger)}}
+				flags := pflag.NewFlagSet("init", pflag.ContinueOnError)
+				s.BindFlags(flags)
+				Expect(flags.Parse(c.args)).To(Succeed())
+				Expect(s.PreScaffold(fs)).To(Succeed())
+				if c.warn {
+					Expect(logOut.String()).To(ContainSubstring("level=warning"), "%v", c.args)
+				} else {
+					Expect(logOut.String()).To(BeEmpty(), "%v", c.args)
+				}
+			}
 		})
 
-		It("substitutes all images correctly", func() {
-			Expect(afero.WriteFile(fs.FS, dockerfilePath, []byte(dockerfileAll), 0644)).To(Succeed())
-			Expect(afero.WriteFile(fs.FS, proxyPatchPath, []byte(proxyPatch), 0644)).To(Succeed())
-			Expect(replaceImages(fs)).To(Succeed())
-			dockerfileOut, err := afero.ReadFile(fs.FS, dockerfilePath)
+		It("parses --since as an RFC 3339 time", func() {
+			Expect(flags.Parse([]string{"--" + sinceFlag, "2024-01-02T15:04:05Z"})).To(Succeed())
+			Expect(s.PreScaffold(fs)).To(Succeed())
+			Expect(s.options.since).To(Equal(time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)))
+
+			s = &initSubcommand{}
+			flags = pflag.NewFlagSet("init", pflag.ContinueOnError)
+			s.BindFlags(flags)
+			Expect(flags.Parse([]string{"--" + sinceFlag, "2024-01-02"})).To(Succeed())
+			Expect(s.PreScaffold(fs)).To(MatchError(ContainSubstring("invalid --" + sinceFlag + " value")))
+		})
+
+		It("validates versions from the environment", func() {
+			Expect(os.Setenv(ocpVersionEnv, "v4.13")).To(Succeed())
+			Expect(flags.Parse(nil)).To(Succeed())
+			Expect(s.PreScaffold(fs)).To(MatchError(ContainSubstring("--" + ocpVersionFlag)))
+		})
+	})
+
+	Describe("Scaffold", func() {
+		It("leaves images of kept registries unchanged in every substitution", func() {
+			fs := machinery.Filesystem{FS: afero.NewMemMapFs()}
+			const dockerfile = "FROM quay.io/operator-framework/helm-operator:v1.31.0\nFROM quay.io/example/sidecar:v1\n"
+			Expect(afero.WriteFile(fs.FS, "Dockerfile", []byte(dockerfile), 0644)).To(Succeed())
+
+			s := &initSubcommand{}
+			flags := pflag.NewFlagSet("init", pflag.ContinueOnError)
+			s.BindFlags(flags)
+			Expect(flags.Parse([]string{
+				"--" + keepRegistryFlag, "quay.io",
+				"--" + fromPatternFlag, `quay.io/example/sidecar:v1`, "--" + toImageFlag, "mirror.example.com/sidecar:v1",
+			})).To(Succeed())
+			Expect(s.InjectConfig(cfgv3.New())).To(Succeed())
+			Expect(s.PreScaffold(fs)).To(Succeed())
+			Expect(s.Scaffold(fs)).To(Succeed())
+
+			b, err := afero.ReadFile(fs.FS, "Dockerfile")
 			Expect(err).NotTo(HaveOccurred())
-			Expect(string(dockerfileOut)).To(ContainSubstring(dockerfileAllExp), "Dockerfile match")
-			proxyPatchOut, err := afero.ReadFile(fs.FS, proxyPatchPath)
+			Expect(string(b)).To(Equal(dockerfile))
+			cfg, err := decodeConfig(s.config)
 			Expect(err).NotTo(HaveOccurred())
-			Expect(string(proxyPatchOut)).To(ContainSubstring(proxyPatchExp), "manager_auth_proxy_patch.yaml match")
+			Expect(cfg.KeepRegistries).To(Equal([]string{"quay.io"}))
 		})
-	})
-})
 
-const dockerfileAll = `FROM foo:bar
+		It("replaces the images of categories given --image-<category> flags", func() {
+			fs := machinery.Filesystem{FS: afero.NewMemMapFs()}
+			const dockerfile = "FROM quay.io/operator-framework/helm-operator:v1.31.0\nFROM gcr.io/distroless/static:nonroot\n"
+			Expect(afero.WriteFile(fs.FS, "Dockerfile", []byte(dockerfile), 0644)).To(Succeed())
 
-FROM quay.io/operator-framework/ansible-operator:v1.2.3
-FROM quay.io/operator-framework/ansible-operator:v1.2
-FROM quay.io/operator-framework/ansible-operator:latest
-FROM quay.io/operator-framework/ansible:latest
-FROM foo/ansible-operator:latest
+			s := &initSubcommand{}
+			flags := pflag.NewFlagSet("init", pflag.ContinueOnError)
+			s.BindFlags(flags)
+			Expect(flags.Parse([]string{"--" + imageFlagPrefix + "go-runtime", "mirror/foo:bar"})).To(
+				MatchError(ContainSubstring("unknown flag")))
+			Expect(flags.Parse([]string{"--" + imageFlagPrefix + UBIMinimalCategory, "mirror.example.com/foo:bar"})).To(Succeed())
+			Expect(s.InjectConfig(cfgv3.New())).To(Succeed())
+			Expect(s.PreScaffold(fs)).To(Succeed())
+			Expect(s.Scaffold(fs)).To(Succeed())
 
-FROM quay.io/operator-framework/helm-operator:v1.2.3
-FROM quay.io/operator-framework/helm-operator:v1.2
-FROM quay.io/operator-framework/helm-operator:latest
-FROM quay.io/operator-framework/helm:latest
-FROM foo/helm-operator:latest
+			b, err := afero.ReadFile(fs.FS, "Dockerfile")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(Equal("FROM registry.redhat.io/openshift4/ose-helm-operator:v" + ocpProductVersion + "\n" +
+				"FROM mirror.example.com/foo:bar\n"))
+			cfg, err := decodeConfig(s.config)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Images).To(Equal(map[string]string{UBIMinimalCategory: "mirror.example.com/foo:bar"}))
+		})
 
-FROM gcr.io/distroless/static:nonroot
-FROM gcr.io/distroless/static:latest
-FROM distroless/static:latest
+		It("writes files to --output-dir instead of modifying the project", func() {
+			fs := machinery.Filesystem{FS: afero.NewMemMapFs()}
+			Expect(afero.WriteFile(fs.FS, "Dockerfile", []byte(dockerfileAll), 0600)).To(Succeed())
+			Expect(afero.WriteFile(fs.FS, "config/default/kustomization.yaml", []byte(defaultKustomization), 0644)).To(Succeed())
+			Expect(afero.WriteFile(fs.FS, authProxyPatchPath, []byte(proxyPatch), 0644)).To(Succeed())
 
-FROM registry.access.redhat.com/ubi8/ubi-micro:8.1
-`
+			c := cfgv3.New()
+			Expect(c.SetProjectName("memcached-operator")).To(Succeed())
+			s := &initSubcommand{withSCC: true, options: imageOptions{outputDir: "out"}}
+			Expect(s.InjectConfig(c)).To(Succeed())
+			Expect(s.PreScaffold(fs)).To(Succeed())
+			Expect(s.Scaffold(fs)).To(Succeed())
 
-const dockerfileAllExp = `FROM foo:bar
+			b, err := afero.ReadFile(fs.FS, "Dockerfile")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(Equal(dockerfileAll))
+			b, err = afero.ReadFile(fs.FS, "config/default/kustomization.yaml")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(Equal(defaultKustomization))
+			Expect(afero.Exists(fs.FS, "config/openshift/scc.yaml")).To(BeFalse())
 
-FROM registry.redhat.io/openshift4/ose-ansible-operator:v` + ocpProductVersion + `
-FROM registry.redhat.io/openshift4/ose-ansible-operator:v` + ocpProductVersion + `
-FROM registry.redhat.io/openshift4/ose-ansible-operator:v` + ocpProductVersion + `
-FROM quay.io/operator-framework/ansible:latest
-FROM foo/ansible-operator:latest
+			b, err = afero.ReadFile(fs.FS, "out/Dockerfile")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(ContainSubstring(dockerfileAllExp))
+			info, err := fs.FS.Stat("out/Dockerfile")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+			b, err = afero.ReadFile(fs.FS, "out/"+authProxyPatchPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(ContainSubstring(proxyPatchExp))
+			b, err = afero.ReadFile(fs.FS, "out/config/default/kustomization.yaml")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(ContainSubstring("- ../openshift\n"))
+			Expect(afero.Exists(fs.FS, "out/config/openshift/scc.yaml")).To(BeTrue())
+			Expect(s.Report().Created).To(ContainElement("config/openshift/scc.yaml"))
+			Expect(s.Report().Modified).To(ContainElement("Dockerfile"))
+		})
 
-FROM registry.redhat.io/openshift4/ose-helm-operator:v` + ocpProductVersion + `
-FROM registry.redhat.io/openshift4/ose-helm-operator:v` + ocpProductVersion + `
-FROM registry.redhat.io/openshift4/ose-helm-operator:v` + ocpProductVersion + `
-FROM quay.io/operator-framework/helm:latest
-FROM foo/helm-operator:latest
+		It("rejects the project root as --output-dir", func() {
+			s := &initSubcommand{options: imageOptions{outputDir: "./"}}
+			Expect(s.PreScaffold(machinery.Filesystem{FS: afero.NewMemMapFs()})).To(MatchError(ContainSubstring("--" + outputDirFlag)))
+		})
 
-FROM registry.access.redhat.com/ubi8/ubi-minimal:` + ubiMinimalVersion + `
-FROM registry.access.redhat.com/ubi8/ubi-minimal:` + ubiMinimalVersion + `
-FROM distroless/static:latest
+		It("rejects a malformed --ubi-version before writing files", func() {
+			fs := machinery.Filesystem{FS: afero.NewMemMapFs()}
+			Expect(afero.WriteFile(fs.FS, "Dockerfile", []byte(dockerfileAll), 0644)).To(Succeed())
+			s := &initSubcommand{}
+			flags := pflag.NewFlagSet("init", pflag.ContinueOnError)
+			s.BindFlags(flags)
+			Expect(flags.Parse([]string{"--" + ubiVersionFlag, "8.8-1032"})).To(Succeed())
+			Expect(s.InjectConfig(cfgv3.New())).To(Succeed())
+			Expect(s.PreScaffold(fs)).To(MatchError(ContainSubstring("--" + ubiVersionFlag)))
 
-FROM registry.access.redhat.com/ubi8/ubi-micro:` + ubiMinimalVersion + `
-`
+			b, err := afero.ReadFile(fs.FS, "Dockerfile")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(Equal(dockerfileAll))
+		})
 
-const proxyPatch = `apiVersion: apps/v1
-kind: Deployment
-metadata:
-  name: controller-manager
-  namespace: system
-spec:
-  template:
-    spec:
-      containers:
-      - name: kube-rbac-proxy
-        image: gcr.io/kubebuilder/kube-rbac-proxy:v0.5.0
-      - name: kube-rbac-proxy-latest
-        image: gcr.io/kubebuilder/kube-rbac-proxy:latest
-      - name: upstream
-        image: quay.io/brancz/kube-rbac-proxy:v0.5.0
-`
+		It("records the channel, which defaults to stable", func() {
+			fs := machinery.Filesystem{FS: afero.NewMemMapFs()}
+			cases := []struct {
+				args    []string
+				channel string
+			}{
+				{nil, defaultChannel},
+				{[]string{"--" + channelFlag, "fast"}, "fast"},
+			}
+			for _, c := range cases {
+				s := &initSubcommand{}
+				flags := pflag.NewFlagSet("init", pflag.ContinueOnError)
+				s.BindFlags(flags)
+				Expect(flags.Parse(c.args)).To(Succeed())
+				Expect(s.InjectConfig(cfgv3.New())).To(Succeed())
+				Expect(s.PreScaffold(fs)).To(Succeed())
+				Expect(s.Scaffold(fs)).To(Succeed())
+				cfg, err := decodeConfig(s.config)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(cfg.Channel).To(Equal(c.channel))
+			}
+		})
+
+		It("applies ad-hoc substitutions to every file", func() {
+			fs := machinery.Filesystem{FS: afero.NewMemMapFs()}
+			Expect(afero.WriteFile(fs.FS, "Dockerfile", []byte("FROM example.com/vendored/base:v1\n"), 0644)).To(Succeed())
+			Expect(afero.WriteFile(fs.FS, authProxyPatchPath, []byte("image: example.com/vendored/proxy:v1\n"), 0644)).To(Succeed())
+			logOut := &bytes.Buffer{}
+			logger := log.New()
+			logger.SetOutput(logOut)
+
+			s := &initSubcommand{options: imageOptions{logger: log.NewEntry(logger)}}
+			flags := pflag.NewFlagSet("init", pflag.ContinueOnError)
+			s.BindFlags(flags)
+			Expect(flags.Parse([]string{
+				"--" + fromPatternFlag, `example.com/vendored/([a-z]+):v1`, "--" + toImageFlag, "mirror.example.com/$1:v2",
+				"--" + fromPatternFlag, `example.com/unused`, "--" + toImageFlag, "mirror.example.com/unused",
+			})).To(Succeed())
+			Expect(s.InjectConfig(cfgv3.New())).To(Succeed())
+			Expect(s.PreScaffold(fs)).To(Succeed())
+			Expect(s.Scaffold(fs)).To(Succeed())
+
+			b, err := afero.ReadFile(fs.FS, "Dockerfile")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(Equal("FROM mirror.example.com/base:v2\n"))
+			b, err = afero.ReadFile(fs.FS, authProxyPatchPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(Equal("image: mirror.example.com/proxy:v2\n"))
+			Expect(logOut.String()).To(ContainSubstring(`--` + fromPatternFlag + ` \"example.com/unused\" did not match anything`))
+			Expect(logOut.String()).NotTo(ContainSubstring("vendored"))
+		})
+
+		It("fails with --fail-on-nomatch if a built-in substitution did not match", func() {
+			fs := machinery.Filesystem{FS: afero.NewMemMapFs()}
+			Expect(afero.WriteFile(fs.FS, "Dockerfile", []byte("FROM golang:1.20 as builder\nFROM example.com/base:v1\n"), 0644)).To(Succeed())
+			for _, failOnNoMatch := range []bool{false, true} {
+				s := &initSubcommand{options: imageOptions{failOnNoMatch: failOnNoMatch}}
+				Expect(s.InjectConfig(cfgv3.New())).To(Succeed())
+				Expect(s.PreScaffold(fs)).To(Succeed())
+				err := s.Scaffold(fs)
+				if !failOnNoMatch {
+					Expect(err).NotTo(HaveOccurred())
+					continue
+				}
+				Expect(err).To(MatchError(ContainSubstring("Dockerfile: " + ubiMinimalSubstitution.fromTagRE.String())))
+				Expect(err).NotTo(MatchError(ContainSubstring(bundleDockerfilePath)))
+			}
+		})
+
+		It("exits with the substitutions needed code from a dry run only if images would be substituted", func() {
+			fs := machinery.Filesystem{FS: afero.NewMemMapFs()}
+			Expect(afero.WriteFile(fs.FS, "Dockerfile", []byte(dockerfileAll), 0644)).To(Succeed())
+			cases := []struct {
+				dryRun bool
+				code   int
+			}{
+				{true, ExitCodeSubstitutionsNeeded},
+				{false, 0},
+				{true, 0},
+			}
+			for _, c := range cases {
+				s := &initSubcommand{options: imageOptions{dryRun: c.dryRun, out: &bytes.Buffer{}}}
+				Expect(s.InjectConfig(cfgv3.New())).To(Succeed())
+				Expect(s.PreScaffold(fs)).To(Succeed())
+				Expect(ExitCode(s.Scaffold(fs))).To(Equal(c.code))
+			}
+		})
+
+		It("substitutes images again in files rewritten by plugins after it in the chain", func() {
+			const dockerfile = "FROM gcr.io/distroless/static:nonroot\n"
+			const dockerfileExp = "FROM registry.access.redhat.com/ubi8/ubi-minimal:" + ubiMinimalVersion + "\n"
+			cases := []struct {
+				chain []string
+				exp   string
+			}{
+				{[]string{"go.kubebuilder.io/v3", pluginKey, "example.com/v1"}, dockerfileExp},
+				{[]string{"go.kubebuilder.io/v3", pluginKey}, dockerfile},
+			}
+			for _, c := range cases {
+				fs := machinery.Filesystem{FS: afero.NewMemMapFs()}
+				Expect(afero.WriteFile(fs.FS, "Dockerfile", []byte(dockerfile), 0644)).To(Succeed())
+				cfg := cfgv3.New()
+				Expect(cfg.SetPluginChain(c.chain)).To(Succeed())
+				s := &initSubcommand{}
+				Expect(s.InjectConfig(cfg)).To(Succeed())
+				Expect(s.PreScaffold(fs)).To(Succeed())
+				Expect(s.Scaffold(fs)).To(Succeed())
+
+				// A later plugin in the chain scaffolds the Dockerfile again.
+				Expect(afero.WriteFile(fs.FS, "Dockerfile", []byte(dockerfile), 0644)).To(Succeed())
+				Expect(s.PostScaffold()).To(Succeed())
+				b, err := afero.ReadFile(fs.FS, "Dockerfile")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(b)).To(Equal(c.exp), "%v", c.chain)
+			}
+		})
+
+		It("tags golang builder images with the go directive's version with --auto-go-version", func() {
+			fs := machinery.Filesystem{FS: afero.NewMemMapFs()}
+			Expect(afero.WriteFile(fs.FS, "Dockerfile", []byte("FROM golang:1.19 as builder\n"), 0644)).To(Succeed())
+			Expect(afero.WriteFile(fs.FS, "go.mod", []byte("module example.com/m\n\ngo 1.21\n"), 0644)).To(Succeed())
+
+			s := &initSubcommand{}
+			flags := pflag.NewFlagSet("init", pflag.ContinueOnError)
+			s.BindFlags(flags)
+			Expect(flags.Parse([]string{"--" + autoGoVersionFlag, "--" + goBuilderVersionFlag, "1.20"})).To(Succeed())
+			Expect(s.InjectConfig(cfgv3.New())).To(Succeed())
+			Expect(s.PreScaffold(fs)).To(Succeed())
+			Expect(s.Scaffold(fs)).To(Succeed())
+
+			b, err := afero.ReadFile(fs.FS, "Dockerfile")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(Equal("FROM golang:1.21 as builder\n"))
+			b, err = afero.ReadFile(fs.FS, "go.mod")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(Equal("module example.com/m\n\ngo 1.21\n"))
+		})
+
+		It("records the resolved OCP version in the plugin config", func() {
+			fs := machinery.Filesystem{FS: afero.NewMemMapFs()}
+			Expect(afero.WriteFile(fs.FS, "Dockerfile", []byte(dockerfileAll), 0644)).To(Succeed())
+			Expect(afero.WriteFile(fs.FS, "config/default/manager_auth_proxy_patch.yaml", []byte(proxyPatch), 0644)).To(Succeed())
+
+			s := &initSubcommand{options: imageOptions{Options: Options{OCPVersion: "4.15"}}}
+			Expect(s.InjectConfig(cfgv3.New())).To(Succeed())
+			Expect(s.PreScaffold(fs)).To(Succeed())
+			Expect(s.Scaffold(fs)).To(Succeed())
+
+			cfg, err := decodeConfig(s.config)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.OCPVersion).To(Equal("4.15"))
+		})
+
+		It("defaults the UBI version to the one known to work with the OCP version", func() {
+			fs := machinery.Filesystem{FS: afero.NewMemMapFs()}
+			s := &initSubcommand{options: imageOptions{Options: Options{OCPVersion: "4.15"}}}
+			Expect(s.PreScaffold(fs)).To(Succeed())
+			Expect(s.options.UBIVersion).To(Equal("8.9"))
+
+			s = &initSubcommand{options: imageOptions{Options: Options{OCPVersion: "4.15", UBIMajor: 9}}}
+			Expect(s.PreScaffold(fs)).To(Succeed())
+			Expect(s.options.UBIVersion).To(Equal("9.3"))
+		})
+
+		It("prefers an explicit UBI version over the OCP version's", func() {
+			fs := machinery.Filesystem{FS: afero.NewMemMapFs()}
+			s := &initSubcommand{options: imageOptions{Options: Options{OCPVersion: "4.15", UBIVersion: "8.8"}}}
+			Expect(s.PreScaffold(fs)).To(Succeed())
+			Expect(s.options.UBIVersion).To(Equal("8.8"))
+		})
+
+		It("scaffolds a SecurityContextConstraints and adds it to the default kustomization", func() {
+			fs := machinery.Filesystem{FS: afero.NewMemMapFs()}
+			Expect(afero.WriteFile(fs.FS, "config/default/kustomization.yaml", []byte(defaultKustomization), 0644)).To(Succeed())
+
+			c := cfgv3.New()
+			Expect(c.SetProjectName("memcached-operator")).To(Succeed())
+			s := &initSubcommand{withSCC: true}
+			Expect(s.InjectConfig(c)).To(Succeed())
+			Expect(s.PreScaffold(fs)).To(Succeed())
+			Expect(s.Scaffold(fs)).To(Succeed())
+			// Scaffolding again must not add config/openshift twice.
+			Expect(s.Scaffold(fs)).To(Succeed())
+
+			sccOut, err := afero.ReadFile(fs.FS, "config/openshift/scc.yaml")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(sccOut)).To(ContainSubstring("kind: SecurityContextConstraints\n"))
+			Expect(string(sccOut)).To(ContainSubstring("app.kubernetes.io/part-of: memcached-operator\n"))
+			Expect(string(sccOut)).To(ContainSubstring("- kind: ServiceAccount\n  name: controller-manager\n"))
+			kustomizationOut, err := afero.ReadFile(fs.FS, "config/openshift/kustomization.yaml")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(kustomizationOut)).To(ContainSubstring("- scc.yaml\n"))
+			Expect(string(kustomizationOut)).NotTo(ContainSubstring("- consoleplugin.yaml\n"))
+			Expect(afero.Exists(fs.FS, "config/openshift/kustomizeconfig.yaml")).To(BeTrue())
+
+			defaultOut, err := afero.ReadFile(fs.FS, "config/default/kustomization.yaml")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(defaultOut)).To(Equal("resources:\n- ../crd\n- ../rbac\n- ../manager\n- ../openshift\n- ../prometheus\n"))
+		})
+
+		It("scaffolds config/openshift as an overlay of config/default with --with-openshift-overlay", func() {
+			fs := machinery.Filesystem{FS: afero.NewMemMapFs()}
+			Expect(afero.WriteFile(fs.FS, "config/default/kustomization.yaml", []byte(defaultKustomization), 0644)).To(Succeed())
+
+			c := cfgv3.New()
+			Expect(c.SetProjectName("memcached-operator")).To(Succeed())
+			s := &initSubcommand{withSCC: true, withOpenShiftOverlay: true}
+			Expect(s.InjectConfig(c)).To(Succeed())
+			Expect(s.PreScaffold(fs)).To(Succeed())
+			Expect(s.Scaffold(fs)).To(Succeed())
+
+			kustomizationOut, err := afero.ReadFile(fs.FS, "config/openshift/kustomization.yaml")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(kustomizationOut)).To(HavePrefix("# This overlay deploys the operator on OpenShift"))
+			Expect(string(kustomizationOut)).To(ContainSubstring("resources:\n- ../default\n# Grants"))
+			Expect(string(kustomizationOut)).To(ContainSubstring("- scc.yaml\n\npatches:\n"))
+			Expect(string(kustomizationOut)).To(ContainSubstring("service.beta.openshift.io/serving-cert-secret-name: webhook-server-cert\n"))
+			Expect(string(kustomizationOut)).To(ContainSubstring("    kind: ValidatingWebhookConfiguration\n\nconfigurations:\n- kustomizeconfig.yaml\n"))
+			Expect(afero.Exists(fs.FS, "config/openshift/scc.yaml")).To(BeTrue())
+
+			defaultOut, err := afero.ReadFile(fs.FS, "config/default/kustomization.yaml")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(defaultOut)).To(Equal(defaultKustomization))
+		})
+
+		It("scaffolds an overlay without OpenShift resources or kustomize configuration", func() {
+			fs := machinery.Filesystem{FS: afero.NewMemMapFs()}
+			s := &initSubcommand{withOpenShiftOverlay: true}
+			Expect(s.InjectConfig(cfgv3.New())).To(Succeed())
+			Expect(s.PreScaffold(fs)).To(Succeed())
+			Expect(s.Scaffold(fs)).To(Succeed())
+
+			kustomizationOut, err := afero.ReadFile(fs.FS, "config/openshift/kustomization.yaml")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(kustomizationOut)).To(ContainSubstring("resources:\n- ../default\n\npatches:\n"))
+			Expect(string(kustomizationOut)).NotTo(ContainSubstring("configurations:"))
+			Expect(afero.Exists(fs.FS, "config/openshift/kustomizeconfig.yaml")).To(BeFalse())
+		})
+
+		It("reports the files created and modified by the last Scaffold call", func() {
+			fs := machinery.Filesystem{FS: afero.NewMemMapFs()}
+			Expect(afero.WriteFile(fs.FS, "config/default/kustomization.yaml", []byte(defaultKustomization), 0644)).To(Succeed())
+			Expect(afero.WriteFile(fs.FS, "Dockerfile", []byte("FROM gcr.io/distroless/static:nonroot\n"), 0644)).To(Succeed())
+
+			c := cfgv3.New()
+			Expect(c.SetProjectName("memcached-operator")).To(Succeed())
+			s := &initSubcommand{withSCC: true}
+			Expect(s.InjectConfig(c)).To(Succeed())
+			Expect(s.PreScaffold(fs)).To(Succeed())
+			Expect(s.Scaffold(fs)).To(Succeed())
+			Expect(s.Report()).To(Equal(ScaffoldReport{
+				Created: []string{
+					"config/openshift/kustomization.yaml",
+					"config/openshift/kustomizeconfig.yaml",
+					"config/openshift/scc.yaml",
+				},
+				Modified: []string{"Dockerfile", "config/default/kustomization.yaml"},
+			}))
+
+			Expect(s.Scaffold(fs)).To(Succeed())
+			Expect(s.Report()).To(Equal(ScaffoldReport{Created: []string{}, Modified: []string{}}))
+		})
+
+		It("scaffolds a console plugin with a substituted nginx image", func() {
+			fs := machinery.Filesystem{FS: afero.NewMemMapFs()}
+			Expect(afero.WriteFile(fs.FS, "config/default/kustomization.yaml", []byte(defaultKustomization), 0644)).To(Succeed())
+
+			c := cfgv3.New()
+			Expect(c.SetProjectName("memcached-operator")).To(Succeed())
+			s := &initSubcommand{
+				withConsolePlugin: true,
+				options:           imageOptions{Options: Options{UBIMajor: 9, Registry: "mirror.example.com"}},
+			}
+			Expect(s.InjectConfig(c)).To(Succeed())
+			Expect(s.PreScaffold(fs)).To(Succeed())
+			Expect(s.Scaffold(fs)).To(Succeed())
+
+			pluginOut, err := afero.ReadFile(fs.FS, "config/openshift/consoleplugin.yaml")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(pluginOut)).To(ContainSubstring("kind: ConsolePlugin\n"))
+			Expect(string(pluginOut)).To(ContainSubstring("  displayName: memcached-operator\n"))
+			Expect(string(pluginOut)).To(ContainSubstring("image: mirror.example.com/ubi9/nginx-122:latest\n"))
+			kustomizationOut, err := afero.ReadFile(fs.FS, "config/openshift/kustomization.yaml")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(kustomizationOut)).To(ContainSubstring("- consoleplugin.yaml\n"))
+			Expect(string(kustomizationOut)).NotTo(ContainSubstring("- scc.yaml\n"))
+			kustomizeConfigOut, err := afero.ReadFile(fs.FS, "config/openshift/kustomizeconfig.yaml")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(kustomizeConfigOut)).To(ContainSubstring("path: spec/backend/service/name\n"))
+			Expect(string(kustomizeConfigOut)).NotTo(ContainSubstring("SecurityContextConstraints"))
+
+			defaultOut, err := afero.ReadFile(fs.FS, "config/default/kustomization.yaml")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(defaultOut)).To(ContainSubstring("- ../manager\n- ../openshift\n"))
+		})
+
+		It("scaffolds an OpenShift Template with substituted parameter defaults if set", func() {
+			fs := machinery.Filesystem{FS: afero.NewMemMapFs()}
+			Expect(afero.WriteFile(fs.FS, "config/default/kustomization.yaml", []byte(defaultKustomization), 0644)).To(Succeed())
+
+			c := cfgv3.New()
+			Expect(c.SetProjectName("memcached-operator")).To(Succeed())
+			s := &initSubcommand{
+				withTemplate: true,
+				options:      imageOptions{Options: Options{OCPVersion: "4.13"}},
+			}
+			Expect(s.InjectConfig(c)).To(Succeed())
+			Expect(s.PreScaffold(fs)).To(Succeed())
+			Expect(s.Scaffold(fs)).To(Succeed())
+
+			templateOut, err := afero.ReadFile(fs.FS, "config/openshift/template.yaml")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(templateOut)).To(ContainSubstring("kind: Template\n"))
+			Expect(string(templateOut)).To(ContainSubstring("  value: memcached-operator-system\n"))
+			Expect(string(templateOut)).To(ContainSubstring("  value: registry.redhat.io/openshift4/ose-kube-rbac-proxy:v4.13\n"))
+			Expect(string(templateOut)).To(ContainSubstring("image: ${IMAGE}\n"))
+			Expect(string(templateOut)).NotTo(ContainSubstring("gcr.io/kubebuilder"))
+
+			// The Template is processed with oc, not kustomize.
+			exists, err := afero.Exists(fs.FS, "config/openshift/kustomization.yaml")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(exists).To(BeFalse())
+		})
+
+		It("substitutes images in additional files and records them for later subcommands", func() {
+			fs := machinery.Filesystem{FS: afero.NewMemMapFs()}
+			Expect(afero.WriteFile(fs.FS, "deploy/operator.yaml", []byte(proxyPatch), 0644)).To(Succeed())
+
+			s := &initSubcommand{options: imageOptions{Options: Options{Files: []string{"./deploy/operator.yaml"}}}}
+			Expect(s.InjectConfig(cfgv3.New())).To(Succeed())
+			Expect(s.PreScaffold(fs)).To(Succeed())
+			Expect(s.Scaffold(fs)).To(Succeed())
+
+			operatorOut, err := afero.ReadFile(fs.FS, "deploy/operator.yaml")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(operatorOut)).To(Equal(proxyPatchExp))
+			cfg, err := decodeConfig(s.config)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.Files).To(Equal([]string{"deploy/operator.yaml"}))
+
+			Expect(afero.WriteFile(fs.FS, "deploy/operator.yaml", []byte(proxyPatch), 0644)).To(Succeed())
+			api := &createAPISubcommand{}
+			Expect(api.InjectConfig(s.config)).To(Succeed())
+			Expect(api.Scaffold(fs)).To(Succeed())
+			operatorOut, err = afero.ReadFile(fs.FS, "deploy/operator.yaml")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(operatorOut)).To(Equal(proxyPatchExp))
+		})
+
+		It("rejects additional files outside the project root", func() {
+			fs := machinery.Filesystem{FS: afero.NewMemMapFs()}
+			s := &initSubcommand{options: imageOptions{Options: Options{Files: []string{"../operator.yaml"}}}}
+			Expect(s.PreScaffold(fs)).To(MatchError(ContainSubstring("--" + fileFlag)))
+		})
+
+		It("scaffolds CSV annotations for the OCP version", func() {
+			fs := machinery.Filesystem{FS: afero.NewMemMapFs()}
+			s := &initSubcommand{options: imageOptions{Options: Options{OCPVersion: "4.15"}}, withCSVAnnotations: true}
+			Expect(s.InjectConfig(cfgv3.New())).To(Succeed())
+			Expect(s.PreScaffold(fs)).To(Succeed())
+			Expect(s.Scaffold(fs)).To(Succeed())
+
+			patchOut, err := afero.ReadFile(fs.FS, "config/manifests/bases/openshift_csv_patch.yaml")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(patchOut)).To(ContainSubstring(`com.redhat.openshift.versions: "=v4.15"`))
+		})
+
+		It("scaffolds CSV annotations for an OCP version range, tagging images with its earliest release", func() {
+			fs := machinery.Filesystem{FS: afero.NewMemMapFs()}
+			Expect(afero.WriteFile(fs.FS, authProxyPatchPath, []byte(proxyPatch), 0644)).To(Succeed())
+			for _, c := range []struct{ value, annotation string }{{"4.12-4.16", "v4.12-v4.16"}, {"4.12+", "v4.12"}} {
+				s := &initSubcommand{options: imageOptions{Options: Options{OCPVersion: c.value}}, withCSVAnnotations: true}
+				Expect(s.InjectConfig(cfgv3.New())).To(Succeed())
+				Expect(s.PreScaffold(fs)).To(Succeed())
+				Expect(s.options.OCPVersion).To(Equal("4.12"))
+				Expect(s.ocpVersions.annotation()).To(Equal(c.annotation))
+			}
+
+			s := &initSubcommand{options: imageOptions{Options: Options{OCPVersion: "4.12-4.16"}}, withCSVAnnotations: true}
+			Expect(s.InjectConfig(cfgv3.New())).To(Succeed())
+			Expect(s.PreScaffold(fs)).To(Succeed())
+			Expect(s.Scaffold(fs)).To(Succeed())
+			patchOut, err := afero.ReadFile(fs.FS, "config/manifests/bases/openshift_csv_patch.yaml")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(patchOut)).To(ContainSubstring(`com.redhat.openshift.versions: "v4.12-v4.16"`))
+			proxyPatchOut, err := afero.ReadFile(fs.FS, authProxyPatchPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(proxyPatchOut)).To(ContainSubstring("ose-kube-rbac-proxy:v4.12\n"))
+			cfg, err := decodeConfig(s.config)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.OCPVersion).To(Equal("4.12"))
+		})
+
+		It("requires CSV annotations for an OCP version range", func() {
+			fs := machinery.Filesystem{FS: afero.NewMemMapFs()}
+			s := &initSubcommand{options: imageOptions{Options: Options{OCPVersion: "4.12-4.16"}}}
+			Expect(s.PreScaffold(fs)).To(MatchError(ContainSubstring("--" + withCSVAnnotationsFlag)))
+		})
+
+		It("requires CSV annotations for a maximum OCP version", func() {
+			fs := machinery.Filesystem{FS: afero.NewMemMapFs()}
+			s := &initSubcommand{maxOCPVersion: "4.16"}
+			Expect(s.PreScaffold(fs)).To(MatchError(ContainSubstring("--" + withCSVAnnotationsFlag)))
+		})
+
+		It("does not scaffold a SecurityContextConstraints by default", func() {
+			fs := machinery.Filesystem{FS: afero.NewMemMapFs()}
+			s := &initSubcommand{}
+			Expect(s.InjectConfig(cfgv3.New())).To(Succeed())
+			Expect(s.PreScaffold(fs)).To(Succeed())
+			Expect(s.Scaffold(fs)).To(Succeed())
+			Expect(afero.Exists(fs.FS, "config/openshift/scc.yaml")).To(BeFalse())
+			Expect(afero.Exists(fs.FS, "config/openshift/consoleplugin.yaml")).To(BeFalse())
+			Expect(afero.Exists(fs.FS, "config/manifests/bases/openshift_csv_patch.yaml")).To(BeFalse())
+		})
+	})
+})
 
-const proxyPatchExp = `apiVersion: apps/v1
-kind: Deployment
-metadata:
-  name: controller-manager
-  namespace: system
-spec:
-  template:
-    spec:
-      containers:
-      - name: kube-rbac-proxy
-        image: registry.redhat.io/openshift4/ose-kube-rbac-proxy:v` + ocpProductVersion + `
-      - name: kube-rbac-proxy-latest
-        image: registry.redhat.io/openshift4/ose-kube-rbac-proxy:v` + ocpProductVersion + `
-      - name: upstream
-        image: quay.io/brancz/kube-rbac-proxy:v0.5.0
+const defaultKustomization = `resources:
+- ../crd
+- ../rbac
+- ../manager
+- ../prometheus
 `
@@ -0,0 +1,92 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/v3/pkg/config"
+	"sigs.k8s.io/kubebuilder/v3/pkg/plugin"
+)
+
+const (
+	goPluginName        = "go.kubebuilder.io"
+	kustomizePluginName = "kustomize.common.kubebuilder.io"
+	helmPluginName      = "helm.sdk.operatorframework.io"
+	ansiblePluginName   = "ansible.sdk.operatorframework.io"
+)
+
+// Project types that substitutions may be limited to.
+const (
+	GoProjectType      = "go"
+	HelmProjectType    = "helm"
+	AnsibleProjectType = "ansible"
+)
+
+// authProxyPatchPath is the path of the kube-rbac-proxy sidecar patch.
+var authProxyPatchPath = filepath.Join("config", "default", "manager_auth_proxy_patch.yaml")
+
+// projectType returns the type of project c's layout scaffolds, or "" if it is not known.
+func projectType(c config.Config) string {
+	if c == nil {
+		return ""
+	}
+	for _, key := range c.GetPluginChain() {
+		switch name, _ := plugin.SplitKey(key); name {
+		case goPluginName:
+			return GoProjectType
+		case helmPluginName:
+			return HelmProjectType
+		case ansiblePluginName:
+			return AnsibleProjectType
+		}
+	}
+	return ""
+}
+
+// mayOmitAuthProxy returns true if c's layout may not scaffold the kube-rbac-proxy sidecar patch.
+// Stable go/v4 and kustomize/v2 layouts eventually replaced kube-rbac-proxy with authentication
+// and authorization of metrics in the manager itself, which uses no image that needs substitution.
+func mayOmitAuthProxy(c config.Config) bool {
+	if c == nil {
+		return false
+	}
+	for _, key := range c.GetPluginChain() {
+		name, versionStr := plugin.SplitKey(key)
+		var version plugin.Version
+		if err := version.Parse(versionStr); err != nil || !version.IsStable() {
+			continue
+		}
+		if (name == goPluginName && version.Number >= 4) || (name == kustomizePluginName && version.Number >= 2) {
+			return true
+		}
+	}
+	return false
+}
+
+// pluginsAfter returns the keys of the plugins that follow this plugin in c's plugin chain,
+// which scaffold after this plugin and may overwrite the files it substituted images in.
+func pluginsAfter(c config.Config) []string {
+	if c == nil {
+		return nil
+	}
+	chain := c.GetPluginChain()
+	for i, key := range chain {
+		if name, _ := plugin.SplitKey(key); name == pluginName {
+			return chain[i+1:]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+	cfgv3 "sigs.k8s.io/kubebuilder/v3/pkg/config/v3"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+var _ = Describe("projectType", func() {
+	It("returns the type of project a layout scaffolds", func() {
+		for chain, expected := range map[string]string{
+			"go.kubebuilder.io/v4":                GoProjectType,
+			"helm.sdk.operatorframework.io/v1":    HelmProjectType,
+			"ansible.sdk.operatorframework.io/v1": AnsibleProjectType,
+			"kustomize.common.kubebuilder.io/v2":  "",
+		} {
+			c := cfgv3.New()
+			Expect(c.SetPluginChain([]string{chain})).To(Succeed())
+			Expect(projectType(c)).To(Equal(expected), chain)
+		}
+		Expect(projectType(nil)).To(BeEmpty())
+	})
+
+	It("skips non-language layouts", func() {
+		c := cfgv3.New()
+		Expect(c.SetPluginChain([]string{"kustomize.common.kubebuilder.io/v2", "ansible.sdk.operatorframework.io/v1"})).To(Succeed())
+		Expect(projectType(c)).To(Equal(AnsibleProjectType))
+	})
+})
+
+var _ = Describe("mayOmitAuthProxy", func() {
+	It("returns false for layouts that scaffold kube-rbac-proxy", func() {
+		for _, chain := range [][]string{
+			{"go.kubebuilder.io/v3"},
+			{"go.kubebuilder.io/v4-alpha"},
+			{"kustomize.common.kubebuilder.io/v1", "go.kubebuilder.io/v4-alpha"},
+			{"helm.sdk.operatorframework.io/v1"},
+		} {
+			c := cfgv3.New()
+			Expect(c.SetPluginChain(chain)).To(Succeed())
+			Expect(mayOmitAuthProxy(c)).To(BeFalse(), "%v", chain)
+		}
+		Expect(mayOmitAuthProxy(nil)).To(BeFalse())
+	})
+
+	It("returns true for layouts that authorize metrics in the manager", func() {
+		for _, chain := range [][]string{
+			{"go.kubebuilder.io/v4"},
+			{"kustomize.common.kubebuilder.io/v2", "ansible.sdk.operatorframework.io/v1"},
+		} {
+			c := cfgv3.New()
+			Expect(c.SetPluginChain(chain)).To(Succeed())
+			Expect(mayOmitAuthProxy(c)).To(BeTrue(), "%v", chain)
+		}
+	})
+
+	Describe("RunInit", func() {
+		var fs machinery.Filesystem
+
+		BeforeEach(func() {
+			fs = machinery.Filesystem{FS: afero.NewMemMapFs()}
+			Expect(afero.WriteFile(fs.FS, "Dockerfile", []byte(dockerfileAll), 0644)).To(Succeed())
+		})
+
+		It("requires the auth proxy patch of older layouts in strict mode", func() {
+			c := cfgv3.New()
+			Expect(c.SetPluginChain([]string{"go.kubebuilder.io/v4-alpha"})).To(Succeed())
+			s := &initSubcommand{options: imageOptions{strict: true}}
+			Expect(s.InjectConfig(c)).To(Succeed())
+			Expect(s.PreScaffold(fs)).To(Succeed())
+			Expect(s.Scaffold(fs)).To(MatchError(ContainSubstring("error reading file for substitution")))
+		})
+
+		It("skips the missing auth proxy patch of newer layouts in strict mode", func() {
+			c := cfgv3.New()
+			Expect(c.SetPluginChain([]string{"go.kubebuilder.io/v4"})).To(Succeed())
+			s := &initSubcommand{options: imageOptions{strict: true}}
+			Expect(s.InjectConfig(c)).To(Succeed())
+			Expect(s.PreScaffold(fs)).To(Succeed())
+			Expect(s.Scaffold(fs)).To(Succeed())
+			dockerfileOut, err := afero.ReadFile(fs.FS, "Dockerfile")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(dockerfileOut)).To(ContainSubstring("FROM registry.access.redhat.com/ubi8/ubi-minimal:" + ubiMinimalVersion + "\n"))
+			Expect(string(dockerfileOut)).NotTo(ContainSubstring("gcr.io/distroless/static:nonroot"))
+		})
+
+		It("substitutes the auth proxy patch of newer layouts if it exists", func() {
+			Expect(afero.WriteFile(fs.FS, authProxyPatchPath, []byte(proxyPatch), 0644)).To(Succeed())
+			c := cfgv3.New()
+			Expect(c.SetPluginChain([]string{"go.kubebuilder.io/v4"})).To(Succeed())
+			s := &initSubcommand{options: imageOptions{strict: true}}
+			Expect(s.InjectConfig(c)).To(Succeed())
+			Expect(s.PreScaffold(fs)).To(Succeed())
+			Expect(s.Scaffold(fs)).To(Succeed())
+			proxyPatchOut, err := afero.ReadFile(fs.FS, authProxyPatchPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(proxyPatchOut)).To(Equal(proxyPatchExp))
+		})
+	})
+})
+
+var _ = Describe("pluginsAfter", func() {
+	It("returns the plugins after this plugin in the chain", func() {
+		for _, c := range []struct {
+			chain []string
+			after []string
+		}{
+			{[]string{"go.kubebuilder.io/v3", pluginKey, "example.com/v1"}, []string{"example.com/v1"}},
+			{[]string{"go.kubebuilder.io/v3", pluginKey}, []string{}},
+			{[]string{"go.kubebuilder.io/v3"}, nil},
+		} {
+			cfg := cfgv3.New()
+			Expect(cfg.SetPluginChain(c.chain)).To(Succeed())
+			Expect(pluginsAfter(cfg)).To(Equal(c.after), "%v", c.chain)
+		}
+		Expect(pluginsAfter(nil)).To(BeEmpty())
+	})
+})
@@ -0,0 +1,50 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/spf13/afero"
+)
+
+// listSubstitutions writes each substitution opts would apply to w, in path order. Files
+// are listed whether or not they exist, and substitutions are listed in the order they are applied.
+func listSubstitutions(w io.Writer, fs afero.Fs, opts imageOptions) error {
+	substs, err := fileSubstitutions(fs, opts)
+	if err != nil {
+		return err
+	}
+	filePaths := make([]string, 0, len(substs))
+	for filePath := range substs {
+		filePaths = append(filePaths, filePath)
+	}
+	sort.Strings(filePaths)
+
+	for _, filePath := range filePaths {
+		for _, subst := range substs[filePath] {
+			category := subst.Category
+			if category == "" {
+				category = "user"
+			}
+			if _, err := fmt.Fprintf(w, "%s: %s -> %s [%s]\n", filePath, subst.FromTagRE, subst.ToTag, category); err != nil {
+				return fmt.Errorf("error listing substitutions: %v", err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"bytes"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+	"github.com/spf13/pflag"
+	cfgv3 "sigs.k8s.io/kubebuilder/v3/pkg/config/v3"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+var _ = Describe("listSubstitutions", func() {
+	It("lists resolved substitutions for each file without touching any", func() {
+		fs := machinery.Filesystem{FS: afero.NewMemMapFs()}
+		Expect(afero.WriteFile(fs.FS, "Dockerfile", []byte("FROM gcr.io/distroless/static:nonroot\n"), 0644)).To(Succeed())
+		out := &bytes.Buffer{}
+
+		s := &initSubcommand{options: imageOptions{out: out}}
+		flags := pflag.NewFlagSet("init", pflag.ContinueOnError)
+		s.BindFlags(flags)
+		Expect(flags.Parse([]string{
+			"--" + listSubstitutionsFlag, "--" + ocpVersionFlag, "4.13", "--" + registryFlag, "mirror.example.com",
+			"--" + fromPatternFlag, `quay.io/example/sidecar:v1`, "--" + toImageFlag, "mirror.example.com/sidecar:v1",
+		})).To(Succeed())
+		Expect(s.InjectConfig(cfgv3.New())).To(Succeed())
+		Expect(s.PreScaffold(fs)).To(Succeed())
+		Expect(s.Scaffold(fs)).To(Succeed())
+
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		Expect(lines).To(ContainElement(authProxyPatchPath + `: gcr.io/kubebuilder/kube-rbac-proxy[:@][^ \n"']+ -> ` +
+			"mirror.example.com/openshift4/ose-kube-rbac-proxy:v4.13 [" + KubeRBACProxyCategory + "]"))
+		Expect(lines).To(ContainElement(`Dockerfile: gcr.io/distroless/static[:@][^ \n"']+ -> ` +
+			"mirror.example.com/ubi8/ubi-minimal:" + ubiMinimalVersion + " [" + UBIMinimalCategory + "]"))
+		Expect(lines).To(ContainElement(consolePluginPath + ": quay.io/example/sidecar:v1 -> mirror.example.com/sidecar:v1 [user]"))
+		Expect(lines[0]).To(HavePrefix(koConfigPath + ": "))
+
+		b, err := afero.ReadFile(fs.FS, "Dockerfile")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(Equal("FROM gcr.io/distroless/static:nonroot\n"))
+		Expect(s.Report()).To(Equal(ScaffoldReport{}))
+	})
+})
@@ -0,0 +1,61 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+)
+
+// localConfigPath is the path of the user-editable config file providing defaults for flags,
+// relative to the project root. Unlike the plugin config in the PROJECT file, it is never written.
+const localConfigPath = ".openshiftplugin.yaml"
+
+// localConfig is the content of localConfigPath. Values that are set replace the defaults
+// of their flags, and are overridden by flags that are given.
+type localConfig struct {
+	// OCPVersion is the default --ocp-version.
+	OCPVersion string `yaml:"ocpVersion"`
+	// UBIVersion is the default --ubi-version.
+	UBIVersion string `yaml:"ubiVersion"`
+	// Registry is the default --registry.
+	Registry string `yaml:"registry"`
+	// Disable is the default --disable.
+	Disable []string `yaml:"disable"`
+}
+
+// loadLocalConfig reads localConfigPath from fs, returning an empty config if it does not exist.
+// Unknown fields are rejected, so that misspelled fields are not silently ignored.
+func loadLocalConfig(fs afero.Fs) (localConfig, error) {
+	var cfg localConfig
+	b, err := afero.ReadFile(fs, localConfigPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	} else if err != nil {
+		return cfg, fmt.Errorf("error reading %s: %v", localConfigPath, err)
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return cfg, fmt.Errorf("error parsing %s: %v", localConfigPath, err)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,139 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+var _ = Describe("loadLocalConfig", func() {
+	var fs afero.Fs
+
+	BeforeEach(func() {
+		fs = afero.NewMemMapFs()
+	})
+
+	It("loads defaults from the local config file", func() {
+		Expect(afero.WriteFile(fs, localConfigPath, []byte(localConfigFile), 0644)).To(Succeed())
+		cfg, err := loadLocalConfig(fs)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg).To(Equal(localConfig{
+			OCPVersion: "4.15",
+			UBIVersion: "8.9",
+			Registry:   "mirror.example.com",
+			Disable:    []string{GoBuilderCategory},
+		}))
+	})
+
+	It("returns an empty config if the file does not exist or is empty", func() {
+		cfg, err := loadLocalConfig(fs)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg).To(Equal(localConfig{}))
+		Expect(afero.WriteFile(fs, localConfigPath, nil, 0644)).To(Succeed())
+		cfg, err = loadLocalConfig(fs)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg).To(Equal(localConfig{}))
+	})
+
+	It("rejects unknown fields", func() {
+		Expect(afero.WriteFile(fs, localConfigPath, []byte("ocpVersoin: 4.15\n"), 0644)).To(Succeed())
+		_, err := loadLocalConfig(fs)
+		Expect(err).To(MatchError(ContainSubstring("error parsing " + localConfigPath)))
+	})
+})
+
+var _ = Describe("initSubcommand.applyDefaults", func() {
+	AfterEach(func() {
+		Expect(os.Unsetenv(ocpVersionEnv)).To(Succeed())
+	})
+
+	It("resolves values from flags, then the local config file, then the environment, then defaults", func() {
+		cases := []struct {
+			flag, file, env string
+			expected        string
+		}{
+			{"4.16", "4.15", "4.13", "4.16"},
+			{"", "4.15", "4.13", "4.15"},
+			{"", "", "4.13", "4.13"},
+			{"", "", "", ocpProductVersion},
+			{"4.16", "", "4.13", "4.16"},
+			{"4.16", "4.15", "", "4.16"},
+		}
+		for _, c := range cases {
+			fs := machinery.Filesystem{FS: afero.NewMemMapFs()}
+			if c.file != "" {
+				Expect(afero.WriteFile(fs.FS, localConfigPath, []byte("ocpVersion: "+c.file+"\n"), 0644)).To(Succeed())
+			}
+			if c.env != "" {
+				Expect(os.Setenv(ocpVersionEnv, c.env)).To(Succeed())
+			} else {
+				Expect(os.Unsetenv(ocpVersionEnv)).To(Succeed())
+			}
+			var args []string
+			if c.flag != "" {
+				args = []string{"--" + ocpVersionFlag, c.flag}
+			}
+			s := &initSubcommand{}
+			flags := pflag.NewFlagSet("init", pflag.ContinueOnError)
+			s.BindFlags(flags)
+			Expect(flags.Parse(args)).To(Succeed())
+			Expect(s.PreScaffold(fs)).To(Succeed())
+			Expect(s.options.OCPVersion).To(Equal(c.expected), "flag %q, file %q, env %q", c.flag, c.file, c.env)
+		}
+	})
+
+	It("sets the registry and disabled categories unless their flags are given", func() {
+		fs := machinery.Filesystem{FS: afero.NewMemMapFs()}
+		Expect(afero.WriteFile(fs.FS, localConfigPath, []byte(localConfigFile), 0644)).To(Succeed())
+		cases := []struct {
+			args     []string
+			registry string
+			disabled []string
+		}{
+			{nil, "mirror.example.com", []string{GoBuilderCategory}},
+			{[]string{"--" + registryFlag, "other.example.com", "--" + disableFlag, UBIMicroCategory}, "other.example.com", []string{UBIMicroCategory}},
+		}
+		for _, c := range cases {
+			s := &initSubcommand{}
+			flags := pflag.NewFlagSet("init", pflag.ContinueOnError)
+			s.BindFlags(flags)
+			Expect(flags.Parse(c.args)).To(Succeed())
+			Expect(s.PreScaffold(fs)).To(Succeed())
+			Expect(s.options.Registry).To(Equal(c.registry))
+			Expect(s.options.Disabled).To(Equal(c.disabled))
+		}
+	})
+
+	It("fails if the local config file cannot be parsed", func() {
+		fs := machinery.Filesystem{FS: afero.NewMemMapFs()}
+		Expect(afero.WriteFile(fs.FS, localConfigPath, []byte("registry: [\n"), 0644)).To(Succeed())
+		s := &initSubcommand{}
+		Expect(s.PreScaffold(fs)).To(MatchError(ContainSubstring(localConfigPath)))
+	})
+})
+
+const localConfigFile = `# Defaults for flags of the OpenShift plugin.
+ocpVersion: 4.15
+ubiVersion: "8.9"
+registry: mirror.example.com
+disable:
+- go-builder
+`
@@ -0,0 +1,102 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+)
+
+// makefilePath is the path of the project Makefile.
+const makefilePath = "Makefile"
+
+// makefileAssignmentRE matches a Makefile variable assignment, ex. "OPM_IMG ?= quay.io/operator-framework/opm:latest",
+// capturing its value. Recipe lines start with a tab, so they never match.
+var makefileAssignmentRE = regexp.MustCompile(`(?m)^ *(?:(?:export|override) +)?[A-Za-z0-9_.-]+[ \t]*(?:\?|:{1,3}|\+|!)?=[ \t]*([^\n]*)$`)
+
+// verifyImagesTargetRE matches the verify-images target rule of a Makefile.
+var verifyImagesTargetRE = regexp.MustCompile(`(?m)^verify-images:`)
+
+// makefileVerifyImagesFragment is appended to the Makefile to audit the project for upstream images.
+// OPERATOR_SDK is usually set by the operator-sdk target of the manifests plugin.
+const makefileVerifyImagesFragment = `
+##@ OpenShift
+
+OPERATOR_SDK ?= operator-sdk
+.PHONY: verify-images
+verify-images: ## Verify that no upstream images are referenced in place of OpenShift images.
+	$(OPERATOR_SDK) openshift verify
+`
+
+// addVerifyImagesTarget appends a verify-images target to the Makefile, unless it already has one.
+// A warning is logged if the project has no Makefile.
+func addVerifyImagesTarget(fs afero.Fs) error {
+	b, err := afero.ReadFile(fs, makefilePath)
+	if errors.Is(err, os.ErrNotExist) {
+		log.Warnf("%s does not exist, skipping verify-images target", makefilePath)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading %s: %v", makefilePath, err)
+	}
+	if verifyImagesTargetRE.Match(b) {
+		return nil
+	}
+
+	info, err := fs.Stat(makefilePath)
+	if err != nil {
+		return fmt.Errorf("error reading file info of %s: %v", makefilePath, err)
+	}
+	out := append([]byte{}, b...)
+	if len(out) > 0 && !bytes.HasSuffix(out, []byte("\n")) {
+		out = append(out, '\n')
+	}
+	out = append(out, makefileVerifyImagesFragment...)
+	return writeFile(fs, makefilePath, out, info.Mode())
+}
+
+// substituteMakefile applies subs to the values of variable assignments in Makefile content,
+// and returns the substituted content and the matches of each substitution. Comments following
+// values are left unchanged unless rewriteComments is set.
+func substituteMakefile(content []byte, subs []Substitution, rewriteComments bool) ([]byte, [][][]byte) {
+	substMatches := make([][][]byte, len(subs))
+	var out []byte
+	last := 0
+	for _, loc := range makefileAssignmentRE.FindAllSubmatchIndex(content, -1) {
+		var (
+			value   []byte
+			matches [][][]byte
+		)
+		if rewriteComments {
+			value, matches = substitute(content[loc[2]:loc[3]], subs)
+		} else {
+			value, matches = substituteCode(makefilePath, content[loc[2]:loc[3]], subs)
+		}
+		for i := range matches {
+			substMatches[i] = append(substMatches[i], matches[i]...)
+		}
+		out = append(out, content[last:loc[2]]...)
+		out = append(out, value...)
+		last = loc[3]
+	}
+	out = append(out, content[last:]...)
+	return out, substMatches
+}
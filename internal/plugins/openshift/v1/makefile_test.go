@@ -0,0 +1,131 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+	cfgv3 "sigs.k8s.io/kubebuilder/v3/pkg/config/v3"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+var _ = Describe("addVerifyImagesTarget", func() {
+	var fs afero.Fs
+
+	BeforeEach(func() {
+		fs = afero.NewMemMapFs()
+	})
+
+	It("appends a verify-images target once", func() {
+		Expect(afero.WriteFile(fs, makefilePath, []byte("all: build"), 0644)).To(Succeed())
+		Expect(addVerifyImagesTarget(fs)).To(Succeed())
+		Expect(addVerifyImagesTarget(fs)).To(Succeed())
+		b, err := afero.ReadFile(fs, makefilePath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(Equal("all: build\n" + makefileVerifyImagesFragment))
+	})
+
+	It("keeps an existing verify-images target", func() {
+		makefile := "verify-images:\n\t./hack/verify.sh\n"
+		Expect(afero.WriteFile(fs, makefilePath, []byte(makefile), 0644)).To(Succeed())
+		Expect(addVerifyImagesTarget(fs)).To(Succeed())
+		b, err := afero.ReadFile(fs, makefilePath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(Equal(makefile))
+	})
+
+	It("skips projects without a Makefile", func() {
+		Expect(addVerifyImagesTarget(fs)).To(Succeed())
+		Expect(afero.Exists(fs, makefilePath)).To(BeFalse())
+	})
+
+	It("is added by init with --with-verify-target", func() {
+		Expect(afero.WriteFile(fs, makefilePath, []byte("all: build\n"), 0644)).To(Succeed())
+		s := &initSubcommand{withVerifyTarget: true}
+		Expect(s.InjectConfig(cfgv3.New())).To(Succeed())
+		Expect(s.PreScaffold(machinery.Filesystem{FS: fs})).To(Succeed())
+		Expect(s.Scaffold(machinery.Filesystem{FS: fs})).To(Succeed())
+		b, err := afero.ReadFile(fs, makefilePath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(ContainSubstring("verify-images:"))
+	})
+})
+
+var _ = Describe("substituteMakefile", func() {
+	var fs afero.Fs
+
+	BeforeEach(func() {
+		fs = afero.NewMemMapFs()
+		Expect(afero.WriteFile(fs, makefilePath, []byte(makefileWithToolImages), 0644)).To(Succeed())
+	})
+
+	It("replaces tool images assigned to variables with --makefile-images", func() {
+		opts := defaultImageOptions()
+		opts.MakefileImages = true
+		_, err := replaceImages(machinery.Filesystem{FS: fs}, opts)
+		Expect(err).NotTo(HaveOccurred())
+		b, err := afero.ReadFile(fs, makefilePath)
+		Expect(err).NotTo(HaveOccurred())
+		oseTag := ":v" + ocpProductVersion
+		Expect(string(b)).To(Equal("OPM_IMG ?= registry.redhat.io/openshift4/ose-operator-registry" + oseTag + "\n" +
+			"export KUBECTL_IMG := registry.redhat.io/openshift4/ose-cli" + oseTag + "\n" +
+			"PINNED_IMG = quay.io/operator-framework/opm@sha256:abc123\n" +
+			"\n" +
+			"catalog-build:\n" +
+			"\tdocker run quay.io/operator-framework/opm:v1.28.0 index add\n"))
+	})
+
+	It("keeps images of kept registries", func() {
+		opts := defaultImageOptions()
+		opts.MakefileImages = true
+		opts.KeepRegistries = []string{"quay.io"}
+		_, err := replaceImages(machinery.Filesystem{FS: fs}, opts)
+		Expect(err).NotTo(HaveOccurred())
+		b, err := afero.ReadFile(fs, makefilePath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(ContainSubstring("OPM_IMG ?= quay.io/operator-framework/opm:v1.28.0\n"))
+		Expect(string(b)).To(ContainSubstring("KUBECTL_IMG := registry.redhat.io/openshift4/ose-cli:"))
+	})
+
+	It("leaves comments following values unchanged", func() {
+		makefile := "OPM_IMG ?= quay.io/operator-framework/opm:v1.28.0 # was quay.io/operator-framework/opm:v1.27.0\n"
+		Expect(afero.WriteFile(fs, makefilePath, []byte(makefile), 0644)).To(Succeed())
+		opts := defaultImageOptions()
+		opts.MakefileImages = true
+		_, err := replaceImages(machinery.Filesystem{FS: fs}, opts)
+		Expect(err).NotTo(HaveOccurred())
+		b, err := afero.ReadFile(fs, makefilePath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(Equal("OPM_IMG ?= registry.redhat.io/openshift4/ose-operator-registry:v" + ocpProductVersion +
+			" # was quay.io/operator-framework/opm:v1.27.0\n"))
+	})
+
+	It("leaves the Makefile unchanged by default", func() {
+		_, err := replaceImages(machinery.Filesystem{FS: fs}, defaultImageOptions())
+		Expect(err).NotTo(HaveOccurred())
+		b, err := afero.ReadFile(fs, makefilePath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(Equal(makefileWithToolImages))
+	})
+})
+
+const makefileWithToolImages = `OPM_IMG ?= quay.io/operator-framework/opm:v1.28.0
+export KUBECTL_IMG := bitnami/kubectl:1.27
+PINNED_IMG = quay.io/operator-framework/opm@sha256:abc123
+
+catalog-build:
+	docker run quay.io/operator-framework/opm:v1.28.0 index add
+`
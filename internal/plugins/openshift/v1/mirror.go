@@ -0,0 +1,89 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"sigs.k8s.io/kubebuilder/v3/pkg/config"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+
+	"github.com/operator-framework/operator-sdk/internal/plugins/openshift/v1/templates/config/openshift"
+)
+
+// mirroredRepositories returns the Red Hat repositories of images that replaced upstream images
+// in results and their mirrors in opts.Registry, sorted by source repository.
+func mirroredRepositories(opts Options, results []SubstitutionResult) []openshift.RepositoryMirror {
+	// Substitutions rendered without a registry or repository overrides reveal the Red Hat repository
+	// of each result's image. Overrides do not enable or disable substitutions, so both renderings
+	// have the same substitutions in the same order.
+	unmirrored := opts
+	unmirrored.Registry, unmirrored.ImageNamespace, unmirrored.ImageNames = "", "", nil
+	substs, mirrored := BuildSubstitutions(unmirrored), BuildSubstitutions(opts)
+
+	seen := map[string]bool{}
+	var mirrors []openshift.RepositoryMirror
+	for _, result := range results {
+		if result.Count == 0 {
+			continue
+		}
+		mirroredSubsts := pathSubstitutions(mirrored, result.Path)
+		for i, subst := range pathSubstitutions(substs, result.Path) {
+			if subst.FromTagRE.String() != result.Pattern {
+				continue
+			}
+			source := imageName(subst.ToTag)
+			if isRedHatImage(source) && !seen[source] {
+				seen[source] = true
+				mirrors = append(mirrors, openshift.RepositoryMirror{Source: source, Mirror: imageName(mirroredSubsts[i].ToTag)})
+			}
+		}
+	}
+	sort.Slice(mirrors, func(i, j int) bool { return mirrors[i].Source < mirrors[j].Source })
+	return mirrors
+}
+
+// isRedHatImage reports whether image is in a Red Hat registry.
+func isRedHatImage(image string) bool {
+	for _, host := range append(redHatRegistries, redHatAccessRegistry) {
+		if strings.HasPrefix(image, host+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// scaffoldMirrorPolicy scaffolds an ImageContentSourcePolicy mirroring the Red Hat repositories
+// of images substituted in results to opts.Registry.
+func scaffoldMirrorPolicy(fs machinery.Filesystem, c config.Config, opts Options, results []SubstitutionResult) error {
+	mirrors := mirroredRepositories(opts, results)
+	if len(mirrors) == 0 {
+		log.Warnf("No Red Hat images were substituted, skipping ImageContentSourcePolicy scaffolding")
+		return nil
+	}
+	scaffold := machinery.NewScaffold(fs,
+		// NOTE: kubebuilder's default permissions are only for root users
+		machinery.WithDirectoryPermissions(0755),
+		machinery.WithFilePermissions(0644),
+		machinery.WithConfig(c),
+	)
+	if err := scaffold.Execute(&openshift.ImageContentSourcePolicy{Mirrors: mirrors}); err != nil {
+		return fmt.Errorf("error scaffolding ImageContentSourcePolicy: %w", err)
+	}
+	return nil
+}
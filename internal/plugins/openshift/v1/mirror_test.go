@@ -0,0 +1,110 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+	cfgv3 "sigs.k8s.io/kubebuilder/v3/pkg/config/v3"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+
+	"github.com/operator-framework/operator-sdk/internal/plugins/openshift/v1/templates/config/openshift"
+)
+
+var _ = Describe("Mirror policy", func() {
+	var fs machinery.Filesystem
+
+	BeforeEach(func() {
+		fs = machinery.Filesystem{FS: afero.NewMemMapFs()}
+		Expect(afero.WriteFile(fs.FS, "Dockerfile", []byte(dockerfileAll), 0644)).To(Succeed())
+		Expect(afero.WriteFile(fs.FS, authProxyPatchPath, []byte(proxyPatch), 0644)).To(Succeed())
+	})
+
+	Describe("mirroredRepositories", func() {
+		It("returns the Red Hat repositories of substituted images", func() {
+			opts := defaultImageOptions()
+			opts.Registry = "mirror.example.com:5000"
+			opts.Disabled = []string{HelmOperatorCategory}
+			results, err := replaceImages(fs, opts)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(mirroredRepositories(opts.Options, results)).To(Equal([]openshift.RepositoryMirror{
+				{Source: "registry.access.redhat.com/ubi8/ubi-micro", Mirror: "mirror.example.com:5000/ubi8/ubi-micro"},
+				{Source: "registry.access.redhat.com/ubi8/ubi-minimal", Mirror: "mirror.example.com:5000/ubi8/ubi-minimal"},
+				{Source: "registry.redhat.io/openshift4/ose-ansible-operator", Mirror: "mirror.example.com:5000/openshift4/ose-ansible-operator"},
+				{Source: "registry.redhat.io/openshift4/ose-kube-rbac-proxy", Mirror: "mirror.example.com:5000/openshift4/ose-kube-rbac-proxy"},
+			}))
+		})
+
+		It("mirrors repositories to their overridden namespace and names", func() {
+			opts := defaultImageOptions()
+			opts.Registry, opts.ImageNamespace = "mirror.example.com", "mirror"
+			opts.ImageNames = map[string]string{KubeRBACProxyCategory: "kube-rbac-proxy"}
+			opts.Disabled = []string{HelmOperatorCategory, UBIMinimalCategory, UBIMicroCategory}
+			results, err := replaceImages(fs, opts)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(mirroredRepositories(opts.Options, results)).To(Equal([]openshift.RepositoryMirror{
+				{Source: "registry.redhat.io/openshift4/ose-ansible-operator", Mirror: "mirror.example.com/mirror/ose-ansible-operator"},
+				{Source: "registry.redhat.io/openshift4/ose-kube-rbac-proxy", Mirror: "mirror.example.com/mirror/kube-rbac-proxy"},
+			}))
+		})
+
+		It("returns no repositories if nothing was substituted", func() {
+			opts := defaultImageOptions()
+			opts.Registry = "mirror.example.com"
+			Expect(mirroredRepositories(opts.Options, nil)).To(BeEmpty())
+		})
+	})
+
+	Describe("init", func() {
+		It("scaffolds an ImageContentSourcePolicy for the registry", func() {
+			c := cfgv3.New()
+			Expect(c.SetProjectName("memcached-operator")).To(Succeed())
+			s := &initSubcommand{options: imageOptions{Options: Options{Registry: "mirror.example.com"}}, withMirrorPolicy: true}
+			Expect(s.InjectConfig(c)).To(Succeed())
+			Expect(s.PreScaffold(fs)).To(Succeed())
+			Expect(s.Scaffold(fs)).To(Succeed())
+
+			policyOut, err := afero.ReadFile(fs.FS, "config/openshift/imagecontentsourcepolicy.yaml")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(policyOut)).To(ContainSubstring(`kind: ImageContentSourcePolicy
+metadata:
+  name: memcached-operator-mirrors
+spec:
+  repositoryDigestMirrors:
+  - mirrors:
+    - mirror.example.com/ubi8/ubi-micro
+    source: registry.access.redhat.com/ubi8/ubi-micro
+  - mirrors:
+    - mirror.example.com/ubi8/ubi-minimal
+    source: registry.access.redhat.com/ubi8/ubi-minimal
+  - mirrors:
+    - mirror.example.com/openshift4/ose-ansible-operator
+    source: registry.redhat.io/openshift4/ose-ansible-operator
+  - mirrors:
+    - mirror.example.com/openshift4/ose-helm-operator
+    source: registry.redhat.io/openshift4/ose-helm-operator
+  - mirrors:
+    - mirror.example.com/openshift4/ose-kube-rbac-proxy
+    source: registry.redhat.io/openshift4/ose-kube-rbac-proxy
+`))
+		})
+
+		It("requires a registry", func() {
+			s := &initSubcommand{withMirrorPolicy: true}
+			Expect(s.PreScaffold(fs)).To(MatchError(ContainSubstring("--" + registryFlag)))
+		})
+	})
+})
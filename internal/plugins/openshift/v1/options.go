@@ -0,0 +1,159 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+// Option sets a field of Options.
+type Option func(*Options)
+
+// NewOptions returns DefaultOptions with opts applied in order. Unless an option sets the UBI version,
+// it is the UBI version known to work with the resulting OCP version and UBI major version.
+func NewOptions(opts ...Option) Options {
+	o := DefaultOptions()
+	o.UBIVersion = ""
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.UBIVersion == "" {
+		o.UBIVersion = ubiVersionForOCP(o.OCPVersion, o.UBIMajor)
+	}
+	return o
+}
+
+// ReplaceImagesWithOptions replaces upstream images in the project in fs with their downstream
+// (OpenShift) equivalents as configured by NewOptions(opts...), and reports the result of each substitution.
+func ReplaceImagesWithOptions(fs machinery.Filesystem, opts ...Option) ([]SubstitutionResult, error) {
+	o := imageOptions{Options: NewOptions(opts...)}
+	if err := newConfig(o).validate(); err != nil {
+		return nil, fmt.Errorf("invalid options: %v", err)
+	}
+	return replaceImages(fs, o)
+}
+
+// WithOCPVersion sets the OCP release version OpenShift images are tagged with, ex. "4.14".
+func WithOCPVersion(version string) Option {
+	return func(o *Options) { o.OCPVersion = version }
+}
+
+// WithUBIVersion sets the version UBI base images are tagged with, ex. "8.8".
+func WithUBIVersion(version string) Option {
+	return func(o *Options) { o.UBIVersion = version }
+}
+
+// WithUBIMajor sets the major version of UBI base images, either 8 or 9.
+func WithUBIMajor(major int) Option {
+	return func(o *Options) { o.UBIMajor = major }
+}
+
+// WithArch appends arch to OpenShift image tags to select single-architecture images.
+func WithArch(arch string) Option {
+	return func(o *Options) { o.Arch = arch }
+}
+
+// WithRegistry replaces the Red Hat registry host of every downstream image with registry.
+func WithRegistry(registry string) Option {
+	return func(o *Options) { o.Registry = registry }
+}
+
+// WithRedHatRegistry sets the Red Hat registry host OpenShift images are pulled from.
+func WithRedHatRegistry(host string) Option {
+	return func(o *Options) { o.RedHatRegistry = host }
+}
+
+// WithImageNamespace replaces the openshift4 repository namespace of OpenShift images with namespace.
+func WithImageNamespace(namespace string) Option {
+	return func(o *Options) { o.ImageNamespace = namespace }
+}
+
+// WithImageName replaces the name of category's OpenShift image with name.
+func WithImageName(category, name string) Option {
+	return func(o *Options) {
+		if o.ImageNames == nil {
+			o.ImageNames = map[string]string{}
+		}
+		o.ImageNames[category] = name
+	}
+}
+
+// WithImage replaces every image of category with image, instead of the computed downstream image.
+func WithImage(category, image string) Option {
+	return func(o *Options) {
+		if o.Images == nil {
+			o.Images = map[string]string{}
+		}
+		o.Images[category] = image
+	}
+}
+
+// WithDisabled disables the built-in substitution categories with keys categories.
+func WithDisabled(categories ...string) Option {
+	return func(o *Options) { o.Disabled = append(o.Disabled, categories...) }
+}
+
+// WithGoBuilderVersion tags golang builder images with version and raises the go.mod go directive to it.
+func WithGoBuilderVersion(version string) Option {
+	return func(o *Options) { o.GoBuilderVersion = version }
+}
+
+// WithNoGoModEdit leaves the go.mod go directive unchanged even if a Go builder version is set.
+func WithNoGoModEdit() Option {
+	return func(o *Options) { o.NoGoModEdit = true }
+}
+
+// WithFIPS replaces golang builder images with FIPS capable UBI go-toolset images.
+func WithFIPS() Option {
+	return func(o *Options) { o.FIPS = true }
+}
+
+// WithMakefileImages replaces tool images assigned to Makefile variables.
+func WithMakefileImages() Option {
+	return func(o *Options) { o.MakefileImages = true }
+}
+
+// WithRewriteDigests replaces images pinned by digest like other images.
+func WithRewriteDigests() Option {
+	return func(o *Options) { o.RewriteDigests = true }
+}
+
+// WithRBACProxyVersion sets the OCP release version kube-rbac-proxy images are tagged with, ex. "4.13",
+// if not the OCP version.
+func WithRBACProxyVersion(version string) Option {
+	return func(o *Options) { o.RBACProxyVersion = version }
+}
+
+// WithGoBaseImage sets the UBI image that replaces the distroless runtime base image of Go operators.
+func WithGoBaseImage(image string) Option {
+	return func(o *Options) { o.GoBaseImage = image }
+}
+
+// WithProjectType limits substitutions of project type-specific images to projects of type t.
+func WithProjectType(t string) Option {
+	return func(o *Options) { o.ProjectType = t }
+}
+
+// WithFiles applies every built-in substitution to the additional files.
+func WithFiles(files ...string) Option {
+	return func(o *Options) { o.Files = append(o.Files, files...) }
+}
+
+// WithKeepRegistries leaves images of registries unchanged.
+func WithKeepRegistries(registries ...string) Option {
+	return func(o *Options) { o.KeepRegistries = append(o.KeepRegistries, registries...) }
+}
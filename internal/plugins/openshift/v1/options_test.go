@@ -0,0 +1,94 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+var _ = Describe("NewOptions", func() {
+	It("returns the default options without any option", func() {
+		Expect(NewOptions()).To(Equal(DefaultOptions()))
+	})
+
+	It("applies each option in order", func() {
+		opts := NewOptions(
+			WithOCPVersion("4.14"),
+			WithRegistry("mirror.example.com"),
+			WithArch("arm64"),
+			WithImageName(KubeRBACProxyCategory, "kube-rbac-proxy"),
+			WithImage(UBIMinimalCategory, "mirror.example.com/base:1"),
+			WithDisabled(HelmOperatorCategory),
+			WithDisabled(AnsibleOperatorCategory),
+			WithKeepRegistries("quay.io"),
+			WithFIPS(),
+		)
+		Expect(opts).To(Equal(Options{
+			OCPVersion:     "4.14",
+			UBIVersion:     ubiVersionForOCP("4.14", 8),
+			UBIMajor:       8,
+			Arch:           "arm64",
+			Registry:       "mirror.example.com",
+			ImageNames:     map[string]string{KubeRBACProxyCategory: "kube-rbac-proxy"},
+			Images:         map[string]string{UBIMinimalCategory: "mirror.example.com/base:1"},
+			Disabled:       []string{HelmOperatorCategory, AnsibleOperatorCategory},
+			FIPS:           true,
+			KeepRegistries: []string{"quay.io"},
+		}))
+	})
+
+	It("defaults the UBI version to the version known to work with the OCP version and UBI major version", func() {
+		Expect(NewOptions(WithUBIMajor(9), WithOCPVersion("4.14")).UBIVersion).To(Equal(ubiVersionForOCP("4.14", 9)))
+		Expect(NewOptions(WithUBIVersion("8.6"), WithOCPVersion("4.14")).UBIVersion).To(Equal("8.6"))
+	})
+
+	It("configures BuildSubstitutions", func() {
+		substs := BuildSubstitutions(NewOptions(WithOCPVersion("4.13"), WithRegistry("mirror.example.com")))
+		Expect(substs[authProxyPatchPath][0].ToTag).To(Equal("mirror.example.com/openshift4/ose-kube-rbac-proxy:v4.13"))
+	})
+})
+
+var _ = Describe("ReplaceImagesWithOptions", func() {
+	var fs machinery.Filesystem
+
+	BeforeEach(func() {
+		fs = machinery.Filesystem{FS: afero.NewMemMapFs()}
+		Expect(afero.WriteFile(fs.FS, "Dockerfile", []byte("FROM gcr.io/distroless/static:nonroot\n"), 0644)).To(Succeed())
+	})
+
+	It("replaces images as configured by the options", func() {
+		results, err := ReplaceImagesWithOptions(fs, WithUBIMajor(9), WithGoBaseImage("ubi-micro"), WithRegistry("mirror.example.com"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(totalCount(results)).To(Equal(1))
+		b, err := afero.ReadFile(fs.FS, "Dockerfile")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(Equal("FROM mirror.example.com/ubi9/ubi-micro:" + ubiVersionForOCP(ocpProductVersion, 9) + "\n"))
+	})
+
+	It("fails without changing any file if the options are invalid", func() {
+		_, err := ReplaceImagesWithOptions(fs, WithOCPVersion("latest"))
+		Expect(err).To(MatchError(ContainSubstring(`invalid options: ocpVersion "latest"`)))
+		_, err = ReplaceImagesWithOptions(fs, WithUBIMajor(7))
+		Expect(err).To(MatchError("invalid options: ubiMajor 7 must be 8 or 9"))
+		_, err = ReplaceImagesWithOptions(fs, WithImage(UBIMinimalCategory, "ubi-minimal"))
+		Expect(err).To(MatchError(ContainSubstring(`images value "ubi-minimal"`)))
+		b, err := afero.ReadFile(fs.FS, "Dockerfile")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(Equal("FROM gcr.io/distroless/static:nonroot\n"))
+	})
+})
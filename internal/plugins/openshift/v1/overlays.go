@@ -0,0 +1,106 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+)
+
+// overlaysDir is the directory kustomize overlays, ex. config/overlays/prod, are detected in.
+var overlaysDir = filepath.Join("config", "overlays")
+
+// overlayDirs returns opts.overlays, or the directories in overlaysDir of fs if none are set.
+func overlayDirs(fs afero.Fs, opts imageOptions) ([]string, error) {
+	if len(opts.overlays) > 0 {
+		dirs := make([]string, 0, len(opts.overlays))
+		for _, dir := range opts.overlays {
+			dir = filepath.Clean(dir)
+			if isDir, err := afero.IsDir(fs, dir); err != nil || !isDir {
+				return nil, fmt.Errorf("invalid --%s value %q: must be a directory of the project", overlayFlag, dir)
+			}
+			dirs = append(dirs, dir)
+		}
+		return dirs, nil
+	}
+	infos, err := afero.ReadDir(fs, overlaysDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("error finding overlays in %s: %v", overlaysDir, err)
+	}
+	var dirs []string
+	for _, info := range infos {
+		if info.IsDir() {
+			dirs = append(dirs, filepath.Join(overlaysDir, info.Name()))
+		}
+	}
+	return dirs, nil
+}
+
+// overlaySubstitutions returns substs with substitutions added for each YAML file of the kustomize
+// overlays of fs, so that every environment's patches reference downstream images like the base config.
+// The built-in substitutions, which are reversed if opts.reverse is set, and ad-hoc substitutions that
+// match each file are added. Files in substs are left as they are, since their substitutions are already known.
+func overlaySubstitutions(fs afero.Fs, opts imageOptions, substs map[string][]Substitution) (map[string][]Substitution, error) {
+	if len(opts.paths) > 0 {
+		return substs, nil
+	}
+	dirs, err := overlayDirs(fs, opts)
+	if err != nil || len(dirs) == 0 {
+		return substs, err
+	}
+
+	builtin := BuildSubstitutions(opts.Options)
+	if opts.reverse {
+		builtin = reverseSubstitutions(opts.Options, opts.upstreamTag)
+	}
+	candidates := append(distinctSubstitutions(builtin), withKeepRegistries(opts.adHocSubstitutions, opts.KeepRegistries)...)
+
+	withOverlays := map[string][]Substitution{}
+	for filePath, fileSubsts := range substs {
+		withOverlays[filePath] = fileSubsts
+	}
+	for _, dir := range dirs {
+		err := afero.Walk(fs, dir, func(filePath string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() || !isYAMLFile(filePath) {
+				return nil
+			}
+			if _, ok := withOverlays[filePath]; ok {
+				return nil
+			}
+			b, err := afero.ReadFile(fs, filePath)
+			if err != nil {
+				return err
+			}
+			for _, subst := range candidates {
+				if subst.FromTagRE.Match(b) {
+					withOverlays[filePath] = append(withOverlays[filePath], subst)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error finding overlay files to substitute images in: %v", err)
+		}
+	}
+	return withOverlays, nil
+}
@@ -0,0 +1,86 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+var _ = Describe("overlaySubstitutions", func() {
+	var fs machinery.Filesystem
+
+	const (
+		prodPatchPath = "config/overlays/prod/manager_patch.yaml"
+		devPatchPath  = "config/overlays/dev/patches/manager_patch.yaml"
+		stagingPath   = "deploy/staging/manager_patch.yaml"
+		patch         = "image: gcr.io/kubebuilder/kube-rbac-proxy:v0.13.1\n"
+		patchExp      = "image: registry.redhat.io/openshift4/ose-kube-rbac-proxy:v" + ocpProductVersion + "\n"
+	)
+
+	BeforeEach(func() {
+		fs = machinery.Filesystem{FS: afero.NewMemMapFs()}
+		Expect(afero.WriteFile(fs.FS, prodPatchPath, []byte(patch), 0644)).To(Succeed())
+		Expect(afero.WriteFile(fs.FS, devPatchPath, []byte(patch), 0644)).To(Succeed())
+		Expect(afero.WriteFile(fs.FS, "config/overlays/dev/kustomization.yaml", []byte("resources:\n- ../../default\n"), 0644)).To(Succeed())
+		Expect(afero.WriteFile(fs.FS, stagingPath, []byte(patch), 0644)).To(Succeed())
+	})
+
+	readFile := func(filePath string) string {
+		b, err := afero.ReadFile(fs.FS, filePath)
+		Expect(err).NotTo(HaveOccurred())
+		return string(b)
+	}
+
+	It("substitutes images in every overlay in config/overlays by default", func() {
+		results, err := replaceImages(fs, defaultImageOptions())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(readFile(prodPatchPath)).To(Equal(patchExp))
+		Expect(readFile(devPatchPath)).To(Equal(patchExp))
+		Expect(readFile(stagingPath)).To(Equal(patch))
+		for _, result := range results {
+			Expect(result.Path).NotTo(Equal("config/overlays/dev/kustomization.yaml"))
+		}
+	})
+
+	It("substitutes images in only the given overlays", func() {
+		opts := defaultImageOptions()
+		opts.overlays = []string{"./deploy/staging", "config/overlays/prod"}
+		_, err := replaceImages(fs, opts)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(readFile(prodPatchPath)).To(Equal(patchExp))
+		Expect(readFile(devPatchPath)).To(Equal(patch))
+		Expect(readFile(stagingPath)).To(Equal(patchExp))
+	})
+
+	It("restores upstream images in overlays when reversing substitutions", func() {
+		_, err := replaceImages(fs, defaultImageOptions())
+		Expect(err).NotTo(HaveOccurred())
+		opts := defaultImageOptions()
+		opts.reverse, opts.upstreamTag = true, "v1.31.0"
+		_, err = replaceImages(fs, opts)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(readFile(prodPatchPath)).To(Equal("image: gcr.io/kubebuilder/kube-rbac-proxy:" + upstreamRBACProxyTag + "\n"))
+	})
+
+	It("fails if a given overlay is not a directory", func() {
+		opts := defaultImageOptions()
+		opts.overlays = []string{"config/overlays/staging"}
+		_, err := replaceImages(fs, opts)
+		Expect(err).To(MatchError(ContainSubstring(`invalid --` + overlayFlag + ` value "config/overlays/staging"`)))
+	})
+})
@@ -0,0 +1,129 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"bytes"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+	"github.com/spf13/pflag"
+	cfgv3 "sigs.k8s.io/kubebuilder/v3/pkg/config/v3"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+var _ = Describe("passthrough mode", func() {
+	var fs machinery.Filesystem
+
+	BeforeEach(func() {
+		fs = machinery.Filesystem{FS: afero.NewMemMapFs()}
+		Expect(afero.WriteFile(fs.FS, "Dockerfile", []byte(dockerfileAll), 0644)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		Expect(os.Unsetenv(passthroughEnv)).To(Succeed())
+	})
+
+	initProject := func(args ...string) (*initSubcommand, *bytes.Buffer) {
+		out := &bytes.Buffer{}
+		s := &initSubcommand{options: imageOptions{out: out}}
+		flags := pflag.NewFlagSet("init", pflag.ContinueOnError)
+		s.BindFlags(flags)
+		Expect(flags.Parse(args)).To(Succeed())
+		Expect(s.InjectConfig(cfgv3.New())).To(Succeed())
+		Expect(s.PreScaffold(fs)).To(Succeed())
+		Expect(s.Scaffold(fs)).To(Succeed())
+		return s, out
+	}
+
+	readDockerfile := func() string {
+		b, err := afero.ReadFile(fs.FS, "Dockerfile")
+		Expect(err).NotTo(HaveOccurred())
+		return string(b)
+	}
+
+	It("substitutes no image with --passthrough, but records the plugin config", func() {
+		s, out := initProject("--"+passthroughFlag, "--"+outputFlag, jsonOutput, "--"+ocpVersionFlag, "4.14")
+		Expect(readDockerfile()).To(Equal(dockerfileAll))
+		Expect(out.String()).To(Equal("[]\n"))
+		Expect(s.Report()).To(Equal(ScaffoldReport{Created: []string{}, Modified: []string{}}))
+		cfg, err := decodeConfig(s.config)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg.OCPVersion).To(Equal("4.14"))
+	})
+
+	It("changes no file rewritten by later plugins in the chain, even with --fips", func() {
+		Expect(afero.WriteFile(fs.FS, "Dockerfile", []byte(goDockerfile), 0644)).To(Succeed())
+		cfg := cfgv3.New()
+		Expect(cfg.SetPluginChain([]string{"go.kubebuilder.io/v4", pluginKey, "example.com/v1"})).To(Succeed())
+		s := &initSubcommand{}
+		flags := pflag.NewFlagSet("init", pflag.ContinueOnError)
+		s.BindFlags(flags)
+		Expect(flags.Parse([]string{"--" + passthroughFlag, "--" + fipsFlag})).To(Succeed())
+		Expect(s.InjectConfig(cfg)).To(Succeed())
+		Expect(s.PreScaffold(fs)).To(Succeed())
+		Expect(s.Scaffold(fs)).To(Succeed())
+
+		// A later plugin in the chain scaffolds the Dockerfile again.
+		Expect(afero.WriteFile(fs.FS, "Dockerfile", []byte(goDockerfile), 0644)).To(Succeed())
+		Expect(s.PostScaffold()).To(Succeed())
+		Expect(readDockerfile()).To(Equal(goDockerfile))
+		Expect(s.Report()).To(Equal(ScaffoldReport{Created: []string{}, Modified: []string{}}))
+	})
+
+	It("is enabled for init by $"+passthroughEnv+" unless --passthrough=false is given", func() {
+		Expect(os.Setenv(passthroughEnv, "true")).To(Succeed())
+		initProject()
+		Expect(readDockerfile()).To(Equal(dockerfileAll))
+		initProject("--" + passthroughFlag + "=false")
+		Expect(readDockerfile()).To(ContainSubstring(dockerfileAllExp))
+	})
+
+	It("is enabled for other subcommands by $"+passthroughEnv, func() {
+		Expect(os.Setenv(passthroughEnv, "true")).To(Succeed())
+		c := cfgv3.New()
+		Expect(c.EncodePluginConfig(pluginKey, Config{OCPVersion: "4.15", UBIVersion: "9.2", UBIMajor: 9})).To(Succeed())
+		api := &createAPISubcommand{}
+		Expect(api.InjectConfig(c)).To(Succeed())
+		Expect(api.Scaffold(fs)).To(Succeed())
+		Expect(readDockerfile()).To(Equal(dockerfileAll))
+
+		edit := &editSubcommand{ocpVersion: "4.16"}
+		Expect(edit.InjectConfig(c)).To(Succeed())
+		Expect(edit.Scaffold(fs)).To(Succeed())
+		cfg, err := decodeConfig(c)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg.OCPVersion).To(Equal("4.15"))
+	})
+
+	It("reads $"+passthroughEnv+" as a boolean", func() {
+		cases := []struct {
+			value       string
+			passthrough bool
+		}{
+			{"true", true},
+			{"1", true},
+			{"false", false},
+			{"", false},
+			{"yes", false},
+		}
+		for _, c := range cases {
+			Expect(os.Setenv(passthroughEnv, c.value)).To(Succeed())
+			Expect(passthroughFromEnv()).To(Equal(c.passthrough), c.value)
+		}
+	})
+})
@@ -15,6 +15,13 @@
 package v1
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
 	"sigs.k8s.io/kubebuilder/v3/pkg/config"
 	cfgv2 "sigs.k8s.io/kubebuilder/v3/pkg/config/v2"
 	cfgv3 "sigs.k8s.io/kubebuilder/v3/pkg/config/v3"
@@ -30,18 +37,274 @@ var (
 )
 
 var (
-	_ plugin.Plugin = Plugin{}
-	_ plugin.Init   = Plugin{}
+	_ plugin.Plugin        = Plugin{}
+	_ plugin.Init          = Plugin{}
+	_ plugin.CreateAPI     = Plugin{}
+	_ plugin.CreateWebhook = Plugin{}
+	_ plugin.Edit          = Plugin{}
 )
 
 type Plugin struct {
 	initSubcommand
+	createAPISubcommand
+	createWebhookSubcommand
+	editSubcommand
+}
+
+func (Plugin) Name() string                                         { return pluginName }
+func (Plugin) Version() plugin.Version                              { return pluginVersion }
+func (Plugin) SupportedProjectVersions() []config.Version           { return supportedProjectVersions }
+func (p Plugin) GetInitSubcommand() plugin.InitSubcommand           { return &p.initSubcommand }
+func (p Plugin) GetCreateAPISubcommand() plugin.CreateAPISubcommand { return &p.createAPISubcommand }
+func (p Plugin) GetCreateWebhookSubcommand() plugin.CreateWebhookSubcommand {
+	return &p.createWebhookSubcommand
 }
+func (p Plugin) GetEditSubcommand() plugin.EditSubcommand { return &p.editSubcommand }
 
-func (Plugin) Name() string                               { return pluginName }
-func (Plugin) Version() plugin.Version                    { return pluginVersion }
-func (Plugin) SupportedProjectVersions() []config.Version { return supportedProjectVersions }
-func (p Plugin) GetInitSubcommand() plugin.InitSubcommand { return &p.initSubcommand }
+// WithContext returns a copy of p whose subcommands stop resolving versions, substituting images,
+// scanning, and checking images once ctx is canceled.
+func (p Plugin) WithContext(ctx context.Context) Plugin {
+	p.initSubcommand.options.ctx = ctx
+	p.createAPISubcommand.ctx = ctx
+	p.createWebhookSubcommand.ctx = ctx
+	p.editSubcommand.ctx = ctx
+	return p
+}
 
 // Config configures this plugin, and is saved in the project config file.
-type Config struct{}
+type Config struct {
+	// OCPVersion is the OCP release version downstream images were tagged with.
+	OCPVersion string `json:"ocpVersion,omitempty"`
+	// UBIVersion is the version UBI base images were tagged with.
+	UBIVersion string `json:"ubiVersion,omitempty"`
+	// UBIMajor is the major version of UBI base images.
+	UBIMajor int `json:"ubiMajor,omitempty"`
+	// Arch is the architecture appended to downstream OpenShift image tags, if any.
+	Arch string `json:"arch,omitempty"`
+	// Registry is the registry host that replaced Red Hat registry hosts, if any.
+	Registry string `json:"registry,omitempty"`
+	// RedHatRegistry is the Red Hat registry host OpenShift images were pulled from, if not registry.redhat.io.
+	RedHatRegistry string `json:"redHatRegistry,omitempty"`
+	// ImageNamespace is the repository namespace that replaced openshift4 in OpenShift images, if any.
+	ImageNamespace string `json:"imageNamespace,omitempty"`
+	// ImageNames map substitution categories to the names that replaced their OpenShift image's name, if any.
+	ImageNames map[string]string `json:"imageNames,omitempty"`
+	// Images map substitution categories to the images that replaced their computed images, if any.
+	Images map[string]string `json:"images,omitempty"`
+	// Disabled are the keys of substitution categories that were not applied.
+	Disabled []string `json:"disabled,omitempty"`
+	// GoBuilderVersion is the version golang builder images were tagged with, if any.
+	GoBuilderVersion string `json:"goBuilderVersion,omitempty"`
+	// NoGoModEdit records that the go.mod go directive must be left unchanged.
+	NoGoModEdit bool `json:"noGoModEdit,omitempty"`
+	// FIPS records that golang builder images were replaced by FIPS capable go-toolset images.
+	FIPS bool `json:"fips,omitempty"`
+	// MakefileImages records that tool images assigned to Makefile variables were replaced.
+	MakefileImages bool `json:"makefileImages,omitempty"`
+	// RewriteDigests records that images pinned by digest were replaced too.
+	RewriteDigests bool `json:"rewriteDigests,omitempty"`
+	// RBACProxyVersion is the OCP release version kube-rbac-proxy images were tagged with, if not OCPVersion.
+	RBACProxyVersion string `json:"rbacProxyVersion,omitempty"`
+	// GoBaseImage is the UBI image that replaced distroless runtime base images, if not ubi-minimal.
+	GoBaseImage string `json:"goBaseImage,omitempty"`
+	// Reversed records that upstream images were restored, so downstream images are not substituted
+	// until images are substituted again with the edit subcommand.
+	Reversed bool `json:"reversed,omitempty"`
+	// Files are the additional paths images were substituted in.
+	Files []string `json:"files,omitempty"`
+	// KeepRegistries are the registry hosts whose images were not replaced.
+	KeepRegistries []string `json:"keepRegistries,omitempty"`
+	// Channel is the OLM channel the operator's bundle is published to, if recorded.
+	Channel string `json:"channel,omitempty"`
+}
+
+// newConfig returns a Config recording opts.
+func newConfig(opts imageOptions) Config {
+	return Config{
+		OCPVersion: opts.OCPVersion,
+		UBIVersion: opts.UBIVersion,
+		UBIMajor:   opts.UBIMajor,
+		Arch:       opts.Arch,
+		Registry:   opts.Registry,
+		Disabled:   opts.Disabled,
+
+		GoBuilderVersion: opts.GoBuilderVersion,
+		NoGoModEdit:      opts.NoGoModEdit,
+		FIPS:             opts.FIPS,
+		MakefileImages:   opts.MakefileImages,
+		RewriteDigests:   opts.RewriteDigests,
+		RBACProxyVersion: opts.RBACProxyVersion,
+		GoBaseImage:      opts.GoBaseImage,
+		RedHatRegistry:   opts.RedHatRegistry,
+		ImageNamespace:   opts.ImageNamespace,
+		ImageNames:       opts.ImageNames,
+		Images:           opts.Images,
+		Files:            opts.Files,
+		KeepRegistries:   opts.KeepRegistries,
+		Channel:          opts.Channel,
+	}
+}
+
+// imageOptions returns the imageOptions recorded by cfg.
+func (cfg Config) imageOptions() imageOptions {
+	return imageOptions{Options: Options{
+		OCPVersion: cfg.OCPVersion,
+		UBIVersion: cfg.UBIVersion,
+		UBIMajor:   cfg.UBIMajor,
+		Arch:       cfg.Arch,
+		Registry:   cfg.Registry,
+		Disabled:   cfg.Disabled,
+
+		GoBuilderVersion: cfg.GoBuilderVersion,
+		NoGoModEdit:      cfg.NoGoModEdit,
+		FIPS:             cfg.FIPS,
+		MakefileImages:   cfg.MakefileImages,
+		RewriteDigests:   cfg.RewriteDigests,
+		RBACProxyVersion: cfg.RBACProxyVersion,
+		GoBaseImage:      cfg.GoBaseImage,
+		RedHatRegistry:   cfg.RedHatRegistry,
+		ImageNamespace:   cfg.ImageNamespace,
+		ImageNames:       cfg.ImageNames,
+		Images:           cfg.Images,
+		Files:            cfg.Files,
+		KeepRegistries:   cfg.KeepRegistries,
+		Channel:          cfg.Channel,
+	}}
+}
+
+// Validate returns an error if cfg records settings that would produce broken image
+// references, such as those of a hand-edited project config.
+func (cfg Config) Validate() error {
+	if err := cfg.validate(); err != nil {
+		return fmt.Errorf("invalid plugin config for %s: %v", pluginKey, err)
+	}
+	return nil
+}
+
+func (cfg Config) validate() error {
+	if !ocpVersionRE.MatchString(cfg.OCPVersion) {
+		return fmt.Errorf("ocpVersion %q must be of the form <major>.<minor>, ex. %s", cfg.OCPVersion, ocpProductVersion)
+	}
+	if cfg.RBACProxyVersion != "" && !ocpVersionRE.MatchString(cfg.RBACProxyVersion) {
+		return fmt.Errorf("rbacProxyVersion %q must be of the form <major>.<minor>, ex. %s", cfg.RBACProxyVersion, ocpProductVersion)
+	}
+	if cfg.UBIMajor != 8 && cfg.UBIMajor != 9 {
+		return fmt.Errorf("ubiMajor %d must be 8 or 9", cfg.UBIMajor)
+	}
+	m := ubiVersionRE.FindStringSubmatch(cfg.UBIVersion)
+	if m == nil {
+		return fmt.Errorf("ubiVersion %q must be of the form <major>.<minor>, ex. %s", cfg.UBIVersion, ubiMinimalVersion)
+	}
+	if m[1] != strconv.Itoa(cfg.UBIMajor) {
+		return fmt.Errorf("ubiVersion %q is not a UBI %d version, set by ubiMajor", cfg.UBIVersion, cfg.UBIMajor)
+	}
+	if cfg.Registry != "" && !registryHostRE.MatchString(cfg.Registry) {
+		return fmt.Errorf("registry %q must be a registry host, ex. mirror.example.com:5000", cfg.Registry)
+	}
+	if cfg.RedHatRegistry != "" && !contains(redHatRegistries, cfg.RedHatRegistry) {
+		return fmt.Errorf("redHatRegistry %q must be one of %s", cfg.RedHatRegistry, strings.Join(redHatRegistries, ", "))
+	}
+	if cfg.RedHatRegistry != "" && cfg.Registry != "" {
+		return fmt.Errorf("redHatRegistry and registry are mutually exclusive")
+	}
+	if cfg.Arch != "" && !contains(supportedArches, cfg.Arch) {
+		return fmt.Errorf("arch %q must be one of %s", cfg.Arch, strings.Join(supportedArches, ", "))
+	}
+	for _, registry := range cfg.KeepRegistries {
+		if !registryHostRE.MatchString(registry) {
+			return fmt.Errorf("keepRegistries entry %q must be a registry host, ex. quay.io", registry)
+		}
+	}
+	if cfg.ImageNamespace != "" && !repositoryPathRE.MatchString(cfg.ImageNamespace) {
+		return fmt.Errorf("imageNamespace %q must be a repository path, ex. %s", cfg.ImageNamespace, defaultImageNamespace)
+	}
+	for key, name := range cfg.ImageNames {
+		if !contains(openShiftCategories, key) {
+			return fmt.Errorf("imageNames key %q must be one of %s", key, strings.Join(openShiftCategories, ", "))
+		}
+		if !repositoryPathRE.MatchString(name) || strings.Contains(name, "/") {
+			return fmt.Errorf("imageNames value %q for %s must be a repository name", name, key)
+		}
+	}
+	for key, image := range cfg.Images {
+		if !contains(Categories, key) {
+			return fmt.Errorf("images key %q must be one of %s", key, strings.Join(Categories, ", "))
+		}
+		if !isTaggedImage(image) {
+			return fmt.Errorf("images value %q for %s must be an image with a tag or digest, ex. mirror.example.com/%s:latest", image, key, key)
+		}
+	}
+	for _, key := range cfg.Disabled {
+		if !contains(Categories, key) {
+			return fmt.Errorf("disabled entry %q must be one of %s", key, strings.Join(Categories, ", "))
+		}
+	}
+	if cfg.GoBuilderVersion != "" && !goVersionRE.MatchString(cfg.GoBuilderVersion) {
+		return fmt.Errorf("goBuilderVersion %q must be of the form <major>.<minor>[.<patch>], ex. %s", cfg.GoBuilderVersion, defaultGoBuilderVersion)
+	}
+	if cfg.GoBaseImage != "" && !contains(goBaseImages, cfg.GoBaseImage) {
+		return fmt.Errorf("goBaseImage %q must be one of %s", cfg.GoBaseImage, strings.Join(goBaseImages, ", "))
+	}
+	for _, filePath := range cfg.Files {
+		if !isProjectPath(filePath) {
+			return fmt.Errorf("files entry %q must be a path relative to the project root", filePath)
+		}
+	}
+	if cfg.Channel != "" && !channelRE.MatchString(cfg.Channel) {
+		return fmt.Errorf("channel %q must be a channel name, ex. %s", cfg.Channel, defaultChannel)
+	}
+	return nil
+}
+
+// decodeConfig reads this plugin's Config from c. Projects that predate a field,
+// have no plugin config section, or whose project version does not support plugin
+// configs have that field set to its default.
+func decodeConfig(c config.Config) (Config, error) {
+	var cfg Config
+	err := c.DecodePluginConfig(pluginKey, &cfg)
+	if err != nil && !errors.As(err, &config.PluginKeyNotFoundError{}) && !errors.As(err, &config.UnsupportedFieldError{}) {
+		return cfg, fmt.Errorf("error reading plugin config for %s: %v", pluginKey, err)
+	}
+	cfg.setDefaults()
+	return cfg, nil
+}
+
+// migrateConfig reads this plugin's Config from c like decodeConfig. If c records a
+// legacy config, or none at all, the config is upgraded by saving it with defaulted fields.
+func migrateConfig(c config.Config) (Config, error) {
+	var cfg Config
+	err := c.DecodePluginConfig(pluginKey, &cfg)
+	if errors.As(err, &config.UnsupportedFieldError{}) {
+		// Project versions without plugin configs have nothing to upgrade.
+		cfg.setDefaults()
+		return cfg, nil
+	}
+	if err != nil && !errors.As(err, &config.PluginKeyNotFoundError{}) {
+		return cfg, fmt.Errorf("error reading plugin config for %s: %v", pluginKey, err)
+	}
+	if cfg.setDefaults() {
+		log.Infof("Upgrading legacy plugin config for %s", pluginKey)
+		if err := c.EncodePluginConfig(pluginKey, cfg); err != nil {
+			return cfg, fmt.Errorf("error writing plugin config for %s: %v", pluginKey, err)
+		}
+	}
+	return cfg, nil
+}
+
+// setDefaults sets unset fields of cfg to their defaults, and reports whether any were unset.
+func (cfg *Config) setDefaults() bool {
+	changed := false
+	if cfg.OCPVersion == "" {
+		cfg.OCPVersion = ocpProductVersion
+		changed = true
+	}
+	if cfg.UBIMajor == 0 {
+		cfg.UBIMajor = 8
+		changed = true
+	}
+	if cfg.UBIVersion == "" {
+		cfg.UBIVersion = ubiVersionForOCP(cfg.OCPVersion, cfg.UBIMajor)
+		changed = true
+	}
+	return changed
+}
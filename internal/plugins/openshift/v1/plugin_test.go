@@ -0,0 +1,177 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	cfgv2 "sigs.k8s.io/kubebuilder/v3/pkg/config/v2"
+	cfgv3 "sigs.k8s.io/kubebuilder/v3/pkg/config/v3"
+)
+
+var _ = Describe("Plugin", func() {
+	It("threads a context to each subcommand", func() {
+		type key struct{}
+		ctx := context.WithValue(context.Background(), key{}, "value")
+		p := Plugin{}.WithContext(ctx)
+		Expect(p.GetInitSubcommand().(*initSubcommand).options.getContext()).To(Equal(ctx))
+		Expect(p.GetCreateAPISubcommand().(*createAPISubcommand).ctx).To(Equal(ctx))
+		Expect(p.GetCreateWebhookSubcommand().(*createWebhookSubcommand).ctx).To(Equal(ctx))
+		Expect(p.GetEditSubcommand().(*editSubcommand).ctx).To(Equal(ctx))
+	})
+})
+
+var _ = Describe("Config", func() {
+
+	Describe("decodeConfig", func() {
+		It("round-trips image options through the project config", func() {
+			c := cfgv3.New()
+			opts := imageOptions{Options: Options{
+				OCPVersion: "4.15",
+				UBIVersion: "9.2",
+				UBIMajor:   9,
+				Arch:       "arm64",
+				Registry:   "mirror.example.com",
+				Disabled:   []string{HelmOperatorCategory},
+
+				GoBuilderVersion: "1.21",
+				NoGoModEdit:      true,
+				FIPS:             true,
+				MakefileImages:   true,
+				RewriteDigests:   true,
+				GoBaseImage:      "ubi-micro",
+				RedHatRegistry:   redHatConnectRegistry,
+				ImageNamespace:   "mirror",
+				KeepRegistries:   []string{"quay.io"},
+				Channel:          "fast",
+				ImageNames:       map[string]string{KubeRBACProxyCategory: "kube-rbac-proxy"},
+				Images:           map[string]string{UBIMinimalCategory: "mirror.example.com/ubi-minimal:latest"},
+			}}
+			Expect(c.EncodePluginConfig(pluginKey, newConfig(opts))).To(Succeed())
+			b, err := c.MarshalYAML()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(ContainSubstring("ocpVersion: \"4.15\""))
+
+			c = cfgv3.New()
+			Expect(c.UnmarshalYAML(b)).To(Succeed())
+			cfg, err := decodeConfig(c)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.imageOptions()).To(Equal(opts))
+		})
+
+		It("defaults image options when no plugin config was recorded", func() {
+			cfg, err := decodeConfig(cfgv3.New())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.imageOptions()).To(Equal(defaultImageOptions()))
+		})
+
+		It("defaults the OCP version when an older plugin config was recorded", func() {
+			c := cfgv3.New()
+			Expect(c.EncodePluginConfig(pluginKey, Config{})).To(Succeed())
+			cfg, err := decodeConfig(c)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.OCPVersion).To(Equal(ocpProductVersion))
+		})
+
+		It("defaults the OCP version for project versions without plugin configs", func() {
+			cfg, err := decodeConfig(cfgv2.New())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.OCPVersion).To(Equal(ocpProductVersion))
+		})
+	})
+
+	Describe("Validate", func() {
+		valid := Config{OCPVersion: "4.14", UBIVersion: "8.8", UBIMajor: 8}
+
+		It("accepts defaulted and fully set configs", func() {
+			Expect(valid.Validate()).To(Succeed())
+			cfg := valid
+			cfg.RBACProxyVersion, cfg.Registry, cfg.Arch = "4.13", "mirror.example.com:5000", "arm64"
+			Expect(cfg.Validate()).To(Succeed())
+		})
+
+		It("rejects inconsistent configs", func() {
+			cases := []struct {
+				mutate func(*Config)
+				err    string
+			}{
+				{func(c *Config) { c.OCPVersion = "v4.14" }, `ocpVersion "v4.14"`},
+				{func(c *Config) { c.RBACProxyVersion = "latest" }, `rbacProxyVersion "latest"`},
+				{func(c *Config) { c.UBIMajor = 7 }, "ubiMajor 7 must be 8 or 9"},
+				{func(c *Config) { c.UBIVersion = "8" }, `ubiVersion "8" must be of the form`},
+				{func(c *Config) { c.UBIVersion = "9.2" }, `ubiVersion "9.2" is not a UBI 8 version`},
+				{func(c *Config) { c.Registry = "https://mirror.example.com" }, `registry "https://mirror.example.com"`},
+				{func(c *Config) { c.RedHatRegistry = "quay.io" }, `redHatRegistry "quay.io"`},
+				{func(c *Config) { c.Arch = "x86_64" }, `arch "x86_64"`},
+				{func(c *Config) { c.Channel = "stable,fast" }, `channel "stable,fast"`},
+				{func(c *Config) { c.Registry, c.RedHatRegistry = "mirror.example.com", redHatConnectRegistry }, "redHatRegistry and registry are mutually exclusive"},
+				{func(c *Config) { c.ImageNamespace = "openshift4/" }, `imageNamespace "openshift4/"`},
+				{func(c *Config) { c.ImageNames = map[string]string{"unknown": "ose-operator"} }, `imageNames key "unknown"`},
+				{func(c *Config) { c.Images = map[string]string{"unknown": "quay.io/op:v1"} }, `images key "unknown"`},
+				{func(c *Config) { c.Disabled = []string{"unknown"} }, `disabled entry "unknown"`},
+				{func(c *Config) { c.GoBuilderVersion = "go1.21" }, `goBuilderVersion "go1.21"`},
+				{func(c *Config) { c.GoBaseImage = "distroless" }, `goBaseImage "distroless"`},
+				{func(c *Config) { c.Files = []string{"../Dockerfile"} }, `files entry "../Dockerfile"`},
+			}
+			for _, c := range cases {
+				cfg := valid
+				c.mutate(&cfg)
+				Expect(cfg.Validate()).To(MatchError(ContainSubstring("invalid plugin config for "+pluginKey+": "+c.err)), c.err)
+			}
+		})
+	})
+
+	Describe("migrateConfig", func() {
+		It("upgrades an empty legacy plugin config", func() {
+			c := cfgv3.New()
+			Expect(c.EncodePluginConfig(pluginKey, Config{})).To(Succeed())
+			cfg, err := migrateConfig(c)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.OCPVersion).To(Equal(ocpProductVersion))
+
+			var stored Config
+			Expect(c.DecodePluginConfig(pluginKey, &stored)).To(Succeed())
+			Expect(stored).To(Equal(cfg))
+		})
+
+		It("records a plugin config for projects that predate it", func() {
+			c := cfgv3.New()
+			cfg, err := migrateConfig(c)
+			Expect(err).NotTo(HaveOccurred())
+
+			var stored Config
+			Expect(c.DecodePluginConfig(pluginKey, &stored)).To(Succeed())
+			Expect(stored).To(Equal(cfg))
+			Expect(stored.imageOptions()).To(Equal(defaultImageOptions()))
+		})
+
+		It("leaves a current plugin config unchanged", func() {
+			c := cfgv3.New()
+			current := Config{OCPVersion: "4.13", UBIVersion: "8.7", UBIMajor: 8, Arch: "s390x"}
+			Expect(c.EncodePluginConfig(pluginKey, current)).To(Succeed())
+			cfg, err := migrateConfig(c)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg).To(Equal(current))
+		})
+
+		It("defaults the config for project versions without plugin configs", func() {
+			cfg, err := migrateConfig(cfgv2.New())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cfg.OCPVersion).To(Equal(ocpProductVersion))
+		})
+	})
+})
@@ -0,0 +1,79 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+	"sigs.k8s.io/kubebuilder/v3/pkg/config/store"
+	"sigs.k8s.io/kubebuilder/v3/pkg/config/store/yaml"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+// resolveProjectRoot returns the directory substitution paths are relative to: root if it is set,
+// otherwise the working directory, where kubebuilder reads and writes the PROJECT file.
+// Any other root must contain a PROJECT file, so that files of another directory are never changed.
+func resolveProjectRoot(fs afero.Fs, root string) (string, error) {
+	if root == "" || filepath.Clean(root) == "." {
+		return ".", nil
+	}
+	root = filepath.Clean(root)
+	if info, err := fs.Stat(root); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("invalid --%s value %q: not a directory", projectRootFlag, root)
+	}
+	if _, err := fs.Stat(filepath.Join(root, yaml.DefaultPath)); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("invalid --%s value %q: not an operator project, no %s file found", projectRootFlag, root, yaml.DefaultPath)
+		}
+		return "", fmt.Errorf("error reading %s: %v", filepath.Join(root, yaml.DefaultPath), err)
+	}
+	return root, nil
+}
+
+// newProjectRootFs returns a filesystem whose paths are relative to root in fs,
+// or fs itself if root is the working directory.
+func newProjectRootFs(fs afero.Fs, root string) afero.Fs {
+	if root == "" || root == "." {
+		return fs
+	}
+	return afero.NewBasePathFs(fs, root)
+}
+
+// loadProjectRootConfig loads the PROJECT file of the project at root in fs. Subcommands given a
+// --project-root other than the working directory read their plugin config from and record it in
+// this file, instead of the config kubebuilder loaded from the working directory's PROJECT file.
+func loadProjectRootConfig(fs afero.Fs, root string) (store.Store, error) {
+	st := yaml.New(machinery.Filesystem{FS: newProjectRootFs(fs, root)})
+	if err := st.Load(); err != nil {
+		return nil, fmt.Errorf("error reading %s: %v", filepath.Join(root, yaml.DefaultPath), err)
+	}
+	return st, nil
+}
+
+// saveProjectRootConfig writes the config loaded by loadProjectRootConfig back to its PROJECT file,
+// if one was loaded.
+func saveProjectRootConfig(st store.Store) error {
+	if st == nil {
+		return nil
+	}
+	if err := st.Save(); err != nil {
+		return fmt.Errorf("error writing --%s project config: %v", projectRootFlag, err)
+	}
+	return nil
+}
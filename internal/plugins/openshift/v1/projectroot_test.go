@@ -0,0 +1,155 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+	"github.com/spf13/pflag"
+	cfgv3 "sigs.k8s.io/kubebuilder/v3/pkg/config/v3"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+var _ = Describe("Project root", func() {
+	var fs machinery.Filesystem
+
+	const (
+		dockerfile    = "FROM gcr.io/distroless/static:nonroot\n"
+		dockerfileExp = "FROM registry.access.redhat.com/ubi8/ubi-minimal:" + ubiMinimalVersion + "\n"
+	)
+
+	BeforeEach(func() {
+		fs = machinery.Filesystem{FS: afero.NewMemMapFs()}
+		Expect(afero.WriteFile(fs.FS, "operators/memcached/PROJECT", []byte("version: \"3\"\n"), 0644)).To(Succeed())
+		Expect(afero.WriteFile(fs.FS, "operators/memcached/Dockerfile", []byte(dockerfile), 0644)).To(Succeed())
+		Expect(afero.WriteFile(fs.FS, "operators/notes/Dockerfile", []byte(dockerfile), 0644)).To(Succeed())
+		Expect(afero.WriteFile(fs.FS, "Dockerfile", []byte(dockerfile), 0644)).To(Succeed())
+	})
+
+	readFile := func(filePath string) string {
+		b, err := afero.ReadFile(fs.FS, filePath)
+		Expect(err).NotTo(HaveOccurred())
+		return string(b)
+	}
+
+	Describe("resolveProjectRoot", func() {
+		It("resolves to the working directory by default", func() {
+			Expect(resolveProjectRoot(fs.FS, "")).To(Equal("."))
+		})
+
+		It("resolves a directory containing a PROJECT file", func() {
+			Expect(resolveProjectRoot(fs.FS, "operators/memcached/")).To(Equal("operators/memcached"))
+		})
+
+		It("fails if the directory has no PROJECT file", func() {
+			_, err := resolveProjectRoot(fs.FS, "operators/notes")
+			Expect(err).To(MatchError(`invalid --project-root value "operators/notes": not an operator project, no PROJECT file found`))
+		})
+
+		It("fails if the directory does not exist", func() {
+			_, err := resolveProjectRoot(fs.FS, "operators/missing")
+			Expect(err).To(MatchError(`invalid --project-root value "operators/missing": not a directory`))
+		})
+	})
+
+	It("substitutes images in the project at --project-root", func() {
+		s := &initSubcommand{}
+		flags := pflag.NewFlagSet("init", pflag.ContinueOnError)
+		s.BindFlags(flags)
+		Expect(flags.Parse([]string{"--" + projectRootFlag, "operators/memcached"})).To(Succeed())
+		Expect(s.InjectConfig(cfgv3.New())).To(Succeed())
+		Expect(s.PreScaffold(fs)).To(Succeed())
+		Expect(s.Scaffold(fs)).To(Succeed())
+		Expect(readFile("operators/memcached/Dockerfile")).To(Equal(dockerfileExp))
+		Expect(readFile("operators/notes/Dockerfile")).To(Equal(dockerfile))
+		Expect(readFile("Dockerfile")).To(Equal(dockerfile))
+		Expect(s.Report().Modified).To(ContainElement("Dockerfile"))
+	})
+
+	It("reads the local config file of the project at --project-root", func() {
+		Expect(afero.WriteFile(fs.FS, "operators/memcached/"+localConfigPath, []byte("ocpVersion: \"4.15\"\n"), 0644)).To(Succeed())
+		s := &initSubcommand{projectRoot: "operators/memcached"}
+		Expect(s.InjectConfig(cfgv3.New())).To(Succeed())
+		Expect(s.PreScaffold(fs)).To(Succeed())
+		Expect(s.options.OCPVersion).To(Equal("4.15"))
+	})
+
+	It("re-tags images in the project at --project-root with the versions recorded in its PROJECT file", func() {
+		const retaggedDockerfile = "FROM registry.access.redhat.com/ubi8/ubi-minimal:8.8\n"
+		Expect(afero.WriteFile(fs.FS, "operators/memcached/PROJECT", []byte(memcachedProject), 0644)).To(Succeed())
+		Expect(afero.WriteFile(fs.FS, "operators/memcached/Dockerfile", []byte(retaggedDockerfile), 0644)).To(Succeed())
+		// The project kubebuilder loaded from the working directory records other versions.
+		c := cfgv3.New()
+		Expect(c.EncodePluginConfig(pluginKey, Config{OCPVersion: "4.15", UBIVersion: "8.9", UBIMajor: 8})).To(Succeed())
+
+		s := &editSubcommand{projectRoot: "operators/memcached", ocpVersion: "4.16", ubiVersion: "8.10"}
+		Expect(s.InjectConfig(c)).To(Succeed())
+		Expect(s.PreScaffold(fs)).To(Succeed())
+		Expect(s.Scaffold(fs)).To(Succeed())
+		Expect(readFile("operators/memcached/Dockerfile")).To(Equal("FROM registry.access.redhat.com/ubi8/ubi-minimal:8.10\n"))
+		Expect(readFile("Dockerfile")).To(Equal(dockerfile))
+
+		b, err := afero.ReadFile(fs.FS, "operators/memcached/PROJECT")
+		Expect(err).NotTo(HaveOccurred())
+		recorded := cfgv3.New()
+		Expect(recorded.UnmarshalYAML(b)).To(Succeed())
+		cfg, err := decodeConfig(recorded)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg.OCPVersion).To(Equal("4.16"))
+		Expect(cfg.UBIVersion).To(Equal("8.10"))
+		cfg, err = decodeConfig(c)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg.OCPVersion).To(Equal("4.15"))
+	})
+
+	It("records the plugin config of init in the PROJECT file at --project-root", func() {
+		Expect(afero.WriteFile(fs.FS, "operators/memcached/PROJECT", []byte(memcachedProject), 0644)).To(Succeed())
+		c := cfgv3.New()
+		s := &initSubcommand{projectRoot: "operators/memcached"}
+		Expect(s.InjectConfig(c)).To(Succeed())
+		Expect(s.PreScaffold(fs)).To(Succeed())
+		Expect(s.Scaffold(fs)).To(Succeed())
+
+		b, err := afero.ReadFile(fs.FS, "operators/memcached/PROJECT")
+		Expect(err).NotTo(HaveOccurred())
+		recorded := cfgv3.New()
+		Expect(recorded.UnmarshalYAML(b)).To(Succeed())
+		Expect(recorded.GetProjectName()).To(Equal("memcached-operator"))
+		cfg, err := decodeConfig(recorded)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg.OCPVersion).To(Equal(ocpProductVersion))
+		Expect(c.DecodePluginConfig(pluginKey, &Config{})).NotTo(Succeed())
+	})
+
+	It("fails before changing any file if --project-root is not an operator project", func() {
+		s := &editSubcommand{projectRoot: "operators/notes"}
+		Expect(s.PreScaffold(fs)).To(MatchError(ContainSubstring("not an operator project")))
+		Expect(readFile("operators/notes/Dockerfile")).To(Equal(dockerfile))
+	})
+})
+
+const memcachedProject = `domain: example.com
+layout:
+- go.kubebuilder.io/v3
+plugins:
+  sdk.x-openshift.io/v1:
+    ocpVersion: "4.14"
+    ubiVersion: "8.8"
+    ubiMajor: 8
+projectName: memcached-operator
+repo: github.com/example/memcached-operator
+version: "3"
+`
@@ -0,0 +1,66 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"path/filepath"
+	"regexp"
+)
+
+// prometheusGlob matches the monitoring manifests of projects, ex. config/prometheus/monitor.yaml.
+// Since it is a pattern, monitoring images are only substituted in projects that have config/prometheus.
+var prometheusGlob = filepath.Join("config", "prometheus", "*.yaml")
+
+// monitoringImages map upstream monitoring image repositories to the names of the OpenShift images
+// that replace them, which are shipped with OpenShift's monitoring stack:
+//
+//	quay.io/prometheus/prometheus                         -> ose-prometheus
+//	quay.io/prometheus/alertmanager                       -> ose-prometheus-alertmanager
+//	quay.io/prometheus/node-exporter                      -> ose-prometheus-node-exporter
+//	quay.io/prometheus-operator/prometheus-operator       -> ose-prometheus-operator
+//	quay.io/prometheus-operator/prometheus-config-reloader -> ose-prometheus-config-reloader
+//	registry.k8s.io/kube-state-metrics/kube-state-metrics -> ose-kube-state-metrics
+//
+// Images of other repositories, ex. other exporters, have no OpenShift equivalent and are left unchanged.
+var monitoringImages = []struct {
+	repository, name string
+}{
+	{"quay.io/prometheus/prometheus", "ose-prometheus"},
+	{"quay.io/prometheus/alertmanager", "ose-prometheus-alertmanager"},
+	{"quay.io/prometheus/node-exporter", "ose-prometheus-node-exporter"},
+	{"quay.io/prometheus-operator/prometheus-operator", "ose-prometheus-operator"},
+	{"quay.io/prometheus-operator/prometheus-config-reloader", "ose-prometheus-config-reloader"},
+	{"registry.k8s.io/kube-state-metrics/kube-state-metrics", "ose-kube-state-metrics"},
+}
+
+// monitoringSubstitutions returns the substitutions of monitoringImages, followed by that of
+// kube-rbac-proxy, which guards the metrics endpoints of monitoring components.
+func monitoringSubstitutions() []substitutionTemplate {
+	substs := make([]substitutionTemplate, 0, len(monitoringImages)+1)
+	for _, image := range monitoringImages {
+		substs = append(substs, substitutionTemplate{
+			category:  MonitoringCategory,
+			fromTagRE: regexp.MustCompile(regexp.QuoteMeta(image.repository) + `[:@][^ \n"']+`),
+			toTag:     tagTemplate(oseImage(MonitoringCategory, image.name) + oseTag),
+		})
+	}
+	return append(substs, kubeRBACProxySubstitution)
+}
+
+// isMonitoringFile reports whether filePath is a monitoring manifest matched by prometheusGlob.
+func isMonitoringFile(filePath string) bool {
+	matched, _ := filepath.Match(prometheusGlob, filePath)
+	return matched
+}
@@ -0,0 +1,127 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+
+	"github.com/operator-framework/operator-sdk/internal/plugins/openshift/v1/templates/config/openshift"
+)
+
+var _ = Describe("monitoringSubstitutions", func() {
+	var fs machinery.Filesystem
+
+	const exporterPath = "config/prometheus/exporter.yaml"
+
+	BeforeEach(func() {
+		fs = machinery.Filesystem{FS: afero.NewMemMapFs()}
+		Expect(afero.WriteFile(fs.FS, exporterPath, []byte(prometheusExporter), 0644)).To(Succeed())
+		Expect(afero.WriteFile(fs.FS, "config/prometheus/monitor.yaml", []byte(prometheusMonitor), 0644)).To(Succeed())
+	})
+
+	It("substitutes mapped monitoring images in config/prometheus", func() {
+		results, err := replaceImages(fs, defaultImageOptions())
+		Expect(err).NotTo(HaveOccurred())
+		b, err := afero.ReadFile(fs.FS, exporterPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(Equal(prometheusExporterExp))
+		var matched []SubstitutionResult
+		for _, result := range results {
+			if result.Count > 0 {
+				matched = append(matched, result)
+			}
+		}
+		Expect(matched).To(HaveLen(2))
+		for _, result := range matched {
+			Expect(result.Path).To(Equal(exporterPath))
+			Expect(result.Category).To(Equal(MonitoringCategory))
+		}
+	})
+
+	It("leaves config/prometheus unchanged if monitoring images are disabled", func() {
+		opts := defaultImageOptions()
+		opts.Disabled = []string{MonitoringCategory}
+		results, err := replaceImages(fs, opts)
+		Expect(err).NotTo(HaveOccurred())
+		for _, result := range results {
+			Expect(result.Category).NotTo(Equal(MonitoringCategory))
+		}
+		b, err := afero.ReadFile(fs.FS, exporterPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(Equal(prometheusExporter))
+	})
+
+	It("mirrors the repositories of substituted monitoring images", func() {
+		opts := defaultImageOptions()
+		opts.Registry = "mirror.example.com"
+		results, err := replaceImages(fs, opts)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mirroredRepositories(opts.Options, results)).To(Equal([]openshift.RepositoryMirror{
+			{Source: "registry.redhat.io/openshift4/ose-kube-state-metrics", Mirror: "mirror.example.com/openshift4/ose-kube-state-metrics"},
+			{Source: "registry.redhat.io/openshift4/ose-prometheus-node-exporter", Mirror: "mirror.example.com/openshift4/ose-prometheus-node-exporter"},
+		}))
+	})
+
+	It("matches only monitoring manifests", func() {
+		Expect(isMonitoringFile(exporterPath)).To(BeTrue())
+		Expect(isMonitoringFile("config/prometheus/kustomization.yml")).To(BeFalse())
+		Expect(isMonitoringFile("config/manager/manager.yaml")).To(BeFalse())
+	})
+})
+
+const prometheusMonitor = `apiVersion: monitoring.coreos.com/v1
+kind: ServiceMonitor
+metadata:
+  name: controller-manager-metrics-monitor
+spec:
+  endpoints:
+  - path: /metrics
+    port: https
+`
+
+const prometheusExporter = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: exporters
+spec:
+  template:
+    spec:
+      containers:
+      - name: node-exporter
+        image: quay.io/prometheus/node-exporter:v1.6.0
+      - name: kube-state-metrics
+        image: "registry.k8s.io/kube-state-metrics/kube-state-metrics:v2.9.2"
+      - name: blackbox-exporter
+        image: quay.io/prometheus/blackbox-exporter:v0.24.0
+`
+
+const prometheusExporterExp = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: exporters
+spec:
+  template:
+    spec:
+      containers:
+      - name: node-exporter
+        image: registry.redhat.io/openshift4/ose-prometheus-node-exporter:v` + ocpProductVersion + `
+      - name: kube-state-metrics
+        image: "registry.redhat.io/openshift4/ose-kube-state-metrics:v` + ocpProductVersion + `"
+      - name: blackbox-exporter
+        image: quay.io/prometheus/blackbox-exporter:v0.24.0
+`
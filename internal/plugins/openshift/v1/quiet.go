@@ -0,0 +1,40 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+
+	"github.com/operator-framework/operator-sdk/internal/flags"
+)
+
+// quietFlagConflict is the conflict of --quiet with the global --verbose flag, which sets the opposite log level.
+func quietFlagConflict(quiet bool) flagConflict {
+	return flagConflict{
+		flag: quietFlag, other: flags.VerboseOpt,
+		set:    quiet && viper.GetBool(flags.VerboseOpt),
+		reason: "quiet runs only log errors",
+	}
+}
+
+// logOnlyErrors raises the level of the standard logger, and of logger if it has its own, to errors,
+// so that --quiet runs print no warnings or progress. Errors are still returned and set the exit code.
+func logOnlyErrors(logger *log.Entry) {
+	log.SetLevel(log.ErrorLevel)
+	if logger != nil {
+		logger.Logger.SetLevel(log.ErrorLevel)
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	cfgv3 "sigs.k8s.io/kubebuilder/v3/pkg/config/v3"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+
+	"github.com/operator-framework/operator-sdk/internal/flags"
+)
+
+var _ = Describe("--quiet", func() {
+	var (
+		fs     machinery.Filesystem
+		level  log.Level
+		out    *bytes.Buffer
+		logOut *bytes.Buffer
+		logger *log.Logger
+	)
+
+	BeforeEach(func() {
+		fs = machinery.Filesystem{FS: afero.NewMemMapFs()}
+		Expect(afero.WriteFile(fs.FS, "Dockerfile", []byte("FROM gcr.io/distroless/static:nonroot\n"), 0644)).To(Succeed())
+		level = log.GetLevel()
+		out, logOut = &bytes.Buffer{}, &bytes.Buffer{}
+		logger = log.New()
+		logger.SetOutput(logOut)
+	})
+
+	AfterEach(func() {
+		log.SetLevel(level)
+		viper.Set(flags.VerboseOpt, false)
+	})
+
+	newInit := func(args ...string) *initSubcommand {
+		s := &initSubcommand{options: imageOptions{out: out, logger: log.NewEntry(logger)}}
+		fset := pflag.NewFlagSet("init", pflag.ContinueOnError)
+		s.BindFlags(fset)
+		Expect(fset.Parse(args)).To(Succeed())
+		Expect(s.InjectConfig(cfgv3.New())).To(Succeed())
+		return s
+	}
+
+	It("prints no warnings or dry-run substitutions, but still fails a dry run that finds substitutions", func() {
+		s := newInit("--"+quietFlag, "--"+dryRunFlag, "--"+ocpVersionFlag, "4.10")
+		Expect(s.PreScaffold(fs)).To(Succeed())
+		Expect(ExitCode(s.Scaffold(fs))).To(Equal(ExitCodeSubstitutionsNeeded))
+		Expect(out.String()).To(BeEmpty())
+		Expect(logOut.String()).To(BeEmpty())
+		Expect(log.GetLevel()).To(Equal(log.ErrorLevel))
+	})
+
+	It("still returns errors", func() {
+		s := newInit("--"+quietFlag, "--"+failOnNoMatchFlag)
+		Expect(s.PreScaffold(fs)).To(Succeed())
+		Expect(s.Scaffold(fs)).To(MatchError(ContainSubstring("substitutions did not match anything")))
+		Expect(logOut.String()).To(BeEmpty())
+	})
+
+	It("still prints the JSON report requested by --output", func() {
+		s := newInit("--"+quietFlag, "--"+outputFlag, jsonOutput)
+		Expect(s.PreScaffold(fs)).To(Succeed())
+		Expect(s.Scaffold(fs)).To(Succeed())
+		Expect(out.String()).To(ContainSubstring(`"file": "Dockerfile"`))
+	})
+
+	It("prints dry-run substitutions without --quiet", func() {
+		s := newInit("--" + dryRunFlag)
+		Expect(s.PreScaffold(fs)).To(Succeed())
+		Expect(ExitCode(s.Scaffold(fs))).To(Equal(ExitCodeSubstitutionsNeeded))
+		Expect(out.String()).To(ContainSubstring("Dockerfile: gcr.io/distroless/static:nonroot -> "))
+		Expect(log.GetLevel()).To(Equal(level))
+	})
+
+	It("cannot be set with --verbose", func() {
+		viper.Set(flags.VerboseOpt, true)
+		s := newInit("--" + quietFlag)
+		Expect(s.PreScaffold(fs)).To(MatchError("--quiet cannot be set with --verbose: quiet runs only log errors"))
+
+		e := &editSubcommand{quiet: true}
+		Expect(e.PreScaffold(fs)).To(MatchError("--quiet cannot be set with --verbose: quiet runs only log errors"))
+	})
+
+	It("logs only errors when editing", func() {
+		e := &editSubcommand{quiet: true, ocpVersion: "4.10"}
+		Expect(e.PreScaffold(fs)).To(Succeed())
+		Expect(log.GetLevel()).To(Equal(log.ErrorLevel))
+	})
+})
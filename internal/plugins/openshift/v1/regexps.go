@@ -0,0 +1,35 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"regexp"
+	"sync"
+)
+
+// compiledRegexps caches regular expressions compiled at run time, keyed by expression. Patterns of
+// reverse and retag substitutions only depend on the images built-in substitutions produce, so
+// there are few of them even when many projects are processed by the same process.
+var compiledRegexps sync.Map
+
+// mustCompileCached is like regexp.MustCompile, but compiles each expression only once.
+// A Regexp is safe for concurrent use, so cached expressions are shared.
+func mustCompileCached(expr string) *regexp.Regexp {
+	if re, ok := compiledRegexps.Load(expr); ok {
+		return re.(*regexp.Regexp)
+	}
+	re, _ := compiledRegexps.LoadOrStore(expr, regexp.MustCompile(expr))
+	return re.(*regexp.Regexp)
+}
@@ -0,0 +1,55 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"regexp"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("mustCompileCached", func() {
+	It("compiles an expression once", func() {
+		re := mustCompileCached(`quay.io/example/cached:[^ \n"']+`)
+		Expect(re.MatchString("quay.io/example/cached:v1")).To(BeTrue())
+		Expect(mustCompileCached(`quay.io/example/cached:[^ \n"']+`)).To(BeIdenticalTo(re))
+	})
+})
+
+// BenchmarkCompileRegexps compares compiling the patterns of reverse substitutions on each call
+// and compiling them once.
+func BenchmarkCompileRegexps(b *testing.B) {
+	var exprs []string
+	for _, substs := range reverseSubstitutions(DefaultOptions(), "") {
+		for _, subst := range substs {
+			exprs = append(exprs, subst.FromTagRE.String())
+		}
+	}
+
+	for name, compile := range map[string]func(string) *regexp.Regexp{
+		"per-call": regexp.MustCompile,
+		"cached":   mustCompileCached,
+	} {
+		b.Run(name, func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				for _, expr := range exprs {
+					compile(expr)
+				}
+			}
+		})
+	}
+}
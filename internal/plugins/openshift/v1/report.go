@@ -0,0 +1,78 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// textOutput is the default --output format, which prints no report.
+	textOutput = "text"
+	// jsonOutput prints the substitution report as a JSON array of reportEntry objects.
+	jsonOutput = "json"
+)
+
+var outputFormats = []string{textOutput, jsonOutput}
+
+// reportEntry is the JSON representation of a SubstitutionResult. Its fields are relied on
+// by external tooling, so they must not be renamed or removed.
+type reportEntry struct {
+	// File is the path of the file the substitution was applied to.
+	File string `json:"file"`
+	// Pattern is the regular expression matching upstream images.
+	Pattern string `json:"pattern"`
+	// From are the distinct upstream images that were replaced.
+	From []string `json:"from"`
+	// To is the image that replaced each match of Pattern.
+	To string `json:"to"`
+	// Count is the number of replacements made.
+	Count int `json:"count"`
+}
+
+// validateOutput returns an error if format is not a supported --output format.
+func validateOutput(format string) error {
+	if !contains(outputFormats, format) {
+		return fmt.Errorf("invalid --%s value %q: must be one of %s", outputFlag, format, strings.Join(outputFormats, ", "))
+	}
+	return nil
+}
+
+// writeJSONReport writes results to w as an indented JSON array of reportEntry objects.
+func writeJSONReport(w io.Writer, results []SubstitutionResult) error {
+	entries := make([]reportEntry, 0, len(results))
+	for _, result := range results {
+		from := result.From
+		if from == nil {
+			from = []string{}
+		}
+		entries = append(entries, reportEntry{
+			File:    result.Path,
+			Pattern: result.Pattern,
+			From:    from,
+			To:      result.Image,
+			Count:   result.Count,
+		})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(entries); err != nil {
+		return fmt.Errorf("error writing substitution report: %v", err)
+	}
+	return nil
+}
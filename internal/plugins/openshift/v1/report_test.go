@@ -0,0 +1,116 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"bytes"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+	cfgv3 "sigs.k8s.io/kubebuilder/v3/pkg/config/v3"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+var _ = Describe("Report", func() {
+
+	Describe("validateOutput", func() {
+		It("accepts supported formats", func() {
+			for _, format := range outputFormats {
+				Expect(validateOutput(format)).To(Succeed(), format)
+			}
+		})
+		It("rejects unknown formats", func() {
+			Expect(validateOutput("yaml")).To(MatchError(ContainSubstring("--" + outputFlag)))
+		})
+	})
+
+	Describe("writeJSONReport", func() {
+		It("writes an array of {file, pattern, from, to, count} objects", func() {
+			out := &bytes.Buffer{}
+			Expect(writeJSONReport(out, []SubstitutionResult{
+				{Path: "Dockerfile", Pattern: "foo:[^ \\n]+", Image: "bar:v1", Count: 2, From: []string{"foo:v0", "foo:latest"}},
+				{Path: "Dockerfile", Pattern: "baz", Image: "qux"},
+			})).To(Succeed())
+			Expect(out.String()).To(Equal(`[
+  {
+    "file": "Dockerfile",
+    "pattern": "foo:[^ \\n]+",
+    "from": [
+      "foo:v0",
+      "foo:latest"
+    ],
+    "to": "bar:v1",
+    "count": 2
+  },
+  {
+    "file": "Dockerfile",
+    "pattern": "baz",
+    "from": [],
+    "to": "qux",
+    "count": 0
+  }
+]
+`))
+		})
+		It("writes an empty array if there are no results", func() {
+			out := &bytes.Buffer{}
+			Expect(writeJSONReport(out, nil)).To(Succeed())
+			Expect(out.String()).To(Equal("[]\n"))
+		})
+	})
+
+	Describe("init", func() {
+		var fs machinery.Filesystem
+
+		BeforeEach(func() {
+			fs = machinery.Filesystem{FS: afero.NewMemMapFs()}
+			Expect(afero.WriteFile(fs.FS, "Dockerfile", []byte("FROM gcr.io/distroless/static:nonroot\n"), 0644)).To(Succeed())
+		})
+
+		It("prints only the JSON report in dry-run mode", func() {
+			out := &bytes.Buffer{}
+			s := &initSubcommand{options: imageOptions{output: jsonOutput, dryRun: true, out: out}}
+			Expect(s.InjectConfig(cfgv3.New())).To(Succeed())
+			Expect(s.PreScaffold(fs)).To(Succeed())
+			Expect(ExitCode(s.Scaffold(fs))).To(Equal(ExitCodeSubstitutionsNeeded))
+
+			var entries []reportEntry
+			Expect(json.Unmarshal(out.Bytes(), &entries)).To(Succeed())
+			Expect(entries).To(ContainElement(reportEntry{
+				File:    "Dockerfile",
+				Pattern: `gcr.io/distroless/static[:@][^ \n"']+`,
+				From:    []string{"gcr.io/distroless/static:nonroot"},
+				To:      "registry.access.redhat.com/ubi8/ubi-minimal:" + ubiMinimalVersion,
+				Count:   1,
+			}))
+		})
+
+		It("prints no report by default", func() {
+			out := &bytes.Buffer{}
+			s := &initSubcommand{options: imageOptions{out: out}}
+			Expect(s.InjectConfig(cfgv3.New())).To(Succeed())
+			Expect(s.PreScaffold(fs)).To(Succeed())
+			Expect(s.Scaffold(fs)).To(Succeed())
+			Expect(out.String()).To(BeEmpty())
+		})
+
+		It("rejects a diff written to stdout with JSON output", func() {
+			s := &initSubcommand{options: imageOptions{output: jsonOutput, diffOutput: stdoutPath}}
+			Expect(s.PreScaffold(fs)).To(MatchError(ContainSubstring("--" + outputFlag)))
+		})
+	})
+})
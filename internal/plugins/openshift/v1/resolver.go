@@ -0,0 +1,99 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// VersionResolver resolves the OCP and UBI versions downstream images are tagged with,
+// ex. from a release manifest or an API.
+type VersionResolver interface {
+	Resolve(ctx context.Context) (Versions, error)
+}
+
+// VersionResolverFunc adapts a function to a VersionResolver.
+type VersionResolverFunc func(ctx context.Context) (Versions, error)
+
+// Resolve calls f(ctx).
+func (f VersionResolverFunc) Resolve(ctx context.Context) (Versions, error) {
+	return f(ctx)
+}
+
+// DefaultVersionResolver resolves the built-in versions returned by DefaultVersions.
+var DefaultVersionResolver VersionResolver = VersionResolverFunc(func(context.Context) (Versions, error) {
+	return DefaultVersions(), nil
+})
+
+var (
+	versionResolverMu sync.Mutex
+	versionResolver   VersionResolver
+)
+
+// RegisterVersionResolver sets the resolver init uses instead of the built-in versions,
+// replacing any resolver registered before. Versions given by flags, the local config file,
+// or the environment still take precedence over resolved versions.
+func RegisterVersionResolver(r VersionResolver) {
+	versionResolverMu.Lock()
+	defer versionResolverMu.Unlock()
+	versionResolver = r
+}
+
+// registeredVersionResolver returns the registered resolver, or nil if none was registered.
+func registeredVersionResolver() VersionResolver {
+	versionResolverMu.Lock()
+	defer versionResolverMu.Unlock()
+	return versionResolver
+}
+
+// resolveVersions resolves versions with the registered resolver, or returns the built-in
+// versions if none was registered.
+func resolveVersions(ctx context.Context) (Versions, bool, error) {
+	r := registeredVersionResolver()
+	if r == nil {
+		return DefaultVersions(), false, nil
+	}
+	versions, err := r.Resolve(ctx)
+	if err != nil {
+		return Versions{}, false, fmt.Errorf("error resolving OCP and UBI versions: %v", err)
+	}
+	return versions, true, nil
+}
+
+// ubiVersionFor returns the version of UBI base images with the given major version that is
+// known to work with OCP release ocp according to v, or the built-in version if v has none.
+func (v Versions) ubiVersionFor(ocp string, major int) string {
+	pick := func(ubi8, ubi9 string) string {
+		if major == 9 {
+			return ubi9
+		}
+		return ubi8
+	}
+	if ocp == v.OCPVersion {
+		if ubi := pick(v.UBIVersion, v.UBI9Version); ubi != "" {
+			return ubi
+		}
+	}
+	for _, release := range v.Releases {
+		if release.OCPVersion == ocp {
+			if ubi := pick(release.UBIVersion, release.UBI9Version); ubi != "" {
+				return ubi
+			}
+		}
+	}
+	return ubiVersionForOCP(ocp, major)
+}
@@ -0,0 +1,121 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+	"github.com/spf13/pflag"
+	cfgv3 "sigs.k8s.io/kubebuilder/v3/pkg/config/v3"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+var _ = Describe("VersionResolver", func() {
+	var fs machinery.Filesystem
+
+	resolved := Versions{
+		OCPVersion:  "4.15",
+		UBIVersion:  "8.9",
+		UBI9Version: "9.3",
+		Releases:    []ReleaseVersions{{OCPVersion: "4.16", UBIVersion: "8.10", UBI9Version: "9.4"}},
+	}
+
+	preScaffold := func(args ...string) (*initSubcommand, error) {
+		s := &initSubcommand{}
+		flags := pflag.NewFlagSet("init", pflag.ContinueOnError)
+		s.BindFlags(flags)
+		Expect(flags.Parse(args)).To(Succeed())
+		Expect(s.InjectConfig(cfgv3.New())).To(Succeed())
+		return s, s.PreScaffold(fs)
+	}
+
+	BeforeEach(func() {
+		fs = machinery.Filesystem{FS: afero.NewMemMapFs()}
+	})
+
+	AfterEach(func() {
+		versionResolver = nil
+	})
+
+	It("tags images with the built-in versions if no resolver is registered", func() {
+		s, err := preScaffold()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.options.OCPVersion).To(Equal(ocpProductVersion))
+		Expect(s.options.UBIVersion).To(Equal(ubiMinimalVersion))
+	})
+
+	It("tags images with the versions of a registered resolver", func() {
+		RegisterVersionResolver(VersionResolverFunc(func(context.Context) (Versions, error) { return resolved, nil }))
+		s, err := preScaffold()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.options.OCPVersion).To(Equal("4.15"))
+		Expect(s.options.UBIVersion).To(Equal("8.9"))
+
+		s, err = preScaffold("--"+ubiMajorFlag, "9")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.options.UBIVersion).To(Equal("9.3"))
+	})
+
+	It("prefers versions given by flags over resolved versions", func() {
+		RegisterVersionResolver(VersionResolverFunc(func(context.Context) (Versions, error) { return resolved, nil }))
+		s, err := preScaffold("--"+ocpVersionFlag, "4.16")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.options.OCPVersion).To(Equal("4.16"))
+		Expect(s.options.UBIVersion).To(Equal("8.10"))
+
+		s, err = preScaffold("--"+ocpVersionFlag, "4.13")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.options.UBIVersion).To(Equal(ubiVersionForOCP("4.13", 8)))
+	})
+
+	It("rejects resolved versions that would be recorded in an invalid config", func() {
+		RegisterVersionResolver(VersionResolverFunc(func(context.Context) (Versions, error) {
+			return Versions{OCPVersion: "4.15", UBIVersion: "8.9-1032"}, nil
+		}))
+		_, err := preScaffold()
+		Expect(err).To(MatchError(ContainSubstring("invalid plugin config for " + pluginKey)))
+	})
+
+	It("fails if the resolver fails", func() {
+		RegisterVersionResolver(VersionResolverFunc(func(context.Context) (Versions, error) {
+			return Versions{}, errors.New("release manifest not found")
+		}))
+		_, err := preScaffold()
+		Expect(err).To(MatchError("error resolving OCP and UBI versions: release manifest not found"))
+	})
+
+	It("resolves versions with the context given to the plugin", func() {
+		RegisterVersionResolver(VersionResolverFunc(func(ctx context.Context) (Versions, error) {
+			<-ctx.Done()
+			return Versions{}, ctx.Err()
+		}))
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		p := Plugin{}.WithContext(ctx)
+		s := &p.initSubcommand
+		Expect(s.InjectConfig(cfgv3.New())).To(Succeed())
+		Expect(s.PreScaffold(fs)).To(MatchError("error resolving OCP and UBI versions: context canceled"))
+	})
+
+	It("resolves the built-in versions by default", func() {
+		versions, err := DefaultVersionResolver.Resolve(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(versions).To(Equal(DefaultVersions()))
+	})
+})
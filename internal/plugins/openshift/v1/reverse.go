@@ -0,0 +1,64 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	// defaultUpstreamTag is the default tag of operator-framework images restored by reversing substitutions.
+	defaultUpstreamTag = "latest"
+	// upstreamRBACProxyTag is the tag of the kube-rbac-proxy image restored by reversing substitutions,
+	// which has no floating tag.
+	upstreamRBACProxyTag = "v0.13.1"
+)
+
+// reverseSubstitutions returns a map of paths to substitutions that restore the upstream image of each
+// built-in substitution configured by opts. Each reverse substitution matches any tag of the image
+// the built-in substitution produces. Substitutions without an upstream image, such as those of images
+// that are UBI-based upstream too, are not reversed. Restored operator-framework images are tagged
+// with upstreamTag, or defaultUpstreamTag if unset.
+func reverseSubstitutions(opts Options, upstreamTag string) map[string][]Substitution {
+	ctx := newTagContext(opts)
+	ctx.UpstreamTag = upstreamTag
+	if ctx.UpstreamTag == "" {
+		ctx.UpstreamTag = defaultUpstreamTag
+	}
+	substs := map[string][]Substitution{}
+	for filePath, tmpls := range builtinSubstitutions {
+		for _, tmpl := range tmpls {
+			if tmpl.upstream == nil || contains(opts.Disabled, tmpl.category) || (tmpl.enabled != nil && !tmpl.enabled(ctx)) {
+				continue
+			}
+			downstream := tmpl.render(ctx).ToTag
+			substs[filePath] = append(substs[filePath], Substitution{
+				Category:  tmpl.category,
+				FromTagRE: mustCompileCached(regexp.QuoteMeta(imageName(downstream)) + `:[^ \n"']+`),
+				ToTag:     tmpl.execute(tmpl.upstream, ctx),
+			})
+		}
+	}
+	return withFiles(substs, opts.Files)
+}
+
+// imageName returns image without its tag.
+func imageName(image string) string {
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[:i]
+	}
+	return image
+}
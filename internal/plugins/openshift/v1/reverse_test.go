@@ -0,0 +1,95 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("reverseSubstitutions", func() {
+	It("derives reverse substitutions from the built-in substitutions", func() {
+		opts := DefaultOptions()
+		opts.Registry, opts.Arch = "mirror.example.com:5000", "arm64"
+		substs := reverseSubstitutions(opts, "")
+
+		cases := []struct {
+			path, content, expected string
+		}{
+			{
+				path:     authProxyPatchPath,
+				content:  "image: mirror.example.com:5000/openshift4/ose-kube-rbac-proxy:v4.12-arm64\n",
+				expected: "image: gcr.io/kubebuilder/kube-rbac-proxy:" + upstreamRBACProxyTag + "\n",
+			},
+			{
+				path:     "Dockerfile",
+				content:  "FROM mirror.example.com:5000/openshift4/ose-ansible-operator:v4.14-arm64\n",
+				expected: "FROM quay.io/operator-framework/ansible-operator:" + defaultUpstreamTag + "\n",
+			},
+			{
+				path:     "Dockerfile",
+				content:  "FROM mirror.example.com:5000/openshift4/ose-helm-operator:v4.13\n",
+				expected: "FROM quay.io/operator-framework/helm-operator:" + defaultUpstreamTag + "\n",
+			},
+			{
+				path:     "Dockerfile",
+				content:  "FROM mirror.example.com:5000/ubi8/ubi-minimal:8.8\n",
+				expected: "FROM gcr.io/distroless/static:nonroot\n",
+			},
+			{
+				// UBI images of hybrid projects are UBI-based upstream too.
+				path:     "Dockerfile",
+				content:  "FROM mirror.example.com:5000/ubi8/ubi-micro:8.8\n",
+				expected: "FROM mirror.example.com:5000/ubi8/ubi-micro:8.8\n",
+			},
+		}
+		for _, c := range cases {
+			out, _ := substituteBytes([]byte(c.content), substs[c.path])
+			Expect(string(out)).To(Equal(c.expected), c.content)
+		}
+	})
+
+	It("tags operator-framework images with the given upstream tag", func() {
+		substs := reverseSubstitutions(DefaultOptions(), "v1.31.0")
+		out, count := substituteBytes([]byte(dockerfileAllExp), substs["Dockerfile"])
+		Expect(count).To(Equal(8))
+		Expect(string(out)).To(ContainSubstring("FROM quay.io/operator-framework/helm-operator:v1.31.0\n"))
+		Expect(string(out)).To(ContainSubstring("FROM quay.io/operator-framework/ansible-operator:v1.31.0\n"))
+	})
+
+	It("restores distroless base images replaced with the given UBI image", func() {
+		opts := DefaultOptions()
+		opts.GoBaseImage = "ubi"
+		out, count := substituteBytes([]byte("FROM registry.access.redhat.com/ubi8/ubi:8.8\n"), reverseSubstitutions(opts, "")["Dockerfile"])
+		Expect(count).To(Equal(1))
+		Expect(string(out)).To(Equal("FROM gcr.io/distroless/static:nonroot\n"))
+	})
+
+	It("does not reverse disabled categories", func() {
+		opts := DefaultOptions()
+		opts.Disabled = []string{UBIMinimalCategory}
+		for _, subst := range reverseSubstitutions(opts, "")["Dockerfile"] {
+			Expect(subst.Category).NotTo(Equal(UBIMinimalCategory))
+		}
+	})
+})
+
+var _ = Describe("imageName", func() {
+	It("strips the tag of an image", func() {
+		Expect(imageName("quay.io/example/foo:v1")).To(Equal("quay.io/example/foo"))
+		Expect(imageName("mirror.example.com:5000/example/foo:v1")).To(Equal("mirror.example.com:5000/example/foo"))
+		Expect(imageName("mirror.example.com:5000/example/foo")).To(Equal("mirror.example.com:5000/example/foo"))
+	})
+})
@@ -0,0 +1,96 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/afero"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+// RollbackBackups restores each file backed up by a previous run with --backup from its
+// "<path>.orig" backup, then removes the backups, and returns the paths of the files restored
+// in path order. Backups are only restored if the file they back up exists, and hidden
+// directories, such as .git, are not searched. If any file cannot be restored, no backup is removed.
+func RollbackBackups(fs machinery.Filesystem) ([]string, error) {
+	filePaths, err := findBackups(fs.FS)
+	if err != nil {
+		return nil, err
+	}
+	if len(filePaths) == 0 {
+		return nil, errors.New("no backups found, run with --" + backupFlag + " to create them")
+	}
+
+	// Every backup is read before any file is restored.
+	var backups []fileBackup
+	for _, filePath := range filePaths {
+		backupPath := filePath + backupSuffix
+		b, err := afero.ReadFile(fs.FS, backupPath)
+		if err != nil {
+			return nil, fmt.Errorf("error reading backup of %s: %v", filePath, err)
+		}
+		info, err := fs.FS.Stat(backupPath)
+		if err != nil {
+			return nil, fmt.Errorf("error reading backup of %s: %v", filePath, err)
+		}
+		backups = append(backups, fileBackup{path: filePath, b: b, mode: info.Mode()})
+	}
+	if err := restoreBackups(fs.FS, backups); err != nil {
+		return nil, err
+	}
+	for _, filePath := range filePaths {
+		if err := fs.FS.Remove(filePath + backupSuffix); err != nil {
+			return nil, fmt.Errorf("error removing backup of %s: %v", filePath, err)
+		}
+	}
+	return filePaths, nil
+}
+
+// findBackups returns the paths of files in fs that have a backup, in path order.
+func findBackups(fs afero.Fs) ([]string, error) {
+	var backups []string
+	err := afero.Walk(fs, ".", func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if filePath != "." && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(filePath, backupSuffix) {
+			return nil
+		}
+		original := strings.TrimSuffix(filePath, backupSuffix)
+		if exists, err := afero.Exists(fs, original); err != nil {
+			return err
+		} else if exists {
+			backups = append(backups, original)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error finding backups: %v", err)
+	}
+	sort.Strings(backups)
+	return backups, nil
+}
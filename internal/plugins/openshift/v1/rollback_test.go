@@ -0,0 +1,77 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+var _ = Describe("RollbackBackups", func() {
+	var fs machinery.Filesystem
+
+	BeforeEach(func() {
+		fs = machinery.Filesystem{FS: afero.NewMemMapFs()}
+	})
+
+	It("restores files substituted with backups and removes the backups", func() {
+		Expect(afero.WriteFile(fs.FS, "Dockerfile", []byte(dockerfileAll), 0600)).To(Succeed())
+		Expect(afero.WriteFile(fs.FS, authProxyPatchPath, []byte(proxyPatch), 0644)).To(Succeed())
+		opts := defaultImageOptions()
+		opts.backup = true
+		_, err := replaceImages(fs, opts)
+		Expect(err).NotTo(HaveOccurred())
+
+		restored, err := RollbackBackups(fs)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(restored).To(Equal([]string{"Dockerfile", authProxyPatchPath}))
+		b, err := afero.ReadFile(fs.FS, "Dockerfile")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(Equal(dockerfileAll))
+		info, err := fs.FS.Stat("Dockerfile")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Mode()).To(Equal(os.FileMode(0600)))
+		b, err = afero.ReadFile(fs.FS, authProxyPatchPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(Equal(proxyPatch))
+		Expect(afero.Exists(fs.FS, "Dockerfile"+backupSuffix)).To(BeFalse())
+		Expect(afero.Exists(fs.FS, authProxyPatchPath+backupSuffix)).To(BeFalse())
+	})
+
+	It("ignores backups of missing files and in hidden directories", func() {
+		Expect(afero.WriteFile(fs.FS, "Makefile.orig", []byte("all:\n"), 0644)).To(Succeed())
+		Expect(afero.WriteFile(fs.FS, filepath.Join(".git", "config"), []byte("[core]\n"), 0644)).To(Succeed())
+		Expect(afero.WriteFile(fs.FS, filepath.Join(".git", "config.orig"), []byte("[user]\n"), 0644)).To(Succeed())
+		_, err := RollbackBackups(fs)
+		Expect(err).To(MatchError(ContainSubstring("no backups found")))
+		Expect(afero.Exists(fs.FS, "Makefile.orig")).To(BeTrue())
+		b, err := afero.ReadFile(fs.FS, filepath.Join(".git", "config"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(Equal("[core]\n"))
+	})
+
+	It("does not remove backups if a file cannot be restored", func() {
+		Expect(afero.WriteFile(fs.FS, "Dockerfile", []byte(dockerfileAllExp), 0644)).To(Succeed())
+		Expect(afero.WriteFile(fs.FS, "Dockerfile"+backupSuffix, []byte(dockerfileAll), 0644)).To(Succeed())
+		_, err := RollbackBackups(machinery.Filesystem{FS: afero.NewReadOnlyFs(fs.FS)})
+		Expect(err).To(MatchError(ContainSubstring("error restoring Dockerfile")))
+		Expect(afero.Exists(fs.FS, "Dockerfile"+backupSuffix)).To(BeTrue())
+	})
+})
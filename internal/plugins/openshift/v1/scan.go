@@ -0,0 +1,103 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/gobwas/glob"
+	"github.com/spf13/afero"
+)
+
+// defaultScanGlobs are the globs of files scanned for upstream images by default.
+var defaultScanGlobs = []string{"config/**/*.yaml", "molecule/**/*.yml"}
+
+// compileGlobs compiles path globs, where "*" does not match "/" and "**" does.
+func compileGlobs(flag string, patterns []string) ([]glob.Glob, error) {
+	globs := make([]glob.Glob, 0, len(patterns))
+	for _, pattern := range patterns {
+		g, err := glob.Compile(filepath.ToSlash(pattern), '/')
+		if err != nil {
+			return nil, fmt.Errorf("invalid --%s value %q: %v", flag, pattern, err)
+		}
+		globs = append(globs, g)
+	}
+	return globs, nil
+}
+
+func matchesAny(globs []glob.Glob, path string) bool {
+	for _, g := range globs {
+		if g.Match(filepath.ToSlash(path)) {
+			return true
+		}
+	}
+	return false
+}
+
+// scanSubstitutions walks fs for files that match opts.scanGlobs (default defaultScanGlobs) but not opts.excludes,
+// and returns substs with the built-in substitutions that match each file added.
+// Files in substs are left as they are, since their substitutions are already known.
+func scanSubstitutions(fs afero.Fs, opts imageOptions, substs map[string][]Substitution) (map[string][]Substitution, error) {
+	scanGlobs := opts.scanGlobs
+	if len(scanGlobs) == 0 {
+		scanGlobs = defaultScanGlobs
+	}
+	includes, err := compileGlobs(scanGlobFlag, scanGlobs)
+	if err != nil {
+		return nil, err
+	}
+	excludes, err := compileGlobs(excludeFlag, opts.excludes)
+	if err != nil {
+		return nil, err
+	}
+
+	// Apply each distinct built-in substitution in path, then substitution order.
+	candidates := distinctSubstitutions(BuildSubstitutions(opts.Options))
+
+	scanned := map[string][]Substitution{}
+	for filePath, fileSubsts := range substs {
+		scanned[filePath] = fileSubsts
+	}
+	err = afero.Walk(fs, ".", func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := canceled(opts.getContext(), "scan"); err != nil {
+			return err
+		}
+		if info.IsDir() || matchesAny(excludes, filePath) || !matchesAny(includes, filePath) {
+			return nil
+		}
+		if _, ok := scanned[filePath]; ok {
+			return nil
+		}
+		b, err := afero.ReadFile(fs, filePath)
+		if err != nil {
+			return err
+		}
+		for _, subst := range candidates {
+			if subst.FromTagRE.Match(b) {
+				scanned[filePath] = append(scanned[filePath], subst)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error scanning for files to substitute images in: %w", err)
+	}
+	return scanned, nil
+}
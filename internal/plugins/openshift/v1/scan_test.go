@@ -0,0 +1,92 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+var _ = Describe("scanSubstitutions", func() {
+	var fs machinery.Filesystem
+
+	const (
+		componentPath = "config/components/metrics/proxy.yaml"
+		excludedPath  = "config/components/vendor/proxy.yaml"
+		component     = "image: gcr.io/kubebuilder/kube-rbac-proxy:v0.13.1\n"
+	)
+
+	BeforeEach(func() {
+		fs = machinery.Filesystem{FS: afero.NewMemMapFs()}
+		Expect(afero.WriteFile(fs.FS, componentPath, []byte(component), 0644)).To(Succeed())
+		Expect(afero.WriteFile(fs.FS, excludedPath, []byte(component), 0644)).To(Succeed())
+		Expect(afero.WriteFile(fs.FS, "config/components/metrics/readme.md", []byte(component), 0644)).To(Succeed())
+		Expect(afero.WriteFile(fs.FS, "config/samples/sample.yaml", []byte("kind: Memcached\n"), 0644)).To(Succeed())
+	})
+
+	It("substitutes images in scanned files that are not excluded", func() {
+		opts := defaultImageOptions()
+		opts.scanDir, opts.excludes = true, []string{"config/components/vendor/**"}
+		results, err := replaceImages(fs, opts)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(results).To(ContainElement(SubstitutionResult{
+			Path:     componentPath,
+			Pattern:  `gcr.io/kubebuilder/kube-rbac-proxy[:@][^ \n"']+`,
+			Category: KubeRBACProxyCategory,
+			Image:    "registry.redhat.io/openshift4/ose-kube-rbac-proxy:v" + ocpProductVersion,
+			Count:    1,
+			From:     []string{"gcr.io/kubebuilder/kube-rbac-proxy:v0.13.1"},
+		}))
+		for _, result := range results {
+			Expect(result.Path).NotTo(Equal("config/samples/sample.yaml"))
+		}
+
+		componentOut, err := afero.ReadFile(fs.FS, componentPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(componentOut)).To(Equal("image: registry.redhat.io/openshift4/ose-kube-rbac-proxy:v" + ocpProductVersion + "\n"))
+		for _, path := range []string{excludedPath, "config/components/metrics/readme.md"} {
+			out, err := afero.ReadFile(fs.FS, path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(out)).To(Equal(component), path)
+		}
+	})
+
+	It("does not scan unless enabled", func() {
+		_, err := replaceImages(fs, defaultImageOptions())
+		Expect(err).NotTo(HaveOccurred())
+		componentOut, err := afero.ReadFile(fs.FS, componentPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(componentOut)).To(Equal(component))
+	})
+
+	It("keeps the substitutions of explicit paths", func() {
+		Expect(afero.WriteFile(fs.FS, authProxyPatchPath, []byte(proxyPatch), 0644)).To(Succeed())
+		opts := defaultImageOptions()
+		opts.scanDir = true
+		substs, err := scanSubstitutions(fs.FS, opts, imageSubstitutions(opts))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(substs[authProxyPatchPath]).To(Equal(imageSubstitutions(opts)[authProxyPatchPath]))
+		Expect(substs).To(HaveKey(componentPath))
+	})
+
+	It("rejects invalid globs", func() {
+		opts := defaultImageOptions()
+		opts.scanDir, opts.excludes = true, []string{"config/[a"}
+		_, err := replaceImages(fs, opts)
+		Expect(err).To(MatchError(ContainSubstring("--" + excludeFlag)))
+	})
+})
@@ -0,0 +1,127 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"sigs.k8s.io/kubebuilder/v3/pkg/config"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+
+	"github.com/operator-framework/operator-sdk/internal/plugins/openshift/v1/templates/config/openshift"
+)
+
+const (
+	// openshiftKustomizeResource is the entry added to the default kustomization for config/openshift.
+	openshiftKustomizeResource = "- ../openshift\n"
+	// managerKustomizeResource is the entry openshiftKustomizeResource is added after.
+	managerKustomizeResource = "- ../manager\n"
+)
+
+var (
+	// defaultKustomizationPath is the path of the kustomization that deploys the operator.
+	defaultKustomizationPath = filepath.Join("config", "default", "kustomization.yaml")
+	// consolePluginPath is the path of the scaffolded console plugin manifests.
+	consolePluginPath = filepath.Join("config", "openshift", "consoleplugin.yaml")
+	// templatePath is the path of the scaffolded OpenShift Template.
+	templatePath = filepath.Join("config", "openshift", "template.yaml")
+)
+
+// scaffoldOpenShiftConfig scaffolds a SecurityContextConstraints for the controller manager if withSCC
+// is set, and a console plugin if withConsolePlugin is set, under config/openshift. If overlay is set,
+// config/openshift is a kustomize overlay of config/default that also configures the service CA for
+// webhooks, otherwise config/openshift is added to the default kustomization's resources.
+func scaffoldOpenShiftConfig(fs machinery.Filesystem, c config.Config, withSCC, withConsolePlugin, overlay bool) error {
+	scaffold := machinery.NewScaffold(fs,
+		// NOTE: kubebuilder's default permissions are only for root users
+		machinery.WithDirectoryPermissions(0755),
+		machinery.WithFilePermissions(0644),
+		machinery.WithConfig(c),
+	)
+	builders := []machinery.Builder{
+		&openshift.Kustomization{Overlay: overlay, WithSCC: withSCC, WithConsolePlugin: withConsolePlugin},
+	}
+	if withSCC || withConsolePlugin {
+		builders = append(builders, &openshift.KustomizeConfig{WithSCC: withSCC, WithConsolePlugin: withConsolePlugin})
+	}
+	if withSCC {
+		builders = append(builders, &openshift.SCC{})
+	}
+	if withConsolePlugin {
+		builders = append(builders, &openshift.ConsolePlugin{})
+	}
+	if err := scaffold.Execute(builders...); err != nil {
+		return fmt.Errorf("error scaffolding OpenShift config: %w", err)
+	}
+
+	// The overlay includes config/default, which must not include it in turn.
+	if overlay {
+		return nil
+	}
+	return addOpenShiftKustomizeResource(fs.FS)
+}
+
+// scaffoldTemplate scaffolds an OpenShift Template that deploys the controller manager under config/openshift.
+// It is not added to any kustomization, since it is processed with "oc process" instead.
+func scaffoldTemplate(fs machinery.Filesystem, c config.Config) error {
+	scaffold := machinery.NewScaffold(fs,
+		// NOTE: kubebuilder's default permissions are only for root users
+		machinery.WithDirectoryPermissions(0755),
+		machinery.WithFilePermissions(0644),
+		machinery.WithConfig(c),
+	)
+	if err := scaffold.Execute(&openshift.Template{}); err != nil {
+		return fmt.Errorf("error scaffolding OpenShift Template: %w", err)
+	}
+	return nil
+}
+
+// addOpenShiftKustomizeResource adds config/openshift after config/manager in the default
+// kustomization's resources. A warning is logged if config/manager is not found, since
+// the user must then add config/openshift themselves.
+func addOpenShiftKustomizeResource(fs afero.Fs) error {
+	b, err := afero.ReadFile(fs, defaultKustomizationPath)
+	if errors.Is(err, os.ErrNotExist) {
+		log.Warnf("%s does not exist, add %q to your kustomization's resources to deploy OpenShift config",
+			defaultKustomizationPath, "../openshift")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading %s: %v", defaultKustomizationPath, err)
+	}
+	if bytes.Contains(b, []byte(openshiftKustomizeResource)) {
+		return nil
+	}
+	i := bytes.Index(b, []byte(managerKustomizeResource))
+	if i < 0 {
+		log.Warnf("%s has no %q resource, add %q to its resources to deploy OpenShift config",
+			defaultKustomizationPath, "../manager", "../openshift")
+		return nil
+	}
+	i += len(managerKustomizeResource)
+
+	info, err := fs.Stat(defaultKustomizationPath)
+	if err != nil {
+		return fmt.Errorf("error reading file info of %s: %v", defaultKustomizationPath, err)
+	}
+	out := append(append(append([]byte{}, b[:i]...), openshiftKustomizeResource...), b[i:]...)
+	return writeFile(fs, defaultKustomizationPath, out, info.Mode())
+}
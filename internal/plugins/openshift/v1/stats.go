@@ -0,0 +1,74 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/afero"
+)
+
+// statsRecord is the JSON line appended to --stats-file for each run, which is aggregated by
+// external tooling, so its fields must not be renamed or removed.
+type statsRecord struct {
+	// Project is the name of the project, if known.
+	Project string `json:"project,omitempty"`
+	// DryRun is set if no substitutions were written.
+	DryRun bool `json:"dryRun"`
+	// Files is the number of files processed.
+	Files int `json:"files"`
+	// Replacements is the total number of replacements made.
+	Replacements int `json:"replacements"`
+	// Categories maps substitution categories to the number of replacements they made.
+	// Substitutions without a category are only counted in Replacements.
+	Categories map[string]int `json:"categories"`
+}
+
+// newStatsRecord summarizes results of the project named project.
+func newStatsRecord(project string, dryRun bool, results []SubstitutionResult) statsRecord {
+	record := statsRecord{Project: project, DryRun: dryRun, Categories: map[string]int{}}
+	files := map[string]bool{}
+	for _, result := range results {
+		files[result.Path] = true
+		record.Replacements += result.Count
+		if result.Category != "" {
+			record.Categories[result.Category] += result.Count
+		}
+	}
+	record.Files = len(files)
+	return record
+}
+
+// appendStats appends record to path in fs as a single line of JSON, creating path if it does not exist.
+func appendStats(fs afero.Fs, path string, record statsRecord) error {
+	b, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("error encoding substitution stats: %v", err)
+	}
+	f, err := fs.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("error opening stats file: %v", err)
+	}
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("error writing stats file: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("error writing stats file: %v", err)
+	}
+	return nil
+}
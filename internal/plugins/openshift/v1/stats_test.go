@@ -0,0 +1,64 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+	cfgv3 "sigs.k8s.io/kubebuilder/v3/pkg/config/v3"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+var _ = Describe("Stats", func() {
+
+	Describe("newStatsRecord", func() {
+		It("counts files, replacements, and replacements per category", func() {
+			record := newStatsRecord("memcached-operator", true, []SubstitutionResult{
+				{Path: "Dockerfile", Category: UBIMinimalCategory, Count: 1},
+				{Path: "Dockerfile", Category: GoBuilderCategory, Count: 2},
+				{Path: "Dockerfile", Count: 1},
+				{Path: authProxyPatchPath, Category: KubeRBACProxyCategory},
+			})
+			Expect(record).To(Equal(statsRecord{
+				Project:      "memcached-operator",
+				DryRun:       true,
+				Files:        2,
+				Replacements: 4,
+				Categories:   map[string]int{UBIMinimalCategory: 1, GoBuilderCategory: 2, KubeRBACProxyCategory: 0},
+			}))
+		})
+	})
+
+	Describe("init", func() {
+		It("appends a line of JSON to --stats-file per run outside of --output-dir", func() {
+			fs := machinery.Filesystem{FS: afero.NewMemMapFs()}
+			Expect(afero.WriteFile(fs.FS, "Dockerfile", []byte("FROM gcr.io/distroless/static:nonroot\n"), 0644)).To(Succeed())
+			for range []int{0, 1} {
+				s := &initSubcommand{options: imageOptions{statsFile: "stats.jsonl", outputDir: "out"}}
+				Expect(s.InjectConfig(cfgv3.New())).To(Succeed())
+				Expect(s.PreScaffold(fs)).To(Succeed())
+				Expect(s.Scaffold(fs)).To(Succeed())
+			}
+			b, err := afero.ReadFile(fs.FS, "stats.jsonl")
+			Expect(err).NotTo(HaveOccurred())
+			// The second run substitutes nothing in the files already written to --output-dir.
+			Expect(string(b)).To(Equal(
+				`{"dryRun":false,"files":1,"replacements":1,"categories":{"ansible-operator":0,"helm-operator":0,"ubi-minimal":1}}` + "\n" +
+					`{"dryRun":false,"files":1,"replacements":0,"categories":{"ansible-operator":0,"helm-operator":0,"ubi-minimal":0}}` + "\n"))
+			Expect(afero.Exists(fs.FS, "out/stats.jsonl")).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,105 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+)
+
+// substitutionRule is a user-defined image substitution read from a substitutions file.
+type substitutionRule struct {
+	// Path of the file to apply the substitution to, relative to the project root,
+	// or a glob pattern matching the files to apply it to.
+	Path string `yaml:"path"`
+	// From is a regular expression matching the image to replace.
+	From string `yaml:"from"`
+	// To is the image that replaces each match of From.
+	To string `yaml:"to"`
+	// Requires, if set, is a regular expression that must match a file's content
+	// for the substitution to be applied to the file.
+	Requires string `yaml:"requires,omitempty"`
+}
+
+// loadSubstitutionsFile reads a YAML list of substitution rules from path in fs,
+// ex.
+//
+//	# substitutions.yaml
+//	- path: Dockerfile
+//	  from: quay.io/example/base:[^ \n]+
+//	  to: mirror.example.com/example/base:v1
+//
+// and returns a map of file paths to their substitutions.
+func loadSubstitutionsFile(fs afero.Fs, path string) (map[string][]Substitution, error) {
+	b, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading substitutions file: %v", err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, fmt.Errorf("error parsing substitutions file %s: %v", path, err)
+	}
+	// An empty file has no document node.
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	list := doc.Content[0]
+	if list.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("%s:%d: substitutions file must contain a list of substitutions", path, list.Line)
+	}
+
+	substs := map[string][]Substitution{}
+	for _, item := range list.Content {
+		var rule substitutionRule
+		if err := item.Decode(&rule); err != nil {
+			return nil, fmt.Errorf("%s:%d: error decoding substitution: %v", path, item.Line, err)
+		}
+		if rule.Path == "" || rule.From == "" || rule.To == "" {
+			return nil, fmt.Errorf("%s:%d: substitution must set path, from, and to", path, item.Line)
+		}
+		fromTagRE, err := regexp.Compile(rule.From)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid from pattern %q: %v", path, valueLine(item, "from"), rule.From, err)
+		}
+		var requires *regexp.Regexp
+		if rule.Requires != "" {
+			if requires, err = regexp.Compile(rule.Requires); err != nil {
+				return nil, fmt.Errorf("%s:%d: invalid requires pattern %q: %v", path, valueLine(item, "requires"), rule.Requires, err)
+			}
+		}
+		filePath := filepath.Clean(rule.Path)
+		if err := validateGlob(filePath); err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, valueLine(item, "path"), err)
+		}
+		substs[filePath] = append(substs[filePath], Substitution{FromTagRE: fromTagRE, ToTag: rule.To, Requires: requires})
+	}
+
+	return substs, nil
+}
+
+// valueLine returns the line of key's value in mapping node n, or n's line if key is not found.
+func valueLine(n *yaml.Node, key string) int {
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1].Line
+		}
+	}
+	return n.Line
+}
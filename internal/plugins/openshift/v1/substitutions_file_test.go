@@ -0,0 +1,180 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	cfgv3 "sigs.k8s.io/kubebuilder/v3/pkg/config/v3"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+var _ = Describe("loadSubstitutionsFile", func() {
+	var fs afero.Fs
+
+	const substsPath = "substitutions.yaml"
+
+	BeforeEach(func() {
+		fs = afero.NewMemMapFs()
+	})
+
+	It("loads substitutions grouped by path", func() {
+		Expect(afero.WriteFile(fs, substsPath, []byte(substitutionsFile), 0644)).To(Succeed())
+		substs, err := loadSubstitutionsFile(fs, substsPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(substs).To(HaveLen(2))
+		Expect(substs["Dockerfile"]).To(HaveLen(2))
+		Expect(substs["Dockerfile"][0].FromTagRE.String()).To(Equal(`quay.io/example/base:[^ \n]+`))
+		Expect(substs["Dockerfile"][0].ToTag).To(Equal("mirror.example.com/example/base:v1"))
+		Expect(substs["config/manager/manager.yaml"]).To(HaveLen(1))
+	})
+
+	It("returns no substitutions for an empty file", func() {
+		Expect(afero.WriteFile(fs, substsPath, nil, 0644)).To(Succeed())
+		substs, err := loadSubstitutionsFile(fs, substsPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(substs).To(BeEmpty())
+	})
+
+	It("fails with the file and line of an invalid from pattern", func() {
+		Expect(afero.WriteFile(fs, substsPath, []byte(substitutionsFileInvalidRE), 0644)).To(Succeed())
+		_, err := loadSubstitutionsFile(fs, substsPath)
+		Expect(err).To(MatchError(ContainSubstring(substsPath + ":6: invalid from pattern")))
+	})
+
+	It("fails with the file and line of an invalid path pattern", func() {
+		Expect(afero.WriteFile(fs, substsPath, []byte("- path: config/[default\n  from: foo\n  to: bar\n"), 0644)).To(Succeed())
+		_, err := loadSubstitutionsFile(fs, substsPath)
+		Expect(err).To(MatchError(ContainSubstring(substsPath + ":1: invalid path pattern")))
+	})
+
+	It("loads the precondition of a substitution", func() {
+		const rules = "- path: Dockerfile\n  from: golang:1.20\n  to: golang:1.21\n  requires: go build\n"
+		Expect(afero.WriteFile(fs, substsPath, []byte(rules), 0644)).To(Succeed())
+		substs, err := loadSubstitutionsFile(fs, substsPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(substs["Dockerfile"][0].Requires.String()).To(Equal("go build"))
+	})
+
+	It("fails with the file and line of an invalid requires pattern", func() {
+		const rules = "- path: Dockerfile\n  from: golang:1.20\n  to: golang:1.21\n  requires: go build(\n"
+		Expect(afero.WriteFile(fs, substsPath, []byte(rules), 0644)).To(Succeed())
+		_, err := loadSubstitutionsFile(fs, substsPath)
+		Expect(err).To(MatchError(ContainSubstring(substsPath + ":4: invalid requires pattern")))
+	})
+
+	It("fails if a substitution is incomplete", func() {
+		Expect(afero.WriteFile(fs, substsPath, []byte("- path: Dockerfile\n  from: foo\n"), 0644)).To(Succeed())
+		_, err := loadSubstitutionsFile(fs, substsPath)
+		Expect(err).To(MatchError(ContainSubstring(substsPath + ":1: substitution must set path, from, and to")))
+	})
+
+	It("fails if the file is not a list", func() {
+		Expect(afero.WriteFile(fs, substsPath, []byte("path: Dockerfile\n"), 0644)).To(Succeed())
+		_, err := loadSubstitutionsFile(fs, substsPath)
+		Expect(err).To(MatchError(ContainSubstring("must contain a list of substitutions")))
+	})
+
+	It("appends user substitutions to built-in substitutions of the same file", func() {
+		mfs := machinery.Filesystem{FS: fs}
+		Expect(afero.WriteFile(fs, substsPath, []byte(substitutionsFile), 0644)).To(Succeed())
+		Expect(afero.WriteFile(fs, "Dockerfile", []byte("FROM gcr.io/distroless/static:nonroot\nFROM quay.io/example/base:latest\n"), 0644)).To(Succeed())
+		Expect(afero.WriteFile(fs, "config/default/manager_auth_proxy_patch.yaml", []byte(proxyPatch), 0644)).To(Succeed())
+		Expect(afero.WriteFile(fs, "config/manager/manager.yaml", []byte("image: quay.io/example/sidecar:v0.1.0\n"), 0644)).To(Succeed())
+
+		s := &initSubcommand{options: imageOptions{substitutionsFile: substsPath}}
+		Expect(s.PreScaffold(mfs)).To(Succeed())
+		_, err := replaceImages(mfs, s.options)
+		Expect(err).NotTo(HaveOccurred())
+
+		dockerfileOut, err := afero.ReadFile(fs, "Dockerfile")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(dockerfileOut)).To(Equal("FROM registry.access.redhat.com/ubi8/ubi-minimal:" + ubiMinimalVersion + "\n" +
+			"FROM mirror.example.com/example/base:v1\n"))
+		managerOut, err := afero.ReadFile(fs, "config/manager/manager.yaml")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(managerOut)).To(Equal("image: mirror.example.com/example/sidecar:v0.1.0\n"))
+	})
+
+	It("applies substitutions of a path pattern to every file it matches", func() {
+		mfs := machinery.Filesystem{FS: fs}
+		const rules = "- path: config/default/*_patch.yaml\n  from: quay.io/example/sidecar:v1\n  to: mirror.example.com/sidecar:v1\n" +
+			"- path: config/missing/*.yaml\n  from: quay.io/example/other:v1\n  to: mirror.example.com/other:v1\n"
+		Expect(afero.WriteFile(fs, substsPath, []byte(rules), 0644)).To(Succeed())
+		Expect(afero.WriteFile(fs, "config/default/sidecar_patch.yaml", []byte("image: quay.io/example/sidecar:v1\n"), 0644)).To(Succeed())
+		Expect(afero.WriteFile(fs, "config/default/other_patch.yaml", []byte("replicas: 1\n"), 0644)).To(Succeed())
+		logOut := &bytes.Buffer{}
+		logger := log.New()
+		logger.SetOutput(logOut)
+
+		s := &initSubcommand{options: imageOptions{substitutionsFile: substsPath, failOnNoMatch: true, logger: log.NewEntry(logger)}}
+		Expect(s.InjectConfig(cfgv3.New())).To(Succeed())
+		Expect(s.PreScaffold(mfs)).To(Succeed())
+		err := s.Scaffold(mfs)
+		Expect(err).To(MatchError(ContainSubstring("config/missing/*.yaml: quay.io/example/other:v1")))
+		Expect(err).NotTo(MatchError(ContainSubstring("other_patch.yaml")))
+		Expect(logOut.String()).To(ContainSubstring(`did not match anything in files matching config/missing/*.yaml`))
+
+		b, err := afero.ReadFile(fs, "config/default/sidecar_patch.yaml")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(Equal("image: mirror.example.com/sidecar:v1\n"))
+	})
+
+	It("applies substitutions only to files their preconditions match", func() {
+		mfs := machinery.Filesystem{FS: fs}
+		const rules = "- path: build/*/Dockerfile\n  from: golang:1.20\n  to: mirror.example.com/golang:1.20\n  requires: (?m)^RUN .*go build\n"
+		const goDockerfile = "FROM golang:1.20 as builder\nRUN go build -o manager main.go\n"
+		const ansibleDockerfile = "FROM golang:1.20 as tools\nRUN ansible-galaxy collection install -r requirements.yml\n"
+		Expect(afero.WriteFile(fs, substsPath, []byte(rules), 0644)).To(Succeed())
+		Expect(afero.WriteFile(fs, "build/go/Dockerfile", []byte(goDockerfile), 0644)).To(Succeed())
+		Expect(afero.WriteFile(fs, "build/ansible/Dockerfile", []byte(ansibleDockerfile), 0644)).To(Succeed())
+
+		s := &initSubcommand{options: imageOptions{substitutionsFile: substsPath, failOnNoMatch: true}}
+		Expect(s.InjectConfig(cfgv3.New())).To(Succeed())
+		Expect(s.PreScaffold(mfs)).To(Succeed())
+		// Substitutions whose preconditions do not match a file do not apply to it, so they cannot fail to match.
+		Expect(s.Scaffold(mfs)).To(Succeed())
+
+		b, err := afero.ReadFile(fs, "build/go/Dockerfile")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(Equal("FROM mirror.example.com/golang:1.20 as builder\nRUN go build -o manager main.go\n"))
+		b, err = afero.ReadFile(fs, "build/ansible/Dockerfile")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(Equal(ansibleDockerfile))
+	})
+})
+
+const substitutionsFile = `- path: Dockerfile
+  from: quay.io/example/base:[^ \n]+
+  to: mirror.example.com/example/base:v1
+- path: ./config/manager/manager.yaml
+  from: quay.io/example/sidecar:v0.1.0
+  to: mirror.example.com/example/sidecar:v0.1.0
+- path: Dockerfile
+  from: quay.io/example/other:[^ \n]+
+  to: mirror.example.com/example/other:v1
+`
+
+const substitutionsFileInvalidRE = `- path: Dockerfile
+  from: quay.io/example/base:[^ \n]+
+  to: mirror.example.com/example/base:v1
+- path: Dockerfile
+  to: mirror.example.com/example/other:v1
+  from: quay.io/example/other:[
+`
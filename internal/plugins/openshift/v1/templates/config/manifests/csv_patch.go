@@ -0,0 +1,72 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manifests
+
+import (
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+var _ machinery.Template = &CSVPatch{}
+
+// CSVPatch scaffolds a patch that adds the annotations required by the Red Hat certified catalog
+// to the base ClusterServiceVersion.
+type CSVPatch struct {
+	machinery.TemplateMixin
+	machinery.ProjectNameMixin
+
+	// OCPVersions is the com.redhat.openshift.versions annotation value, ex. "=v4.14" or "v4.14-v4.16".
+	OCPVersions string
+	// Channel is the OLM channel the bundle is published to, ex. "stable", which bundles are built with.
+	Channel string
+}
+
+// SetTemplateDefaults implements machinery.Template
+func (f *CSVPatch) SetTemplateDefaults() error {
+	if f.Path == "" {
+		f.Path = filepath.Join("config", "manifests", "bases", "openshift_csv_patch.yaml")
+	}
+
+	// Infrastructure features are placeholders that users are expected to change.
+	f.IfExistsAction = machinery.SkipFile
+
+	f.TemplateBody = csvPatchTemplate
+
+	return nil
+}
+
+const csvPatchTemplate = `# This patch adds the annotations required to publish the operator in the Red Hat certified catalog.
+# Set each infrastructure feature your operator supports to "true".
+# The default OLM channel is a bundle annotation in bundle/metadata/annotations.yaml, not a CSV annotation.
+# Build bundles with it, ex. "make bundle CHANNELS={{ .Channel }} DEFAULT_CHANNEL={{ .Channel }}".
+apiVersion: operators.coreos.com/v1alpha1
+kind: ClusterServiceVersion
+metadata:
+  name: {{ .ProjectName }}.v0.0.0
+  annotations:
+    # OCP releases the operator supports, either "=v<version>" for a single release or "v<min>-v<max>" for a range.
+    com.redhat.openshift.versions: "{{ .OCPVersions }}"
+    features.operators.openshift.io/disconnected: "false"
+    features.operators.openshift.io/fips-compliant: "false"
+    features.operators.openshift.io/proxy-aware: "false"
+    features.operators.openshift.io/tls-profiles: "false"
+    features.operators.openshift.io/token-auth-aws: "false"
+    features.operators.openshift.io/token-auth-azure: "false"
+    features.operators.openshift.io/token-auth-gcp: "false"
+    features.operators.openshift.io/cnf: "false"
+    features.operators.openshift.io/cni: "false"
+    features.operators.openshift.io/csi: "false"
+`
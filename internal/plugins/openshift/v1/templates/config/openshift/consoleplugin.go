@@ -0,0 +1,186 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openshift
+
+import (
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+var _ machinery.Template = &ConsolePlugin{}
+
+// ConsolePlugin scaffolds a ConsolePlugin, and an nginx Deployment and Service that serve its assets.
+// The nginx image is scaffolded from UBI 8, and substituted like other images in the project.
+type ConsolePlugin struct {
+	machinery.TemplateMixin
+	machinery.ProjectNameMixin
+}
+
+// SetTemplateDefaults implements machinery.Template
+func (f *ConsolePlugin) SetTemplateDefaults() error {
+	if f.Path == "" {
+		f.Path = filepath.Join("config", "openshift", "consoleplugin.yaml")
+	}
+
+	// The console plugin is a starting point that users are expected to change.
+	f.IfExistsAction = machinery.SkipFile
+
+	f.TemplateBody = consolePluginTemplate
+
+	return nil
+}
+
+const consolePluginTemplate = `# This ConsolePlugin is served by an nginx Deployment. Build an image with your
+# plugin's assets in /opt/app-root/src based on the nginx image below, and enable
+# the plugin in the console operator's config once deployed.
+apiVersion: console.openshift.io/v1
+kind: ConsolePlugin
+metadata:
+  labels:
+    app.kubernetes.io/name: consoleplugin
+    app.kubernetes.io/instance: console-plugin
+    app.kubernetes.io/component: console-plugin
+    app.kubernetes.io/created-by: {{ .ProjectName }}
+    app.kubernetes.io/part-of: {{ .ProjectName }}
+    app.kubernetes.io/managed-by: kustomize
+  name: console-plugin
+spec:
+  displayName: {{ .ProjectName }}
+  backend:
+    type: Service
+    service:
+      name: console-plugin
+      # Must match the namespace the operator is deployed to.
+      namespace: system
+      port: 9443
+      basePath: /
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  labels:
+    app.kubernetes.io/name: configmap
+    app.kubernetes.io/instance: console-plugin
+    app.kubernetes.io/component: console-plugin
+    app.kubernetes.io/created-by: {{ .ProjectName }}
+    app.kubernetes.io/part-of: {{ .ProjectName }}
+    app.kubernetes.io/managed-by: kustomize
+  name: console-plugin
+  namespace: system
+data:
+  nginx.conf: |
+    error_log /dev/stdout info;
+    events {}
+    http {
+      access_log /dev/stdout;
+      include /etc/nginx/mime.types;
+      default_type application/octet-stream;
+      keepalive_timeout 65;
+      server {
+        listen 9443 ssl;
+        listen [::]:9443 ssl;
+        ssl_certificate /var/cert/tls.crt;
+        ssl_certificate_key /var/cert/tls.key;
+        root /opt/app-root/src;
+      }
+    }
+---
+apiVersion: v1
+kind: Service
+metadata:
+  annotations:
+    # The OpenShift service CA issues the plugin's serving certificate.
+    service.beta.openshift.io/serving-cert-secret-name: console-plugin-cert
+  labels:
+    app.kubernetes.io/name: service
+    app.kubernetes.io/instance: console-plugin
+    app.kubernetes.io/component: console-plugin
+    app.kubernetes.io/created-by: {{ .ProjectName }}
+    app.kubernetes.io/part-of: {{ .ProjectName }}
+    app.kubernetes.io/managed-by: kustomize
+  name: console-plugin
+  namespace: system
+spec:
+  ports:
+  - name: https
+    port: 9443
+    protocol: TCP
+    targetPort: 9443
+  selector:
+    app.kubernetes.io/name: console-plugin
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    app.kubernetes.io/name: deployment
+    app.kubernetes.io/instance: console-plugin
+    app.kubernetes.io/component: console-plugin
+    app.kubernetes.io/created-by: {{ .ProjectName }}
+    app.kubernetes.io/part-of: {{ .ProjectName }}
+    app.kubernetes.io/managed-by: kustomize
+  name: console-plugin
+  namespace: system
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app.kubernetes.io/name: console-plugin
+  template:
+    metadata:
+      labels:
+        app.kubernetes.io/name: console-plugin
+    spec:
+      securityContext:
+        runAsNonRoot: true
+        seccompProfile:
+          type: RuntimeDefault
+      containers:
+      - name: console-plugin
+        image: registry.access.redhat.com/ubi8/nginx-122:latest
+        ports:
+        - containerPort: 9443
+          protocol: TCP
+        securityContext:
+          allowPrivilegeEscalation: false
+          capabilities:
+            drop:
+            - ALL
+        volumeMounts:
+        - name: console-plugin-cert
+          mountPath: /var/cert
+          readOnly: true
+        - name: nginx-conf
+          mountPath: /etc/nginx/nginx.conf
+          subPath: nginx.conf
+          readOnly: true
+        resources:
+          limits:
+            cpu: 100m
+            memory: 64Mi
+          requests:
+            cpu: 10m
+            memory: 32Mi
+      volumes:
+      - name: console-plugin-cert
+        secret:
+          secretName: console-plugin-cert
+          defaultMode: 420
+      - name: nginx-conf
+        configMap:
+          name: console-plugin
+          defaultMode: 420
+`
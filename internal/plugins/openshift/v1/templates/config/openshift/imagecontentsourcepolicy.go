@@ -0,0 +1,69 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openshift
+
+import (
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+var _ machinery.Template = &ImageContentSourcePolicy{}
+
+// ImageContentSourcePolicy scaffolds an ImageContentSourcePolicy that has a disconnected cluster
+// pull images of Red Hat registries from a mirror registry.
+type ImageContentSourcePolicy struct {
+	machinery.TemplateMixin
+	machinery.ProjectNameMixin
+
+	// Mirrors are the repositories to mirror, in order.
+	Mirrors []RepositoryMirror
+}
+
+// RepositoryMirror maps a source repository to its mirror.
+type RepositoryMirror struct {
+	Source string
+	Mirror string
+}
+
+// SetTemplateDefaults implements machinery.Template
+func (f *ImageContentSourcePolicy) SetTemplateDefaults() error {
+	if f.Path == "" {
+		f.Path = filepath.Join("config", "openshift", "imagecontentsourcepolicy.yaml")
+	}
+
+	f.IfExistsAction = machinery.SkipFile
+
+	f.TemplateBody = imageContentSourcePolicyTemplate
+
+	return nil
+}
+
+const imageContentSourcePolicyTemplate = `# This ImageContentSourcePolicy has a disconnected cluster pull images referenced by digest
+# from the mirror registry. It is cluster configuration, so it is not deployed with the operator;
+# a cluster administrator applies it with "oc apply -f". On OCP 4.13 and later, convert it to
+# an ImageDigestMirrorSet with "oc adm migrate icsp".
+apiVersion: operator.openshift.io/v1alpha1
+kind: ImageContentSourcePolicy
+metadata:
+  name: {{ .ProjectName }}-mirrors
+spec:
+  repositoryDigestMirrors:
+{{- range .Mirrors }}
+  - mirrors:
+    - {{ .Mirror }}
+    source: {{ .Source }}
+{{- end }}
+`
@@ -0,0 +1,103 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openshift
+
+import (
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+var _ machinery.Template = &Kustomization{}
+
+// Kustomization scaffolds a kustomization.yaml for the openshift folder.
+type Kustomization struct {
+	machinery.TemplateMixin
+
+	// Overlay makes the kustomization an overlay of config/default, instead of resources
+	// that config/default includes.
+	Overlay bool
+	// WithSCC adds the SecurityContextConstraints to the kustomization's resources.
+	WithSCC bool
+	// WithConsolePlugin adds the console plugin to the kustomization's resources.
+	WithConsolePlugin bool
+}
+
+// SetTemplateDefaults implements machinery.Template
+func (f *Kustomization) SetTemplateDefaults() error {
+	if f.Path == "" {
+		f.Path = filepath.Join("config", "openshift", "kustomization.yaml")
+	}
+
+	f.IfExistsAction = machinery.SkipFile
+
+	f.TemplateBody = kustomizationTemplate
+
+	return nil
+}
+
+const kustomizationTemplate = `{{- if .Overlay -}}
+# This overlay deploys the operator on OpenShift, layering OpenShift resources on top of config/default.
+# Deploy it with "kustomize build config/openshift" instead of config/default.
+resources:
+- ../default
+{{- else -}}
+# These resources configure the operator for OpenShift.
+resources:
+{{- end }}
+{{- if .WithSCC }}
+# Grants the controller manager's ServiceAccount use of a SecurityContextConstraints.
+- scc.yaml
+{{- end }}
+{{- if .WithConsolePlugin }}
+# Serves an OpenShift console dynamic plugin.
+- consoleplugin.yaml
+{{- end }}
+{{- if .Overlay }}
+
+patches:
+# Has the OpenShift service CA operator issue the webhook Service's serving certificate and inject its
+# CA bundle into webhook configurations. Remove these patches if webhooks use cert-manager instead.
+- patch: |-
+    apiVersion: v1
+    kind: Service
+    metadata:
+      name: webhook-service
+      annotations:
+        service.beta.openshift.io/serving-cert-secret-name: webhook-server-cert
+  target:
+    kind: Service
+    name: .*webhook-service
+- patch: |-
+    - op: add
+      path: /metadata/annotations
+      value:
+        service.beta.openshift.io/inject-cabundle: "true"
+  target:
+    kind: MutatingWebhookConfiguration
+- patch: |-
+    - op: add
+      path: /metadata/annotations
+      value:
+        service.beta.openshift.io/inject-cabundle: "true"
+  target:
+    kind: ValidatingWebhookConfiguration
+{{- end }}
+{{- if or .WithSCC .WithConsolePlugin }}
+
+configurations:
+- kustomizeconfig.yaml
+{{- end }}
+`
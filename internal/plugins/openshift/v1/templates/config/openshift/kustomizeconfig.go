@@ -0,0 +1,68 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openshift
+
+import (
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+var _ machinery.Template = &KustomizeConfig{}
+
+// KustomizeConfig scaffolds a kustomize configuration so that references to
+// the SecurityContextConstraints and console plugin Service are updated when their names are prefixed.
+type KustomizeConfig struct {
+	machinery.TemplateMixin
+
+	// WithSCC updates references to the SecurityContextConstraints.
+	WithSCC bool
+	// WithConsolePlugin updates references to the console plugin Service.
+	WithConsolePlugin bool
+}
+
+// SetTemplateDefaults implements machinery.Template
+func (f *KustomizeConfig) SetTemplateDefaults() error {
+	if f.Path == "" {
+		f.Path = filepath.Join("config", "openshift", "kustomizeconfig.yaml")
+	}
+
+	f.IfExistsAction = machinery.SkipFile
+
+	f.TemplateBody = kustomizeConfigTemplate
+
+	return nil
+}
+
+const kustomizeConfigTemplate = `# This configuration updates references to OpenShift resources
+# when a name prefix or suffix is added.
+nameReference:
+{{- if .WithSCC }}
+- kind: SecurityContextConstraints
+  group: security.openshift.io
+  fieldSpecs:
+  - kind: ClusterRole
+    group: rbac.authorization.k8s.io
+    path: rules/resourceNames
+{{- end }}
+{{- if .WithConsolePlugin }}
+- kind: Service
+  version: v1
+  fieldSpecs:
+  - kind: ConsolePlugin
+    group: console.openshift.io
+    path: spec/backend/service/name
+{{- end }}
+`
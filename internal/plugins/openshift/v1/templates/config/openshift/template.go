@@ -0,0 +1,166 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openshift
+
+import (
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+var _ machinery.Template = &Template{}
+
+// Template scaffolds an OpenShift Template that deploys the controller manager without OLM.
+// The kube-rbac-proxy image parameter defaults to the upstream image, which is substituted
+// like other images in the project.
+type Template struct {
+	machinery.TemplateMixin
+	machinery.ProjectNameMixin
+}
+
+// SetTemplateDefaults implements machinery.Template
+func (f *Template) SetTemplateDefaults() error {
+	if f.Path == "" {
+		f.Path = filepath.Join("config", "openshift", "template.yaml")
+	}
+
+	// The template is a starting point that users are expected to change.
+	f.IfExistsAction = machinery.SkipFile
+
+	f.TemplateBody = templateTemplate
+
+	return nil
+}
+
+const templateTemplate = `# This Template deploys the controller manager without OLM. CRDs and RBAC are not
+# included; apply them with "make install" and "kustomize build config/rbac" first,
+# then process the template:
+#   oc process -f config/openshift/template.yaml -p IMAGE=<image> | oc apply -f -
+apiVersion: template.openshift.io/v1
+kind: Template
+metadata:
+  name: {{ .ProjectName }}
+  annotations:
+    description: Deploys the {{ .ProjectName }} controller manager.
+parameters:
+- name: IMAGE
+  description: Image of the controller manager.
+  value: controller:latest
+  required: true
+- name: NAMESPACE
+  description: Namespace the controller manager is deployed to.
+  value: {{ .ProjectName }}-system
+  required: true
+- name: KUBE_RBAC_PROXY_IMAGE
+  description: Image of the kube-rbac-proxy sidecar that protects the metrics endpoint.
+  value: gcr.io/kubebuilder/kube-rbac-proxy:v0.13.1
+  required: true
+objects:
+- apiVersion: v1
+  kind: Namespace
+  metadata:
+    labels:
+      control-plane: controller-manager
+      app.kubernetes.io/created-by: {{ .ProjectName }}
+      app.kubernetes.io/part-of: {{ .ProjectName }}
+    name: ${NAMESPACE}
+- apiVersion: v1
+  kind: ServiceAccount
+  metadata:
+    labels:
+      app.kubernetes.io/created-by: {{ .ProjectName }}
+      app.kubernetes.io/part-of: {{ .ProjectName }}
+    name: {{ .ProjectName }}-controller-manager
+    namespace: ${NAMESPACE}
+- apiVersion: apps/v1
+  kind: Deployment
+  metadata:
+    labels:
+      control-plane: controller-manager
+      app.kubernetes.io/created-by: {{ .ProjectName }}
+      app.kubernetes.io/part-of: {{ .ProjectName }}
+    name: {{ .ProjectName }}-controller-manager
+    namespace: ${NAMESPACE}
+  spec:
+    replicas: 1
+    selector:
+      matchLabels:
+        control-plane: controller-manager
+    template:
+      metadata:
+        labels:
+          control-plane: controller-manager
+      spec:
+        securityContext:
+          runAsNonRoot: true
+          seccompProfile:
+            type: RuntimeDefault
+        serviceAccountName: {{ .ProjectName }}-controller-manager
+        terminationGracePeriodSeconds: 10
+        containers:
+        - name: kube-rbac-proxy
+          image: ${KUBE_RBAC_PROXY_IMAGE}
+          args:
+          - --secure-listen-address=0.0.0.0:8443
+          - --upstream=http://127.0.0.1:8080/
+          - --logtostderr=true
+          - --v=0
+          ports:
+          - containerPort: 8443
+            name: https
+            protocol: TCP
+          securityContext:
+            allowPrivilegeEscalation: false
+            capabilities:
+              drop:
+              - ALL
+          resources:
+            limits:
+              cpu: 500m
+              memory: 128Mi
+            requests:
+              cpu: 5m
+              memory: 64Mi
+        - name: manager
+          image: ${IMAGE}
+          args:
+          - --health-probe-bind-address=:8081
+          - --metrics-bind-address=127.0.0.1:8080
+          - --leader-elect
+          securityContext:
+            allowPrivilegeEscalation: false
+            capabilities:
+              drop:
+              - ALL
+          livenessProbe:
+            httpGet:
+              path: /healthz
+              port: 8081
+            initialDelaySeconds: 15
+            periodSeconds: 20
+          readinessProbe:
+            httpGet:
+              path: /readyz
+              port: 8081
+            initialDelaySeconds: 5
+            periodSeconds: 10
+          resources:
+            limits:
+              cpu: 500m
+              memory: 128Mi
+            requests:
+              cpu: 10m
+              memory: 64Mi
+`
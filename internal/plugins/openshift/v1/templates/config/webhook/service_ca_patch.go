@@ -0,0 +1,50 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+var _ machinery.Template = &ServiceCAPatch{}
+
+// ServiceCAPatch scaffolds a patch that has OpenShift's service CA operator inject
+// its CA bundle into webhook configurations.
+type ServiceCAPatch struct {
+	machinery.TemplateMixin
+}
+
+// SetTemplateDefaults implements machinery.Template
+func (f *ServiceCAPatch) SetTemplateDefaults() error {
+	if f.Path == "" {
+		f.Path = filepath.Join("config", "webhook", "service_ca_patch.yaml")
+	}
+
+	f.IfExistsAction = machinery.SkipFile
+
+	f.TemplateBody = serviceCAPatchTemplate
+
+	return nil
+}
+
+const serviceCAPatchTemplate = `# This patch has the OpenShift service CA operator inject its CA bundle into a webhook configuration.
+# Do not enable cert-manager CA injection (the [CERTMANAGER] sections) alongside this patch.
+- op: add
+  path: /metadata/annotations
+  value:
+    service.beta.openshift.io/inject-cabundle: "true"
+`
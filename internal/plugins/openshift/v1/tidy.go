@@ -0,0 +1,58 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+)
+
+// goModTidyFunc runs "go mod tidy" in the module rooted at dir.
+type goModTidyFunc func(ctx context.Context, dir string) error
+
+// execGoModTidy runs "go mod tidy" in dir with the go command found in PATH.
+func execGoModTidy(ctx context.Context, dir string) error {
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		return fmt.Errorf("error running go mod tidy for --%s: go command not found: %v", tidyFlag, err)
+	}
+	cmd := exec.CommandContext(ctx, goBin, "mod", "tidy")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("error running go mod tidy for --%s: %v\n%s", tidyFlag, err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+// tidyGoMod runs go mod tidy in the project root if its go.mod was changed by Scaffold or PostScaffold,
+// so that its requirements are reconciled with the edited go directive.
+func (s *initSubcommand) tidyGoMod() error {
+	if !contains(s.scaffolded.report().Modified, "go.mod") {
+		s.options.getLogger().Debugf("Skipping --%s, go.mod was not changed", tidyFlag)
+		return nil
+	}
+	tidy := s.goModTidy
+	if tidy == nil {
+		tidy = execGoModTidy
+	}
+	dir := s.projectRoot
+	if dir == "" {
+		dir = "."
+	}
+	s.options.getLogger().Info("Running go mod tidy")
+	return tidy(s.options.getContext(), dir)
+}
@@ -0,0 +1,100 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+	"github.com/spf13/pflag"
+	cfgv3 "sigs.k8s.io/kubebuilder/v3/pkg/config/v3"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+var _ = Describe("--tidy", func() {
+	var (
+		fs      machinery.Filesystem
+		s       *initSubcommand
+		dirs    []string
+		tidyErr error
+	)
+
+	BeforeEach(func() {
+		fs = machinery.Filesystem{FS: afero.NewMemMapFs()}
+		Expect(afero.WriteFile(fs.FS, "Dockerfile", []byte("FROM golang:1.19 as builder\n"), 0644)).To(Succeed())
+		Expect(afero.WriteFile(fs.FS, "go.mod", []byte("module example.com/m\n\ngo 1.19\n"), 0644)).To(Succeed())
+		dirs, tidyErr = nil, nil
+		s = &initSubcommand{goModTidy: func(_ context.Context, dir string) error {
+			dirs = append(dirs, dir)
+			return tidyErr
+		}}
+	})
+
+	scaffold := func(args ...string) error {
+		flags := pflag.NewFlagSet("init", pflag.ContinueOnError)
+		s.BindFlags(flags)
+		Expect(flags.Parse(append([]string{"--" + tidyFlag}, args...))).To(Succeed())
+		Expect(s.InjectConfig(cfgv3.New())).To(Succeed())
+		Expect(s.PreScaffold(fs)).To(Succeed())
+		Expect(s.Scaffold(fs)).To(Succeed())
+		return s.PostScaffold()
+	}
+
+	It("runs go mod tidy in the project after go.mod is changed", func() {
+		Expect(scaffold("--"+goBuilderVersionFlag, "1.21")).To(Succeed())
+		Expect(dirs).To(Equal([]string{"."}))
+	})
+
+	It("does not run go mod tidy if go.mod is unchanged", func() {
+		Expect(scaffold()).To(Succeed())
+		Expect(dirs).To(BeEmpty())
+	})
+
+	It("returns the error of go mod tidy", func() {
+		tidyErr = errors.New("go mod tidy failed")
+		Expect(scaffold("--"+goBuilderVersionFlag, "1.21")).To(MatchError("go mod tidy failed"))
+	})
+})
+
+var _ = Describe("execGoModTidy", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "tidy")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	It("tidies a module", func() {
+		Expect(os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/m\n\ngo 1.16\n"), 0644)).To(Succeed())
+		Expect(execGoModTidy(context.Background(), dir)).To(Succeed())
+	})
+
+	It("fails with the output of go mod tidy", func() {
+		Expect(os.WriteFile(filepath.Join(dir, "go.mod"), []byte("modul example.com/m\n"), 0644)).To(Succeed())
+		err := execGoModTidy(context.Background(), dir)
+		Expect(err).To(MatchError(ContainSubstring("error running go mod tidy for --" + tidyFlag)))
+		Expect(err).To(MatchError(ContainSubstring("unknown directive: modul")))
+	})
+})
@@ -0,0 +1,125 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/spf13/afero"
+)
+
+// ScaffoldReport lists the files a Scaffold call created and modified, relative to the project root.
+type ScaffoldReport struct {
+	// Created are files that did not exist before Scaffold was called.
+	Created []string
+	// Modified are existing files that Scaffold wrote to.
+	Modified []string
+}
+
+// recordingFs is an afero.Fs that records the files written through it.
+type recordingFs struct {
+	afero.Fs
+
+	created, modified map[string]bool
+}
+
+func newRecordingFs(fs afero.Fs) *recordingFs {
+	return &recordingFs{Fs: fs, created: map[string]bool{}, modified: map[string]bool{}}
+}
+
+// Create implements afero.Fs
+func (fs *recordingFs) Create(name string) (afero.File, error) {
+	existed := fs.exists(name)
+	f, err := fs.Fs.Create(name)
+	if err == nil {
+		fs.record(name, existed)
+	}
+	return f, err
+}
+
+// OpenFile implements afero.Fs
+func (fs *recordingFs) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_APPEND|os.O_CREATE|os.O_TRUNC) == 0 {
+		return fs.Fs.OpenFile(name, flag, perm)
+	}
+	existed := fs.exists(name)
+	f, err := fs.Fs.OpenFile(name, flag, perm)
+	if err == nil {
+		fs.record(name, existed)
+	}
+	return f, err
+}
+
+// Rename implements afero.Fs
+func (fs *recordingFs) Rename(oldname, newname string) error {
+	existed := fs.exists(newname)
+	if err := fs.Fs.Rename(oldname, newname); err != nil {
+		return err
+	}
+	fs.forget(oldname)
+	fs.record(newname, existed)
+	return nil
+}
+
+// Remove implements afero.Fs
+func (fs *recordingFs) Remove(name string) error {
+	if err := fs.Fs.Remove(name); err != nil {
+		return err
+	}
+	// A created file that was removed, like the temporary file of an atomic write, was never created.
+	fs.forget(name)
+	return nil
+}
+
+// exists reports whether name exists, treating errors as not existing.
+func (fs *recordingFs) exists(name string) bool {
+	exists, err := afero.Exists(fs.Fs, name)
+	return err == nil && exists
+}
+
+// record records a write to name, which is created unless it existed before.
+func (fs *recordingFs) record(name string, existed bool) {
+	name = filepath.Clean(name)
+	if fs.created[name] {
+		return
+	}
+	if existed {
+		fs.modified[name] = true
+	} else {
+		fs.created[name] = true
+	}
+}
+
+// forget drops name from the created files, since it no longer exists.
+func (fs *recordingFs) forget(name string) {
+	delete(fs.created, filepath.Clean(name))
+}
+
+// report returns the sorted created and modified files.
+func (fs *recordingFs) report() ScaffoldReport {
+	return ScaffoldReport{Created: sortedKeys(fs.created), Modified: sortedKeys(fs.modified)}
+}
+
+// sortedKeys returns the keys of m in sorted order.
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,55 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+)
+
+var _ = Describe("recordingFs", func() {
+	var fs *recordingFs
+
+	BeforeEach(func() {
+		fs = newRecordingFs(afero.NewMemMapFs())
+		Expect(afero.WriteFile(fs.Fs, "Dockerfile", []byte("FROM foo\n"), 0644)).To(Succeed())
+	})
+
+	It("records new files as created and existing files as modified", func() {
+		Expect(afero.WriteFile(fs, "config/openshift/scc.yaml", []byte("kind: SecurityContextConstraints\n"), 0644)).To(Succeed())
+		Expect(afero.WriteFile(fs, "./Dockerfile", []byte("FROM bar\n"), 0644)).To(Succeed())
+		Expect(afero.WriteFile(fs, "config/openshift/scc.yaml", []byte("kind: SecurityContextConstraints\n"), 0644)).To(Succeed())
+		Expect(fs.report()).To(Equal(ScaffoldReport{
+			Created:  []string{"config/openshift/scc.yaml"},
+			Modified: []string{"Dockerfile"},
+		}))
+	})
+
+	It("records atomic writes without their temporary files", func() {
+		Expect(writeFile(fs, "Dockerfile", []byte("FROM bar\n"), 0644)).To(Succeed())
+		Expect(writeBackup(fs, fileBackup{path: "Dockerfile", b: []byte("FROM foo\n"), mode: 0644})).To(Succeed())
+		Expect(fs.report()).To(Equal(ScaffoldReport{
+			Created:  []string{"Dockerfile" + backupSuffix},
+			Modified: []string{"Dockerfile"},
+		}))
+	})
+
+	It("does not record files opened for reading", func() {
+		_, err := afero.ReadFile(fs, "Dockerfile")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fs.report()).To(Equal(ScaffoldReport{Created: []string{}, Modified: []string{}}))
+	})
+})
@@ -0,0 +1,64 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"fmt"
+	"sync"
+
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+// Transformer applies a custom transformation to the project after images are substituted.
+type Transformer interface {
+	Transform(fs machinery.Filesystem) error
+}
+
+// TransformerFunc adapts a function to a Transformer.
+type TransformerFunc func(fs machinery.Filesystem) error
+
+// Transform calls f(fs).
+func (f TransformerFunc) Transform(fs machinery.Filesystem) error {
+	return f(fs)
+}
+
+var (
+	transformersMu sync.Mutex
+	transformers   []Transformer
+)
+
+// RegisterTransformer adds t to the transformers run by init, in registration order.
+func RegisterTransformer(t Transformer) {
+	transformersMu.Lock()
+	defer transformersMu.Unlock()
+	transformers = append(transformers, t)
+}
+
+// registeredTransformers returns a copy of the registered transformers.
+func registeredTransformers() []Transformer {
+	transformersMu.Lock()
+	defer transformersMu.Unlock()
+	return append([]Transformer(nil), transformers...)
+}
+
+// runTransformers runs each registered transformer on fs, stopping at the first error.
+func runTransformers(fs machinery.Filesystem) error {
+	for i, t := range registeredTransformers() {
+		if err := t.Transform(fs); err != nil {
+			return fmt.Errorf("error running transformer %d: %v", i, err)
+		}
+	}
+	return nil
+}
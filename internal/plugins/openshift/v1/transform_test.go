@@ -0,0 +1,91 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"bytes"
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+	cfgv3 "sigs.k8s.io/kubebuilder/v3/pkg/config/v3"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+var _ = Describe("Transformers", func() {
+	var fs machinery.Filesystem
+
+	BeforeEach(func() {
+		fs = machinery.Filesystem{FS: afero.NewMemMapFs()}
+		Expect(afero.WriteFile(fs.FS, "Dockerfile", []byte(dockerfileAll), 0644)).To(Succeed())
+		Expect(afero.WriteFile(fs.FS, "config/default/manager_auth_proxy_patch.yaml", []byte(proxyPatch), 0644)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		transformers = nil
+	})
+
+	It("runs registered transformers in order after images are substituted", func() {
+		var calls []string
+		RegisterTransformer(TransformerFunc(func(fs machinery.Filesystem) error {
+			b, err := afero.ReadFile(fs.FS, "Dockerfile")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).NotTo(ContainSubstring("gcr.io/distroless"))
+			calls = append(calls, "first")
+			return nil
+		}))
+		RegisterTransformer(TransformerFunc(func(fs machinery.Filesystem) error {
+			calls = append(calls, "second")
+			return afero.WriteFile(fs.FS, "LABELS", []byte("team=example\n"), 0644)
+		}))
+
+		s := &initSubcommand{}
+		Expect(s.InjectConfig(cfgv3.New())).To(Succeed())
+		Expect(s.PreScaffold(fs)).To(Succeed())
+		Expect(s.Scaffold(fs)).To(Succeed())
+		Expect(calls).To(Equal([]string{"first", "second"}))
+		Expect(afero.Exists(fs.FS, "LABELS")).To(BeTrue())
+	})
+
+	It("stops at the first transformer that fails", func() {
+		called := false
+		RegisterTransformer(TransformerFunc(func(machinery.Filesystem) error { return errors.New("bad label") }))
+		RegisterTransformer(TransformerFunc(func(machinery.Filesystem) error {
+			called = true
+			return nil
+		}))
+
+		s := &initSubcommand{}
+		Expect(s.InjectConfig(cfgv3.New())).To(Succeed())
+		Expect(s.PreScaffold(fs)).To(Succeed())
+		Expect(s.Scaffold(fs)).To(MatchError("error running transformer 0: bad label"))
+		Expect(called).To(BeFalse())
+	})
+
+	It("skips transformers in dry-run mode", func() {
+		called := false
+		RegisterTransformer(TransformerFunc(func(machinery.Filesystem) error {
+			called = true
+			return nil
+		}))
+
+		s := &initSubcommand{options: imageOptions{dryRun: true, out: &bytes.Buffer{}}}
+		Expect(s.InjectConfig(cfgv3.New())).To(Succeed())
+		Expect(s.PreScaffold(fs)).To(Succeed())
+		Expect(ExitCode(s.Scaffold(fs))).To(Equal(ExitCodeSubstitutionsNeeded))
+		Expect(called).To(BeFalse())
+	})
+})
@@ -0,0 +1,84 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+
+	"github.com/spf13/afero"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+// upstreamImageREs match images that must not be referenced by an OpenShift project.
+var upstreamImageREs = []*regexp.Regexp{
+	regexp.MustCompile(`gcr.io/kubebuilder/[^ \n"']+`),
+	regexp.MustCompile(`quay.io/operator-framework/[^ \n"']+`),
+	regexp.MustCompile(`gcr.io/distroless/[^ \n"']+`),
+}
+
+// UpstreamImage is a reference to an upstream image found by VerifyImages.
+type UpstreamImage struct {
+	// Path of the file referencing the image.
+	Path string
+	// Line is the 1-based line number of the reference.
+	Line int
+	// Image is the upstream image reference.
+	Image string
+}
+
+func (i UpstreamImage) String() string {
+	return fmt.Sprintf("%s:%d: %s", i.Path, i.Line, i.Image)
+}
+
+// VerifyImages returns every upstream image still referenced by a file that images are
+// substituted in, in path and line order. Files that do not exist are skipped.
+// A project passes verification if no images are returned. Verification stops if ctx is canceled.
+func VerifyImages(ctx context.Context, fs machinery.Filesystem) ([]UpstreamImage, error) {
+	filePaths := []string{}
+	for filePath := range imageSubstitutions(defaultImageOptions()) {
+		filePaths = append(filePaths, filePath)
+	}
+	sort.Strings(filePaths)
+
+	var images []UpstreamImage
+	for _, filePath := range filePaths {
+		if err := canceled(ctx, "verification"); err != nil {
+			return nil, err
+		}
+		b, err := afero.ReadFile(fs.FS, filePath)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, fmt.Errorf("error reading file for verification: %v", err)
+		}
+		// Comments are not references, and are left unchanged by substitutions by default.
+		for i, line := range bytes.Split(blankComments(filePath, b), []byte("\n")) {
+			for _, re := range upstreamImageREs {
+				for _, match := range re.FindAll(line, -1) {
+					images = append(images, UpstreamImage{Path: filePath, Line: i + 1, Image: string(match)})
+				}
+			}
+		}
+	}
+
+	return images, nil
+}
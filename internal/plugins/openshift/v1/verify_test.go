@@ -0,0 +1,80 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+var _ = Describe("VerifyImages", func() {
+	var fs machinery.Filesystem
+
+	const (
+		dockerfilePath = "Dockerfile"
+		proxyPatchPath = "config/default/manager_auth_proxy_patch.yaml"
+	)
+
+	BeforeEach(func() {
+		fs = machinery.Filesystem{FS: afero.NewMemMapFs()}
+	})
+
+	It("reports each upstream image with its file and line", func() {
+		Expect(afero.WriteFile(fs.FS, dockerfilePath, []byte(dockerfileUpstream), 0644)).To(Succeed())
+		Expect(afero.WriteFile(fs.FS, proxyPatchPath, []byte(proxyPatch), 0644)).To(Succeed())
+		images, err := VerifyImages(context.Background(), fs)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(images).To(Equal([]UpstreamImage{
+			{Path: dockerfilePath, Line: 1, Image: "quay.io/operator-framework/ansible-operator:v1.2.3"},
+			{Path: dockerfilePath, Line: 3, Image: "gcr.io/distroless/static:nonroot"},
+			{Path: proxyPatchPath, Line: 11, Image: "gcr.io/kubebuilder/kube-rbac-proxy:v0.5.0"},
+			{Path: proxyPatchPath, Line: 13, Image: "gcr.io/kubebuilder/kube-rbac-proxy:latest"},
+		}))
+		Expect(images[0].String()).To(Equal(dockerfilePath + ":1: quay.io/operator-framework/ansible-operator:v1.2.3"))
+	})
+
+	It("reports nothing after images are replaced", func() {
+		Expect(afero.WriteFile(fs.FS, dockerfilePath, []byte(dockerfileUpstream), 0644)).To(Succeed())
+		Expect(afero.WriteFile(fs.FS, proxyPatchPath, []byte(proxyPatch), 0644)).To(Succeed())
+		_, err := replaceImages(fs, defaultImageOptions())
+		Expect(err).NotTo(HaveOccurred())
+		images, err := VerifyImages(context.Background(), fs)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(images).To(BeEmpty())
+	})
+
+	It("ignores images in comments", func() {
+		dockerfile := "# Pinned from gcr.io/distroless/static@sha256:abc123\nFROM registry.access.redhat.com/ubi8/ubi-minimal:8.8\n"
+		Expect(afero.WriteFile(fs.FS, dockerfilePath, []byte(dockerfile), 0644)).To(Succeed())
+		images, err := VerifyImages(context.Background(), fs)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(images).To(BeEmpty())
+	})
+
+	It("skips missing files", func() {
+		images, err := VerifyImages(context.Background(), fs)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(images).To(BeEmpty())
+	})
+})
+
+const dockerfileUpstream = `FROM quay.io/operator-framework/ansible-operator:v1.2.3
+FROM registry.redhat.io/openshift4/ose-helm-operator:v4.14
+FROM gcr.io/distroless/static:nonroot
+`
@@ -0,0 +1,80 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"fmt"
+	"sort"
+
+	"golang.org/x/mod/semver"
+)
+
+// Versions describes the OCP and UBI versions this plugin tags downstream images with.
+type Versions struct {
+	// OCPVersion is the default OCP release version.
+	OCPVersion string `json:"ocpVersion"`
+	// UBIVersion is the default UBI 8 version.
+	UBIVersion string `json:"ubiVersion"`
+	// UBI9Version is the default UBI 9 version.
+	UBI9Version string `json:"ubi9Version"`
+	// Releases are the OCP releases with known UBI versions, in version order.
+	Releases []ReleaseVersions `json:"releases"`
+}
+
+// ReleaseVersions are the UBI versions known to work with an OCP release.
+type ReleaseVersions struct {
+	OCPVersion  string `json:"ocpVersion"`
+	UBIVersion  string `json:"ubiVersion"`
+	UBI9Version string `json:"ubi9Version"`
+}
+
+// DefaultVersions returns the default OCP and UBI versions and the UBI versions known
+// to work with each OCP release.
+func DefaultVersions() Versions {
+	versions := Versions{
+		OCPVersion:  ocpProductVersion,
+		UBIVersion:  ubiMinimalVersion,
+		UBI9Version: ubi9MinimalVersion,
+	}
+	for ocp, ubi := range ocpUBIVersions {
+		versions.Releases = append(versions.Releases, ReleaseVersions{OCPVersion: ocp, UBIVersion: ubi.ubi8, UBI9Version: ubi.ubi9})
+	}
+	sort.Slice(versions.Releases, func(i, j int) bool {
+		return semver.Compare("v"+versions.Releases[i].OCPVersion, "v"+versions.Releases[j].OCPVersion) < 0
+	})
+	return versions
+}
+
+const (
+	// minSupportedOCPVersion is the earliest OCP release that is not end of life.
+	minSupportedOCPVersion = "4.12"
+	// maxTestedOCPVersion is the latest OCP release this plugin's images are tested with.
+	// Update it with ocpUBIVersions.
+	maxTestedOCPVersion = "4.16"
+)
+
+// ocpVersionWarning returns a warning if OCP release version is end of life or newer than
+// the releases this plugin is tested with, or "" if it is neither.
+func ocpVersionWarning(version string) string {
+	switch {
+	case semver.Compare("v"+version, "v"+minSupportedOCPVersion) < 0:
+		return fmt.Sprintf("OCP %s is end of life, images may be unsupported; use OCP %s or later, "+
+			"or silence this warning with --%s", version, minSupportedOCPVersion, noVersionWarningsFlag)
+	case semver.Compare("v"+version, "v"+maxTestedOCPVersion) > 0:
+		return fmt.Sprintf("OCP %s is newer than the latest release tested with this plugin, %s; "+
+			"silence this warning with --%s", version, maxTestedOCPVersion, noVersionWarningsFlag)
+	}
+	return ""
+}
@@ -0,0 +1,64 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/mod/semver"
+)
+
+var _ = Describe("DefaultVersions", func() {
+	It("returns the defaults and every known OCP release in version order", func() {
+		versions := DefaultVersions()
+		Expect(versions.OCPVersion).To(Equal(ocpProductVersion))
+		Expect(versions.UBIVersion).To(Equal(ubiMinimalVersion))
+		Expect(versions.UBI9Version).To(Equal(ubi9MinimalVersion))
+		Expect(versions.Releases).To(HaveLen(len(ocpUBIVersions)))
+		for i, release := range versions.Releases {
+			Expect(release.UBIVersion).To(Equal(ubiVersionForOCP(release.OCPVersion, 8)))
+			Expect(release.UBI9Version).To(Equal(ubiVersionForOCP(release.OCPVersion, 9)))
+			if i > 0 {
+				Expect(semver.Compare("v"+versions.Releases[i-1].OCPVersion, "v"+release.OCPVersion)).To(Equal(-1))
+			}
+		}
+	})
+})
+
+var _ = Describe("ocpVersionWarning", func() {
+	It("warns about end of life and untested releases", func() {
+		cases := []struct {
+			version string
+			warning string
+		}{
+			{"4.10", "end of life"},
+			{"4.11", "end of life"},
+			{minSupportedOCPVersion, ""},
+			{ocpProductVersion, ""},
+			{maxTestedOCPVersion, ""},
+			{"4.17", "newer than the latest release tested"},
+			{"5.0", "newer than the latest release tested"},
+		}
+		for _, c := range cases {
+			warning := ocpVersionWarning(c.version)
+			if c.warning == "" {
+				Expect(warning).To(BeEmpty(), c.version)
+			} else {
+				Expect(warning).To(ContainSubstring(c.warning), c.version)
+				Expect(warning).To(ContainSubstring("--"+noVersionWarningsFlag), c.version)
+			}
+		}
+	})
+})
@@ -0,0 +1,197 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/kubebuilder/v3/pkg/config"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+	"sigs.k8s.io/kubebuilder/v3/pkg/model/resource"
+	"sigs.k8s.io/kubebuilder/v3/pkg/plugin"
+
+	"github.com/operator-framework/operator-sdk/internal/plugins/openshift/v1/templates/config/webhook"
+)
+
+const (
+	serviceCAFlag = "service-ca"
+
+	// servingCertSecretAnnotation has the service CA operator write a serving certificate for a Service to a Secret.
+	servingCertSecretAnnotation = "service.beta.openshift.io/serving-cert-secret-name"
+	// webhookServerCertSecret is the Secret the manager mounts webhook serving certificates from.
+	webhookServerCertSecret = "webhook-server-cert"
+
+	// serviceCAKustomizePatches registers service_ca_patch.yaml with the webhook kustomization.
+	serviceCAKustomizePatches = `
+patches:
+# [OPENSHIFT] Inject the OpenShift service CA bundle into webhook configurations.
+- path: service_ca_patch.yaml
+  target:
+    kind: MutatingWebhookConfiguration
+- path: service_ca_patch.yaml
+  target:
+    kind: ValidatingWebhookConfiguration
+`
+)
+
+var (
+	webhookServicePath       = filepath.Join("config", "webhook", "service.yaml")
+	webhookKustomizationPath = filepath.Join("config", "webhook", "kustomization.yaml")
+)
+
+var _ plugin.CreateWebhookSubcommand = &createWebhookSubcommand{}
+
+type createWebhookSubcommand struct {
+	config   config.Config
+	resource *resource.Resource
+
+	// serviceCA configures webhooks to use certificates issued by OpenShift's service CA.
+	serviceCA bool
+
+	// ctx cancels image substitutions, if set.
+	ctx context.Context
+}
+
+func (s *createWebhookSubcommand) BindFlags(fs *pflag.FlagSet) {
+	fs.BoolVar(&s.serviceCA, serviceCAFlag, false,
+		"use serving certificates and CA bundle injection from the OpenShift service CA operator "+
+			"instead of cert-manager for webhooks")
+}
+
+func (s *createWebhookSubcommand) InjectConfig(c config.Config) error {
+	s.config = c
+	return nil
+}
+
+func (s *createWebhookSubcommand) InjectResource(res *resource.Resource) error {
+	s.resource = res
+	return nil
+}
+
+// Scaffold re-applies image substitutions after webhook scaffolding, and optionally configures
+// webhooks to use the OpenShift service CA. Projects without webhook manifests are left unchanged.
+func (s *createWebhookSubcommand) Scaffold(fs machinery.Filesystem) error {
+	cfg, err := migrateConfig(s.config)
+	if err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	if cfg.Reversed {
+		log.Debugf("Skipping image substitutions, upstream images were restored")
+	} else {
+		opts := cfg.imageOptions()
+		opts.authProxyOptional = mayOmitAuthProxy(s.config)
+		opts.ProjectType = projectType(s.config)
+		opts.ctx = s.ctx
+		opts.passthrough = passthroughFromEnv()
+		if _, err := replaceImages(fs, opts); err != nil {
+			return err
+		}
+	}
+
+	if !s.serviceCA {
+		return nil
+	}
+	if exists, err := afero.Exists(fs.FS, webhookKustomizationPath); err != nil {
+		return fmt.Errorf("error reading %s: %v", webhookKustomizationPath, err)
+	} else if !exists {
+		log.Debugf("Skipping service CA configuration, %s does not exist", webhookKustomizationPath)
+		return nil
+	}
+
+	if err := annotateWebhookService(fs.FS); err != nil {
+		return err
+	}
+	scaffold := machinery.NewScaffold(fs,
+		// NOTE: kubebuilder's default permissions are only for root users
+		machinery.WithDirectoryPermissions(0755),
+		machinery.WithFilePermissions(0644),
+		machinery.WithConfig(s.config),
+	)
+	if err := scaffold.Execute(&webhook.ServiceCAPatch{}); err != nil {
+		return fmt.Errorf("error scaffolding service CA patch: %w", err)
+	}
+	return addServiceCAKustomizePatches(fs.FS)
+}
+
+// annotateWebhookService has the service CA operator write the webhook Service's serving certificate
+// to the Secret mounted by the manager.
+func annotateWebhookService(fs afero.Fs) error {
+	b, err := afero.ReadFile(fs, webhookServicePath)
+	if errors.Is(err, os.ErrNotExist) {
+		log.Debugf("Skipping serving certificate annotation, %s does not exist", webhookServicePath)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading %s: %v", webhookServicePath, err)
+	}
+	if bytes.Contains(b, []byte(servingCertSecretAnnotation)) {
+		return nil
+	}
+
+	annotation := "    " + servingCertSecretAnnotation + ": " + webhookServerCertSecret + "\n"
+	var i int
+	if i = bytes.Index(b, []byte("\n  annotations:\n")); i >= 0 {
+		i += len("\n  annotations:\n")
+	} else if i = bytes.Index(b, []byte("metadata:\n")); i >= 0 {
+		i += len("metadata:\n")
+		annotation = "  annotations:\n" + annotation
+	} else {
+		log.Warnf("%s has no metadata, add the annotation %s: %s to the webhook Service",
+			webhookServicePath, servingCertSecretAnnotation, webhookServerCertSecret)
+		return nil
+	}
+
+	info, err := fs.Stat(webhookServicePath)
+	if err != nil {
+		return fmt.Errorf("error reading file info of %s: %v", webhookServicePath, err)
+	}
+	out := append(append(append([]byte{}, b[:i]...), annotation...), b[i:]...)
+	return writeFile(fs, webhookServicePath, out, info.Mode())
+}
+
+// addServiceCAKustomizePatches registers service_ca_patch.yaml with the webhook kustomization.
+func addServiceCAKustomizePatches(fs afero.Fs) error {
+	b, err := afero.ReadFile(fs, webhookKustomizationPath)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %v", webhookKustomizationPath, err)
+	}
+	if bytes.Contains(b, []byte("service_ca_patch.yaml")) {
+		return nil
+	}
+	if bytes.HasPrefix(b, []byte("patches:")) || bytes.Contains(b, []byte("\npatches:")) {
+		log.Warnf("%s already has patches, add service_ca_patch.yaml to them for MutatingWebhookConfiguration "+
+			"and ValidatingWebhookConfiguration targets", webhookKustomizationPath)
+		return nil
+	}
+
+	info, err := fs.Stat(webhookKustomizationPath)
+	if err != nil {
+		return fmt.Errorf("error reading file info of %s: %v", webhookKustomizationPath, err)
+	}
+	out := append(bytes.TrimRight(b, "\n"), '\n')
+	out = append(out, serviceCAKustomizePatches...)
+	return writeFile(fs, webhookKustomizationPath, out, info.Mode())
+}
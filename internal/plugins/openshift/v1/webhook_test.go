@@ -0,0 +1,103 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+	cfgv3 "sigs.k8s.io/kubebuilder/v3/pkg/config/v3"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+var _ = Describe("RunCreateWebhook", func() {
+	var fs machinery.Filesystem
+
+	BeforeEach(func() {
+		fs = machinery.Filesystem{FS: afero.NewMemMapFs()}
+	})
+
+	Describe("Scaffold", func() {
+		It("re-applies image substitutions", func() {
+			Expect(afero.WriteFile(fs.FS, "Dockerfile", []byte(dockerfileAll), 0644)).To(Succeed())
+			s := &createWebhookSubcommand{}
+			Expect(s.InjectConfig(cfgv3.New())).To(Succeed())
+			Expect(s.Scaffold(fs)).To(Succeed())
+			dockerfileOut, err := afero.ReadFile(fs.FS, "Dockerfile")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(dockerfileOut)).To(Equal(dockerfileAllExp))
+		})
+
+		It("configures webhooks to use the OpenShift service CA", func() {
+			Expect(afero.WriteFile(fs.FS, webhookServicePath, []byte(webhookService), 0644)).To(Succeed())
+			Expect(afero.WriteFile(fs.FS, webhookKustomizationPath, []byte(webhookKustomization), 0644)).To(Succeed())
+			s := &createWebhookSubcommand{serviceCA: true}
+			Expect(s.InjectConfig(cfgv3.New())).To(Succeed())
+			Expect(s.Scaffold(fs)).To(Succeed())
+			// Scaffolding again must not annotate or patch twice.
+			Expect(s.Scaffold(fs)).To(Succeed())
+
+			serviceOut, err := afero.ReadFile(fs.FS, webhookServicePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(serviceOut)).To(Equal(`apiVersion: v1
+kind: Service
+metadata:
+  annotations:
+    service.beta.openshift.io/serving-cert-secret-name: webhook-server-cert
+  name: webhook-service
+  namespace: system
+`))
+			kustomizationOut, err := afero.ReadFile(fs.FS, webhookKustomizationPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(kustomizationOut)).To(Equal(webhookKustomization + serviceCAKustomizePatches))
+			patchOut, err := afero.ReadFile(fs.FS, "config/webhook/service_ca_patch.yaml")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(patchOut)).To(ContainSubstring("service.beta.openshift.io/inject-cabundle: \"true\"\n"))
+		})
+
+		It("adds to existing Service annotations", func() {
+			const annotated = "kind: Service\nmetadata:\n  annotations:\n    foo: bar\n  name: webhook-service\n"
+			Expect(afero.WriteFile(fs.FS, webhookServicePath, []byte(annotated), 0644)).To(Succeed())
+			Expect(annotateWebhookService(fs.FS)).To(Succeed())
+			serviceOut, err := afero.ReadFile(fs.FS, webhookServicePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(serviceOut)).To(Equal("kind: Service\nmetadata:\n  annotations:\n" +
+				"    service.beta.openshift.io/serving-cert-secret-name: webhook-server-cert\n" +
+				"    foo: bar\n  name: webhook-service\n"))
+		})
+
+		It("does nothing if no webhook manifests were scaffolded", func() {
+			s := &createWebhookSubcommand{serviceCA: true}
+			Expect(s.InjectConfig(cfgv3.New())).To(Succeed())
+			Expect(s.Scaffold(fs)).To(Succeed())
+			Expect(afero.Exists(fs.FS, "config/webhook/service_ca_patch.yaml")).To(BeFalse())
+		})
+	})
+})
+
+const webhookService = `apiVersion: v1
+kind: Service
+metadata:
+  name: webhook-service
+  namespace: system
+`
+
+const webhookKustomization = `resources:
+- manifests.yaml
+- service.yaml
+
+configurations:
+- kustomizeconfig.yaml
+`
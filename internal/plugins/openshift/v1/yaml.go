@@ -0,0 +1,160 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"path/filepath"
+	"sort"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// imageKey is the key of mapping values that YAML-aware substitutions are applied to.
+const imageKey = "image"
+
+// isYAMLFile reports whether filePath has a YAML file extension.
+func isYAMLFile(filePath string) bool {
+	ext := filepath.Ext(filePath)
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// byteRange is the half-open range [start, end) of bytes of a file.
+type byteRange struct {
+	start, end int
+}
+
+// substituteYAML applies subs in order to the values of image fields in every YAML document of
+// content, and returns the result and the matches replaced by each substitution. Comments and
+// other fields are left unchanged, as is the formatting of the file.
+func substituteYAML(content []byte, subs []Substitution) ([]byte, [][][]byte, error) {
+	ranges, err := imageValueRanges(content)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	substMatches := make([][][]byte, len(subs))
+	var (
+		out  []byte
+		last int
+	)
+	for _, r := range ranges {
+		out = append(out, content[last:r.start]...)
+		value, matches := substitute(content[r.start:r.end], subs)
+		for i := range matches {
+			substMatches[i] = append(substMatches[i], matches[i]...)
+		}
+		out = append(out, value...)
+		last = r.end
+	}
+	return append(out, content[last:]...), substMatches, nil
+}
+
+// imageValueRanges returns the ranges of the scalar values of image fields in every YAML document
+// of content, in order. Aliased values are resolved to their anchor's value. Values that do not
+// appear verbatim on their line, such as escaped or multi-line scalars, are skipped.
+func imageValueRanges(content []byte) ([]byteRange, error) {
+	var nodes []*yaml.Node
+	dec := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		var doc yaml.Node
+		if err := dec.Decode(&doc); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		nodes = appendImageValues(nodes, &doc)
+	}
+
+	lineStarts := lineStarts(content)
+	seen := map[*yaml.Node]bool{}
+	var ranges []byteRange
+	for _, n := range nodes {
+		if seen[n] {
+			continue
+		}
+		seen[n] = true
+		if r, ok := valueRange(content, lineStarts, n); ok {
+			ranges = append(ranges, r)
+		}
+	}
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].start < ranges[j].start })
+	return ranges, nil
+}
+
+// lineStarts returns the offset of the start of each line of content.
+func lineStarts(content []byte) []int {
+	starts := []int{0}
+	for i, c := range content {
+		if c == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	return starts
+}
+
+// valueRange returns the range of scalar node n's value in content, whose lines start at lineStarts,
+// or false if the value does not appear verbatim on n's line.
+func valueRange(content []byte, lineStarts []int, n *yaml.Node) (byteRange, bool) {
+	if n.Line < 1 || n.Line > len(lineStarts) || n.Value == "" {
+		return byteRange{}, false
+	}
+	// A node's position is that of its anchor, tag, or opening quote, if any, so
+	// its value is searched for from there to the end of the line.
+	start := lineStarts[n.Line-1]
+	line := content[start:]
+	if i := bytes.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+	offset := runeOffset(line, n.Column-1)
+	i := bytes.Index(line[offset:], []byte(n.Value))
+	if i < 0 {
+		return byteRange{}, false
+	}
+	start += offset + i
+	return byteRange{start: start, end: start + len(n.Value)}, true
+}
+
+// appendImageValues appends the scalar values of image fields in the tree rooted at n to nodes.
+func appendImageValues(nodes []*yaml.Node, n *yaml.Node) []*yaml.Node {
+	if n.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			if value.Kind == yaml.AliasNode && value.Alias != nil {
+				value = value.Alias
+			}
+			if key.Value == imageKey && value.Kind == yaml.ScalarNode {
+				nodes = append(nodes, value)
+			}
+		}
+	}
+	for _, child := range n.Content {
+		nodes = appendImageValues(nodes, child)
+	}
+	return nodes
+}
+
+// runeOffset returns the byte offset of the n-th rune of b, or len(b) if b has fewer runes.
+func runeOffset(b []byte, n int) int {
+	offset := 0
+	for ; n > 0 && offset < len(b); n-- {
+		_, size := utf8.DecodeRune(b[offset:])
+		offset += size
+	}
+	return offset
+}
@@ -0,0 +1,132 @@
+// Copyright 2023 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"regexp"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/afero"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+var _ = Describe("YAML-aware substitution", func() {
+	substs := []Substitution{{FromTagRE: regexp.MustCompile(`gcr.io/kubebuilder/kube-rbac-proxy:[^ \n"']+`), ToTag: "proxy:v2"}}
+
+	Describe("substituteYAML", func() {
+		It("only substitutes the values of image fields in every document", func() {
+			b, matches, err := substituteYAML([]byte(multiDocumentYAML), substs)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(Equal(multiDocumentYAMLExp))
+			Expect(matches).To(HaveLen(1))
+			Expect(matches[0]).To(HaveLen(3))
+		})
+
+		It("substitutes anchored values once", func() {
+			in := "x-proxy: &proxy gcr.io/kubebuilder/kube-rbac-proxy:v0.5.0\n" +
+				"containers:\n- image: *proxy\n- image: *proxy\n"
+			b, matches, err := substituteYAML([]byte(in), substs)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(Equal("x-proxy: &proxy proxy:v2\ncontainers:\n- image: *proxy\n- image: *proxy\n"))
+			Expect(matches[0]).To(HaveLen(1))
+		})
+
+		It("substitutes values after multi-byte characters", func() {
+			in := "{name: é, image: gcr.io/kubebuilder/kube-rbac-proxy:v0.5.0}\n"
+			b, _, err := substituteYAML([]byte(in), substs)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(Equal("{name: é, image: proxy:v2}\n"))
+		})
+
+		It("fails on invalid YAML", func() {
+			_, _, err := substituteYAML([]byte("image: [\n"), substs)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("replaceImages", func() {
+		It("substitutes YAML files on image fields and other files anywhere", func() {
+			fs := machinery.Filesystem{FS: afero.NewMemMapFs()}
+			Expect(afero.WriteFile(fs.FS, "Dockerfile", []byte(dockerfileAll), 0644)).To(Succeed())
+			Expect(afero.WriteFile(fs.FS, authProxyPatchPath, []byte(proxyPatch+"# gcr.io/kubebuilder/kube-rbac-proxy:v0.5.0\n"), 0644)).To(Succeed())
+
+			opts := defaultImageOptions()
+			opts.yamlAware = true
+			_, err := replaceImages(fs, opts)
+			Expect(err).NotTo(HaveOccurred())
+			b, err := afero.ReadFile(fs.FS, authProxyPatchPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(Equal(proxyPatchExp + "# gcr.io/kubebuilder/kube-rbac-proxy:v0.5.0\n"))
+			b, err = afero.ReadFile(fs.FS, "Dockerfile")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).NotTo(ContainSubstring("gcr.io/distroless"))
+		})
+
+		It("reports files that are not valid YAML", func() {
+			fs := machinery.Filesystem{FS: afero.NewMemMapFs()}
+			Expect(afero.WriteFile(fs.FS, authProxyPatchPath, []byte("image: [\n"), 0644)).To(Succeed())
+
+			opts := defaultImageOptions()
+			opts.yamlAware = true
+			_, err := replaceImages(fs, opts)
+			Expect(err).To(MatchError(ContainSubstring("error parsing " + authProxyPatchPath + " for YAML-aware substitution")))
+		})
+	})
+})
+
+const multiDocumentYAML = `# Replaces gcr.io/kubebuilder/kube-rbac-proxy:v0.5.0
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  annotations:
+    upstream: gcr.io/kubebuilder/kube-rbac-proxy:v0.5.0
+spec:
+  template:
+    spec:
+      containers:
+      - name: kube-rbac-proxy
+        image: gcr.io/kubebuilder/kube-rbac-proxy:v0.5.0 # gcr.io/kubebuilder/kube-rbac-proxy:v0.5.0
+---
+apiVersion: v1
+kind: Pod
+spec:
+  containers:
+  - name: quoted
+    image: "gcr.io/kubebuilder/kube-rbac-proxy:v0.13.1"
+  - {name: flow, image: 'gcr.io/kubebuilder/kube-rbac-proxy:latest'}
+`
+
+const multiDocumentYAMLExp = `# Replaces gcr.io/kubebuilder/kube-rbac-proxy:v0.5.0
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  annotations:
+    upstream: gcr.io/kubebuilder/kube-rbac-proxy:v0.5.0
+spec:
+  template:
+    spec:
+      containers:
+      - name: kube-rbac-proxy
+        image: proxy:v2 # gcr.io/kubebuilder/kube-rbac-proxy:v0.5.0
+---
+apiVersion: v1
+kind: Pod
+spec:
+  containers:
+  - name: quoted
+    image: "proxy:v2"
+  - {name: flow, image: 'proxy:v2'}
+`